@@ -0,0 +1,204 @@
+package changelog
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Unreleased is the version heading of the changes since the last release.
+const Unreleased = "Unreleased"
+
+// Format is the layout of a rendered changelog.
+type Format string
+
+// Supported changelog formats.
+const (
+	// Markdown groups changes by conventional commit type, like conventional-changelog.
+	Markdown Format = "markdown"
+	// KeepAChangelog groups changes into the Added, Changed and Fixed sections of
+	// https://keepachangelog.com.
+	KeepAChangelog Format = "keepachangelog"
+)
+
+// IsValid reports whether f is a supported format.
+func (f Format) IsValid() bool {
+	return f == Markdown || f == KeepAChangelog
+}
+
+// group is a changelog section and the commit types it collects.
+type group struct {
+	title string
+	types []string
+}
+
+// markdownGroups are the sections of the markdown format, in order.
+// Commits without a known type end up in "Other Changes".
+var markdownGroups = []group{
+	{"Features", []string{"feat"}},
+	{"Bug Fixes", []string{"fix"}},
+	{"Performance Improvements", []string{"perf"}},
+	{"Reverts", []string{"revert"}},
+	{"Code Refactoring", []string{"refactor"}},
+	{"Documentation", []string{"docs"}},
+	{"Styles", []string{"style"}},
+	{"Tests", []string{"test"}},
+	{"Build System", []string{"build"}},
+	{"Continuous Integration", []string{"ci"}},
+	{"Miscellaneous Chores", []string{"chore"}},
+}
+
+// keepAChangelogGroups are the sections of the Keep a Changelog format, in order.
+// Commits of other types are left out, since they do not affect users.
+var keepAChangelogGroups = []group{
+	{"Added", []string{"feat"}},
+	{"Changed", []string{"perf", "refactor", "revert", ""}},
+	{"Fixed", []string{"fix"}},
+}
+
+// Titles of the sections that do not depend on the commit type.
+const (
+	// breakingChanges lists breaking changes, in addition to their own section.
+	breakingChanges = "Breaking Changes"
+	// otherChanges collects the commits without a known type in the markdown format.
+	otherChanges = "Other Changes"
+)
+
+// Section is a titled list of changes.
+type Section struct {
+	Title   string
+	Entries []Entry
+	// Text replaces the rendered entries when set, e.g. with notes polished by a model.
+	Text string
+}
+
+// Lines renders the entries as Markdown list items, grouped by scope.
+func (s Section) Lines() []string {
+	lines := make([]string, 0, len(s.Entries))
+	for _, e := range s.Entries {
+		description := e.Description
+		if s.Title == breakingChanges && e.BreakingNote != "" {
+			description = e.BreakingNote
+		}
+
+		line := "- "
+		if e.Scope != "" {
+			line += "**" + e.Scope + ":** "
+		}
+		line += description
+		if e.Hash != "" {
+			line += " (" + e.ShortHash() + ")"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Release is the changelog of a single version.
+type Release struct {
+	Version  string
+	Date     time.Time
+	Format   Format
+	Sections []Section
+}
+
+// NewRelease groups entries into the sections of format. Breaking changes are
+// listed in a section of their own too, and entries are sorted by scope within
+// each section while keeping the commit order otherwise.
+func NewRelease(version string, date time.Time, format Format, entries []Entry) *Release {
+	r := &Release{
+		Version: version,
+		Date:    date,
+		Format:  format,
+	}
+
+	var breaking []Entry
+	for _, e := range entries {
+		if e.Breaking {
+			breaking = append(breaking, e)
+		}
+	}
+	if len(breaking) > 0 {
+		r.Sections = append(r.Sections, newSection(breakingChanges, breaking))
+	}
+
+	groups := markdownGroups
+	if format == KeepAChangelog {
+		groups = keepAChangelogGroups
+	}
+
+	known := map[string]bool{}
+	for _, g := range groups {
+		var matched []Entry
+		for _, e := range entries {
+			if slices.Contains(g.types, e.Type) {
+				matched = append(matched, e)
+			}
+		}
+		for _, t := range g.types {
+			known[t] = true
+		}
+		if len(matched) > 0 {
+			r.Sections = append(r.Sections, newSection(g.title, matched))
+		}
+	}
+
+	if format == Markdown {
+		var other []Entry
+		for _, e := range entries {
+			if !known[e.Type] {
+				other = append(other, e)
+			}
+		}
+		if len(other) > 0 {
+			r.Sections = append(r.Sections, newSection(otherChanges, other))
+		}
+	}
+
+	return r
+}
+
+// newSection returns a section with entries sorted by scope.
+func newSection(title string, entries []Entry) Section {
+	entries = slices.Clone(entries)
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		return strings.Compare(a.Scope, b.Scope)
+	})
+	return Section{Title: title, Entries: entries}
+}
+
+// Unreleased reports whether the release collects the changes since the last
+// release rather than a tagged version.
+func (r *Release) Unreleased() bool {
+	return strings.EqualFold(r.Version, Unreleased)
+}
+
+// String renders the release as Markdown.
+func (r *Release) String() string {
+	var sb strings.Builder
+
+	// Unreleased changes have no release date yet
+	date := r.Date.Format(time.DateOnly)
+	switch {
+	case r.Format == KeepAChangelog && r.Unreleased():
+		fmt.Fprintf(&sb, "## [%s]\n", r.Version)
+	case r.Format == KeepAChangelog:
+		fmt.Fprintf(&sb, "## [%s] - %s\n", r.Version, date)
+	case r.Unreleased():
+		fmt.Fprintf(&sb, "## %s\n", r.Version)
+	default:
+		fmt.Fprintf(&sb, "## %s (%s)\n", r.Version, date)
+	}
+
+	for _, s := range r.Sections {
+		fmt.Fprintf(&sb, "\n### %s\n\n", s.Title)
+		if s.Text != "" {
+			sb.WriteString(strings.TrimSpace(s.Text) + "\n")
+			continue
+		}
+		sb.WriteString(strings.Join(s.Lines(), "\n") + "\n")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,217 @@
+package changelog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		subject string
+		body    string
+		want    Entry
+	}{
+		{
+			subject: "feat(cmd): add the pr command",
+			want:    Entry{Hash: "abc", Type: "feat", Scope: "cmd", Description: "add the pr command"},
+		},
+		{
+			subject: "fix: handle empty diffs",
+			want:    Entry{Hash: "abc", Type: "fix", Description: "handle empty diffs"},
+		},
+		{
+			subject: "refactor(core)!: rename Generative",
+			want: Entry{
+				Hash: "abc", Type: "refactor", Scope: "core",
+				Description: "rename Generative", Breaking: true,
+			},
+		},
+		{
+			subject: "feat: drop the v1 config",
+			body:    "Details.\n\nBREAKING CHANGE: move openai.* keys to providers.openai.*",
+			want: Entry{
+				Hash: "abc", Type: "feat", Description: "drop the v1 config",
+				Breaking: true, BreakingNote: "move openai.* keys to providers.openai.*",
+			},
+		},
+		{
+			subject: "Update README.md",
+			want:    Entry{Hash: "abc", Description: "Update README.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			if got := Parse("abc", tt.subject, tt.body); got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+var testEntries = []Entry{
+	Parse("1111111aaaa", "feat(git): add diff sources", ""),
+	Parse("2222222bbbb", "fix: handle empty diffs", ""),
+	Parse("3333333cccc", "feat(cmd)!: add the pr command", ""),
+	Parse("4444444dddd", "docs: document the pr command", ""),
+	Parse("5555555eeee", "Update README.md", ""),
+}
+
+var testDate = time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+func TestReleaseMarkdown(t *testing.T) {
+	got := NewRelease("v1.3.0", testDate, Markdown, testEntries).String()
+	want := `## v1.3.0 (2026-10-18)
+
+### Breaking Changes
+
+- **cmd:** add the pr command (3333333)
+
+### Features
+
+- **cmd:** add the pr command (3333333)
+- **git:** add diff sources (1111111)
+
+### Bug Fixes
+
+- handle empty diffs (2222222)
+
+### Documentation
+
+- document the pr command (4444444)
+
+### Other Changes
+
+- Update README.md (5555555)
+`
+	if got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestReleaseKeepAChangelog(t *testing.T) {
+	r := NewRelease("1.3.0", testDate, KeepAChangelog, testEntries)
+	r.Sections[1].Text = "- Review pull requests.\n"
+
+	want := `## [1.3.0] - 2026-10-18
+
+### Breaking Changes
+
+- **cmd:** add the pr command (3333333)
+
+### Added
+
+- Review pull requests.
+
+### Changed
+
+- Update README.md (5555555)
+
+### Fixed
+
+- handle empty diffs (2222222)
+`
+	if got := r.String(); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPrepend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+
+	if err := Prepend(path, "## v1.0.0 (2026-01-01)\n\n- first\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Prepend(path, "## v1.1.0 (2026-02-01)\n\n- second\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := header + `
+## v1.1.0 (2026-02-01)
+
+- second
+
+## v1.0.0 (2026-01-01)
+
+- first
+`
+	if string(data) != want {
+		t.Errorf("CHANGELOG.md =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestPrependKeepAChangelog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	existing := header + `
+## [Unreleased]
+
+### Added
+
+- pending
+
+## [1.0.0] - 2026-01-01
+
+- first
+`
+	if err := os.WriteFile(path, []byte(existing), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Prepend(path, "## [1.1.0] - 2026-02-01\n\n- second\n"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := header + `
+## [Unreleased]
+
+### Added
+
+- pending
+
+## [1.1.0] - 2026-02-01
+
+- second
+
+## [1.0.0] - 2026-01-01
+
+- first
+`
+	if string(data) != want {
+		t.Errorf("CHANGELOG.md =\n%s\nwant\n%s", data, want)
+	}
+
+	// New unreleased notes replace the Unreleased section
+	if err := Prepend(path, "## [Unreleased]\n\n- next\n"); err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "pending") ||
+		!strings.HasPrefix(string(data), header+"\n## [Unreleased]\n\n- next\n\n## [1.1.0]") {
+		t.Errorf("expected the Unreleased section to be replaced:\n%s", data)
+	}
+}
+
+func TestReleaseUnreleasedHeading(t *testing.T) {
+	for format, want := range map[Format]string{
+		KeepAChangelog: "## [Unreleased]\n",
+		Markdown:       "## Unreleased\n",
+	} {
+		got := NewRelease(Unreleased, testDate, format, testEntries).String()
+		if !strings.HasPrefix(got, want) {
+			t.Errorf("%s heading = %q, want %q", format, strings.SplitN(got, "\n", 2)[0]+"\n", want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package changelog
+
+import (
+	"regexp"
+	"strings"
+)
+
+// subjectPattern matches a conventional commit subject such as
+// "feat(cmd)!: add the pr command".
+var subjectPattern = regexp.MustCompile(`^(\w+)(?:\(([^()]*)\))?(!)?:\s+(.+)$`)
+
+// breakingFooters are the footers describing a breaking change.
+var breakingFooters = []string{"BREAKING CHANGE:", "BREAKING-CHANGE:"}
+
+// Entry is a commit parsed as a conventional commit.
+type Entry struct {
+	// Hash is the commit hash.
+	Hash string
+	// Type is the conventional commit type such as feat or fix, or empty when the
+	// subject does not follow the conventional commit format.
+	Type string
+	// Scope is the optional scope of the change.
+	Scope string
+	// Description is the subject without type and scope.
+	Description string
+	// Breaking is true for breaking changes, marked with ! or a BREAKING CHANGE footer.
+	Breaking bool
+	// BreakingNote is the text of the BREAKING CHANGE footer, if any.
+	BreakingNote string
+}
+
+// Parse parses the subject and body of a commit as a conventional commit.
+// Subjects that do not follow the format become entries without a type.
+func Parse(hash, subject, body string) Entry {
+	e := Entry{
+		Hash:        hash,
+		Description: strings.TrimSpace(subject),
+	}
+
+	if m := subjectPattern.FindStringSubmatch(e.Description); m != nil {
+		e.Type = strings.ToLower(m[1])
+		e.Scope = strings.TrimSpace(m[2])
+		e.Breaking = m[3] == "!"
+		e.Description = strings.TrimSpace(m[4])
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		for _, footer := range breakingFooters {
+			if note, ok := strings.CutPrefix(strings.TrimSpace(line), footer); ok {
+				e.Breaking = true
+				e.BreakingNote = strings.TrimSpace(note)
+			}
+		}
+	}
+
+	return e
+}
+
+// ShortHash returns the first seven characters of the commit hash.
+func (e Entry) ShortHash() string {
+	if len(e.Hash) > 7 {
+		return e.Hash[:7]
+	}
+	return e.Hash
+}
@@ -0,0 +1,74 @@
+package changelog
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// header starts a new changelog file.
+const header = "# Changelog\n\nAll notable changes to this project will be documented in this file.\n"
+
+// unreleasedHeading starts the Unreleased section of a Keep a Changelog file.
+const unreleasedHeading = "## [" + Unreleased + "]"
+
+// Prepend inserts release notes into the changelog file at path, before the
+// most recent release so the file keeps its title and introduction. In a Keep
+// a Changelog file, the notes go after the Unreleased section at the top, or
+// replace it when the notes are unreleased too. The file is created when it
+// does not exist.
+func Prepend(path, notes string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	content := string(data)
+	if strings.TrimSpace(content) == "" {
+		content = header
+	}
+	notes = strings.TrimSpace(notes) + "\n"
+
+	// Insert before the first release heading, or append after the introduction
+	i := nextHeading(content, 0)
+	if i >= 0 && isUnreleased(content[i:]) {
+		end := nextHeading(content, i+1)
+		if isUnreleased(notes) {
+			// Replace the Unreleased section with the new notes
+			if end < 0 {
+				end = len(content)
+			}
+			content = content[:i] + content[end:]
+		} else {
+			i = end
+		}
+	}
+
+	var out string
+	switch {
+	case i < 0 || i >= len(content):
+		out = strings.TrimRight(content, "\n") + "\n\n" + notes
+	default:
+		out = content[:i] + notes + "\n" + content[i:]
+	}
+
+	return os.WriteFile(path, []byte(out), 0o644) //nolint:gosec // changelogs are public files
+}
+
+// nextHeading returns the offset of the first "## " heading of content at or
+// after from, or -1 when there is none.
+func nextHeading(content string, from int) int {
+	if from == 0 && strings.HasPrefix(content, "## ") {
+		return 0
+	}
+	if i := strings.Index(content[from:], "\n## "); i >= 0 {
+		return from + i + 1
+	}
+	return -1
+}
+
+// isUnreleased reports whether s starts with the Unreleased heading of a Keep
+// a Changelog file.
+func isUnreleased(s string) bool {
+	return strings.HasPrefix(s, unreleasedHeading)
+}
@@ -0,0 +1,155 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// versionPattern matches a semantic version with an optional v prefix, pre-release
+// and build metadata.
+var versionPattern = regexp.MustCompile(
+	`^(v?)(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`,
+)
+
+// Version is a semantic version, as used in release tags.
+type Version struct {
+	// Prefix is "v" for tags such as v1.2.3, and empty otherwise.
+	Prefix     string
+	Major      int
+	Minor      int
+	Patch      int
+	PreRelease string
+}
+
+// ParseVersion parses a semantic version such as v1.2.3 or 1.2.3-rc.1.
+func ParseVersion(s string) (Version, error) {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("invalid semantic version %q", s)
+	}
+
+	v := Version{Prefix: m[1], PreRelease: m[5]}
+	var err error
+	if v.Major, err = strconv.Atoi(m[2]); err != nil {
+		return Version{}, fmt.Errorf("invalid semantic version %q: %w", s, err)
+	}
+	if v.Minor, err = strconv.Atoi(m[3]); err != nil {
+		return Version{}, fmt.Errorf("invalid semantic version %q: %w", s, err)
+	}
+	if v.Patch, err = strconv.Atoi(m[4]); err != nil {
+		return Version{}, fmt.Errorf("invalid semantic version %q: %w", s, err)
+	}
+	return v, nil
+}
+
+// String returns the version with its prefix, e.g. v1.2.3.
+func (v Version) String() string {
+	s := fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 depending on whether v is lower than, equal to or
+// higher than w. Pre-releases are lower than their release, and compared as strings
+// among themselves.
+func (v Version) Compare(w Version) int {
+	for _, d := range []int{v.Major - w.Major, v.Minor - w.Minor, v.Patch - w.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	switch {
+	case v.PreRelease == w.PreRelease:
+		return 0
+	case v.PreRelease == "":
+		return 1
+	case w.PreRelease == "":
+		return -1
+	case v.PreRelease < w.PreRelease:
+		return -1
+	}
+	return 1
+}
+
+// sign returns -1, 0 or +1 for the sign of d.
+func sign(d int) int {
+	switch {
+	case d < 0:
+		return -1
+	case d > 0:
+		return 1
+	}
+	return 0
+}
+
+// Bump is the part of a semantic version to increment.
+type Bump int
+
+// Version bumps, from the least to the most significant.
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+// String returns the name of the bump.
+func (b Bump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	}
+	return "none"
+}
+
+// NextBump returns the bump required by entries: major for breaking changes,
+// minor for features and patch for fixes and performance improvements. Other
+// changes, such as docs or chores, do not need a release and give BumpNone.
+func NextBump(entries []Entry) Bump {
+	bump := BumpNone
+	for _, e := range entries {
+		switch {
+		case e.Breaking:
+			return BumpMajor
+		case e.Type == "feat":
+			bump = BumpMinor
+		case (e.Type == "fix" || e.Type == "perf") && bump == BumpNone:
+			bump = BumpPatch
+		}
+	}
+	return bump
+}
+
+// Applied returns the bump actually applied to v for b: before 1.0.0, breaking
+// changes only bump the minor version, as the public API is not stable yet.
+func (v Version) Applied(b Bump) Bump {
+	if b == BumpMajor && v.Major == 0 {
+		return BumpMinor
+	}
+	return b
+}
+
+// Bump returns the next release version, as bumped by v.Applied(b). Pre-releases
+// are dropped.
+func (v Version) Bump(b Bump) Version {
+	next := Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	switch v.Applied(b) {
+	case BumpMajor:
+		next.Major++
+		next.Minor, next.Patch = 0, 0
+	case BumpMinor:
+		next.Minor++
+		next.Patch = 0
+	case BumpPatch:
+		next.Patch++
+	}
+	return next
+}
@@ -0,0 +1,120 @@
+package changelog
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Version
+		wantErr bool
+	}{
+		{in: "v1.2.3", want: Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3}},
+		{in: "0.10.0", want: Version{Minor: 10}},
+		{in: "v2.0.0-rc.1+build.5", want: Version{Prefix: "v", Major: 2, PreRelease: "rc.1"}},
+		{in: "v1.2", wantErr: true},
+		{in: "release-1.2.3", wantErr: true},
+		{in: "v01.2.3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseVersion(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseVersion() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	ordered := []string{"v0.9.0", "v1.0.0-alpha", "v1.0.0-beta", "v1.0.0", "v1.0.1", "v1.2.0", "v2.0.0"}
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := ParseVersion(ordered[i])
+			b, _ := ParseVersion(ordered[j])
+			if got, want := a.Compare(b), sign(i-j); got != want {
+				t.Errorf("%s.Compare(%s) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+}
+
+func TestNextBump(t *testing.T) {
+	tests := []struct {
+		name     string
+		subjects []string
+		want     Bump
+	}{
+		{"no commits", nil, BumpNone},
+		{"fixes and chores", []string{"fix: a", "chore: b", "update docs"}, BumpPatch},
+		{"performance", []string{"perf: a"}, BumpPatch},
+		{"docs and chores only", []string{"docs: a", "chore: b", "ci: c", "update docs"}, BumpNone},
+		{"feature", []string{"fix: a", "feat(cmd): b"}, BumpMinor},
+		{"breaking", []string{"feat: a", "fix!: b"}, BumpMajor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries []Entry
+			for _, s := range tt.subjects {
+				entries = append(entries, Parse("", s, ""))
+			}
+			if got := NextBump(entries); got != tt.want {
+				t.Errorf("NextBump() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	breaking := Parse("", "refactor: a", "BREAKING CHANGE: b")
+	if got := NextBump([]Entry{breaking}); got != BumpMajor {
+		t.Errorf("NextBump() = %s for a BREAKING CHANGE footer, want major", got)
+	}
+}
+
+func TestVersionApplied(t *testing.T) {
+	for _, tt := range []struct {
+		version string
+		bump    Bump
+		want    Bump
+	}{
+		{"v1.2.3", BumpMajor, BumpMajor},
+		{"v0.4.1", BumpMajor, BumpMinor},
+		{"v0.4.1", BumpPatch, BumpPatch},
+	} {
+		v, err := ParseVersion(tt.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := v.Applied(tt.bump); got != tt.want {
+			t.Errorf("%s.Applied(%s) = %s, want %s", tt.version, tt.bump, got, tt.want)
+		}
+	}
+}
+
+func TestVersionBump(t *testing.T) {
+	tests := []struct {
+		version string
+		bump    Bump
+		want    string
+	}{
+		{"v1.2.3", BumpPatch, "v1.2.4"},
+		{"v1.2.3", BumpMinor, "v1.3.0"},
+		{"v1.2.3", BumpMajor, "v2.0.0"},
+		{"v1.2.3", BumpNone, "v1.2.3"},
+		{"0.4.1", BumpMajor, "0.5.0"},
+		{"v2.0.0-rc.1", BumpPatch, "v2.0.1"},
+	}
+
+	for _, tt := range tests {
+		v, err := ParseVersion(tt.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := v.Bump(tt.bump).String(); got != tt.want {
+			t.Errorf("%s.Bump(%s) = %s, want %s", tt.version, tt.bump, got, tt.want)
+		}
+	}
+}
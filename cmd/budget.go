@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/core/token"
+	"github.com/appleboy/CodeGPT/git"
+	"github.com/appleboy/CodeGPT/util"
+
+	"github.com/fatih/color"
+	"github.com/spf13/viper"
+)
+
+// getContextLimit returns the context window of the provider's model in tokens.
+// It uses openai.context_limit when set, then ollama.num_ctx for Ollama, then
+// the model's max context from the capability registry, otherwise
+// token.DefaultContextLimit.
+func getContextLimit(provider core.Platform, model string) int {
+	if limit := viper.GetInt("openai.context_limit"); limit > 0 {
+		return limit
+	}
+	if provider == core.Ollama {
+		if numCtx := viper.GetInt("ollama.num_ctx"); numCtx > 0 {
+			return numCtx
+		}
+	}
+	if caps, err := getCapabilities(model); err == nil && caps.MaxContext > 0 {
+		return caps.MaxContext
+	}
+	return token.DefaultContextLimit
+}
+
+// diffBudget returns how many tokens of diff fit into templateName, rendered with
+// vars, for the model of step, once the template itself and the tokens reserved
+// for the response are accounted for.
+func diffBudget(templateName, step string, vars util.Data) (int, *token.Estimator, error) {
+	provider, model := stepModel(step)
+	estimator := token.NewEstimator(provider, model)
+
+	data := util.Data{"file_diffs": ""}
+	maps.Copy(data, vars)
+	overhead, err := util.GetTemplateByString(templateName, data)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	limit := getContextLimit(provider, model)
+	budget := limit - viper.GetInt("openai.max_tokens") - estimator.Count(overhead)
+	if budget <= 0 {
+		return 0, nil, fmt.Errorf(
+			"context limit of %d tokens leaves no room for the diff, increase openai.context_limit",
+			limit,
+		)
+	}
+	return budget, estimator, nil
+}
+
+// fitDiffWith trims diff so templateName, rendered with vars and the diff, plus
+// the tokens reserved for the response, fits the context limit of the model used
+// by step. It warns about everything that was trimmed.
+func fitDiffWith(diff, templateName, step string, vars util.Data) (string, error) {
+	budget, estimator, err := diffBudget(templateName, step, vars)
+	if err != nil {
+		return "", err
+	}
+
+	out, report := git.TrimDiff(diff, budget, estimator.Count)
+	if !report.Trimmed() {
+		return diff, nil
+	}
+
+	provider, model := stepModel(step)
+	color.Yellow(
+		"The diff exceeds the context limit of %d tokens and was trimmed:",
+		getContextLimit(provider, model),
+	)
+	if report.ContextDropped {
+		color.Yellow("  - dropped unchanged context lines")
+	}
+	if len(report.Collapsed) > 0 {
+		color.Yellow("  - summarized large files: %s", strings.Join(report.Collapsed, ", "))
+	}
+	if len(report.Omitted) > 0 {
+		color.Yellow("  - left out files: %s", strings.Join(report.Omitted, ", "))
+	}
+	return out, nil
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/provider/cache"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// noCache bypasses the response cache for a single run.
+var noCache bool
+
+var cacheClearExpired bool
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+	cacheCmd.AddCommand(cacheStatsCmd)
+
+	cacheClearCmd.Flags().BoolVar(&cacheClearExpired, "expired", false,
+		"only remove responses older than cache.ttl")
+}
+
+// getCacheDir returns the directory of the response cache.
+// It defaults to $HOME/.config/codegpt/.cache/responses.
+func getCacheDir() (string, error) {
+	if dir := viper.GetString("cache.dir"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "codegpt", ".cache", "responses"), nil
+}
+
+// getCacheTTL returns how long cached responses stay valid.
+func getCacheTTL() time.Duration {
+	if viper.IsSet("cache.ttl") {
+		return viper.GetDuration("cache.ttl")
+	}
+	return cache.DefaultTTL
+}
+
+// withCache wraps client for the provider and model with the response cache
+// when cache.enabled is set and the cache is not bypassed with --no_cache.
+func withCache(
+	client core.Generative,
+	provider, model string,
+) (core.Generative, error) {
+	if noCache || !viper.GetBool("cache.enabled") {
+		return client, nil
+	}
+
+	dir, err := getCacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return cache.New(
+		client,
+		cache.WithDir(dir),
+		cache.WithTTL(getCacheTTL()),
+		cache.WithProvider(provider),
+		cache.WithModel(model),
+		cache.WithTemperature(float32(viper.GetFloat64("openai.temperature"))),
+		cache.WithTopP(float32(viper.GetFloat64("openai.top_p"))),
+		cache.WithMaxTokens(viper.GetInt("openai.max_tokens")),
+		cache.WithSystemPrompt(viper.GetString("prompt.system")),
+		cache.WithParams(cacheParams()...),
+	)
+}
+
+// cacheParams returns the request settings that change the response besides the
+// ones known to the cache: penalties, Ollama options and the capability overrides.
+func cacheParams() []string {
+	params := []string{
+		"frequency_penalty=" + viper.GetString("openai.frequency_penalty"),
+		"presence_penalty=" + viper.GetString("openai.presence_penalty"),
+		"ollama.format=" + viper.GetString("ollama.format"),
+		"ollama.num_ctx=" + viper.GetString("ollama.num_ctx"),
+	}
+	// Overrides change the request shape, e.g. whether tools or a system prompt are sent
+	models, _ := json.Marshal(viper.Get("models"))
+	return append(params, "models="+string(models))
+}
+
+// cacheCmd represents the command for managing the response cache.
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local response cache",
+}
+
+// cacheClearCmd removes cached responses.
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove cached responses",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := getCacheDir()
+		if err != nil {
+			return err
+		}
+
+		removed, err := cache.NewStore(dir, getCacheTTL()).Clear(cacheClearExpired)
+		if err != nil {
+			return err
+		}
+		color.Green("Removed %d cached response(s) from %s", removed, dir)
+		return nil
+	},
+}
+
+// cacheStatsCmd shows the number and size of cached responses.
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show response cache statistics",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := getCacheDir()
+		if err != nil {
+			return err
+		}
+
+		stats, err := cache.NewStore(dir, getCacheTTL()).Stats()
+		if err != nil {
+			return err
+		}
+		color.Cyan("Cache directory: %s", stats.Dir)
+		color.Cyan("Enabled: %t", viper.GetBool("cache.enabled"))
+		color.Cyan("Entries: %d (%d expired)", stats.Entries, stats.Expired)
+		color.Cyan("Size: %d bytes", stats.Size)
+		return nil
+	},
+}
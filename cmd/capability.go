@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/appleboy/CodeGPT/core/capability"
+
+	"github.com/spf13/viper"
+)
+
+// getCapabilities returns the capabilities of model from the built-in registry,
+// with the overrides configured under models applied on top.
+func getCapabilities(model string) (capability.Model, error) {
+	var overrides []capability.Override
+	if err := viper.UnmarshalKey("models", &overrides); err != nil {
+		return capability.Model{}, fmt.Errorf("invalid models config: %w", err)
+	}
+	return capability.NewRegistry(overrides...).Lookup(model), nil
+}
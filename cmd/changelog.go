@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/appleboy/CodeGPT/changelog"
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/git"
+	"github.com/appleboy/CodeGPT/prompt"
+	"github.com/appleboy/CodeGPT/util"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	changelogFrom    string
+	changelogTo      string
+	changelogVersion string
+	changelogFormat  string
+	changelogPolish  bool
+	changelogPrepend string
+)
+
+func init() {
+	changelogCmd.PersistentFlags().StringVar(&changelogFrom, "from", "",
+		"start of the range, excluded (default: the latest tag)")
+	changelogCmd.PersistentFlags().StringVar(&changelogTo, "to", "HEAD",
+		"end of the range, included")
+	changelogCmd.PersistentFlags().StringVar(&changelogVersion, "version", "",
+		"version heading of the release notes (default: --to when it is not HEAD, Unreleased otherwise)")
+	changelogCmd.PersistentFlags().StringVar(&changelogFormat, "format", string(changelog.Markdown),
+		"output format, markdown or keepachangelog")
+	changelogCmd.PersistentFlags().BoolVar(&changelogPolish, "polish", false,
+		"ask the model to rewrite every section into user-facing release notes")
+	changelogCmd.PersistentFlags().StringVar(&changelogPrepend, "prepend", "",
+		"prepend the release notes to a changelog file such as CHANGELOG.md")
+	changelogCmd.PersistentFlags().
+		StringVar(&commitModel, "model", "gpt-4o", "specify which OpenAI model to use for polishing")
+	changelogCmd.PersistentFlags().BoolVar(&noCache, "no_cache", false,
+		"bypass the local response cache for this run")
+}
+
+// changelogCmd generates release notes from the conventional commits of a range.
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Generate release notes from conventional commits",
+	Example: `  codegpt changelog --from v1.2.0 --to HEAD
+  codegpt changelog --format keepachangelog --polish --prepend CHANGELOG.md`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := check(cmd.Context()); err != nil {
+			return err
+		}
+
+		format := changelog.Format(changelogFormat)
+		if !format.IsValid() {
+			return fmt.Errorf("unsupported changelog format %q, use markdown or keepachangelog", format)
+		}
+
+		// Default to the changes since the latest tag before --to, or the whole history
+		// without tags
+		rev := changelogTo
+		from := changelogFrom
+		if from == "" {
+			tag, err := git.New().LatestTag(cmd.Context(), changelogTo+"^")
+			if err == nil {
+				from = tag
+			}
+		}
+		if from != "" {
+			rev = from + ".." + changelogTo
+		}
+
+		entries, err := changelogEntries(cmd.Context(), rev)
+		if err != nil {
+			return err
+		}
+
+		version := changelogVersion
+		if version == "" {
+			version = "Unreleased"
+			if changelogTo != "HEAD" {
+				version = changelogTo
+			}
+		}
+		release := changelog.NewRelease(version, time.Now(), format, entries)
+
+		if changelogPolish {
+			if err := polishRelease(cmd, release); err != nil {
+				return err
+			}
+		}
+
+		notes := release.String()
+		if changelogPrepend == "" {
+			fmt.Fprint(color.Output, notes)
+			return nil
+		}
+
+		if err := changelog.Prepend(changelogPrepend, notes); err != nil {
+			return err
+		}
+		color.Cyan("Prepended %d commits of %s to %s", len(entries), rev, changelogPrepend)
+		return nil
+	},
+}
+
+// changelogEntries parses the commits of the revision range rev as conventional commits.
+func changelogEntries(ctx context.Context, rev string) ([]changelog.Entry, error) {
+	commits, err := git.New(git.WithDiffSource(git.Range(rev))).Commits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits found in %s", rev)
+	}
+
+	entries := make([]changelog.Entry, 0, len(commits))
+	for _, c := range commits {
+		entries = append(entries, changelog.Parse(c.Hash, c.Subject, c.Body))
+	}
+	return entries, nil
+}
+
+// polishRelease asks the model to rewrite every section of release into release notes.
+func polishRelease(cmd *cobra.Command, release *changelog.Release) error {
+	provider := core.Platform(viper.GetString("openai.provider"))
+	client, err := GetClient(cmd.Context(), provider)
+	if err != nil {
+		return err
+	}
+	stepClient, err := newStepClients(cmd.Context(), client).get(stepChangelog)
+	if err != nil {
+		return err
+	}
+	ctx, err := systemContext(cmd.Context(), prompt.ChangelogSectionTemplate)
+	if err != nil {
+		return err
+	}
+
+	_, currentModel := stepModel(stepChangelog)
+	color.Green("Polishing release notes using " + currentModel + " model")
+	for i, s := range release.Sections {
+		out, err := util.GetTemplateByString(
+			prompt.ChangelogSectionTemplate,
+			util.Data{
+				"section_title":   s.Title,
+				"section_entries": strings.Join(s.Lines(), "\n"),
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		color.Cyan("Polishing the %s section...", s.Title)
+		resp, err := stepClient.Completion(ctx, out)
+		if err != nil {
+			return err
+		}
+		color.Magenta(resp.Usage.String())
+		release.Sections[i].Text = strings.TrimSpace(resp.Content)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/prompt"
+	"github.com/appleboy/CodeGPT/provider/fake"
+)
+
+// setupHistory commits the staged file of setupRepo, tags it v1.0.0 and adds
+// conventional commits after the tag.
+func setupHistory(t *testing.T) {
+	t.Helper()
+
+	runGit(t, "commit", "-q", "-m", "feat: add hello")
+	runGit(t, "tag", "v1.0.0")
+	for _, subject := range []string{"feat(world): add world", "fix: greet everyone", "chore: tidy"} {
+		runGit(t, "commit", "-q", "--allow-empty", "-m", subject)
+	}
+}
+
+func TestChangelogCommand(t *testing.T) {
+	setupRepo(t)
+	setupHistory(t)
+	cfg := writeConfig(t, nil)
+
+	out, err := executeCommand(t, "changelog", "--config", cfg, "--version", "v1.1.0")
+	if err != nil {
+		t.Fatalf("changelog failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"## v1.1.0 (",
+		"### Features\n\n- **world:** add world (",
+		"### Bug Fixes\n\n- greet everyone (",
+		"### Miscellaneous Chores\n\n- tidy (",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the changelog:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "add hello") {
+		t.Errorf("unexpected commit before the latest tag:\n%s", out)
+	}
+
+	out, err = executeCommand(t, "changelog", "--config", cfg, "--to", "v1.0.0")
+	if err != nil {
+		t.Fatalf("changelog failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "## v1.0.0 (") || !strings.Contains(out, "add hello") {
+		t.Errorf("expected the whole history up to v1.0.0:\n%s", out)
+	}
+}
+
+func TestChangelogCommandPolishAndPrepend(t *testing.T) {
+	dir := setupRepo(t)
+	setupHistory(t)
+	cfg := writeConfig(t, []fake.Response{
+		{Template: prompt.ChangelogSectionTemplate, Content: "- Polished notes."},
+	})
+	file := filepath.Join(dir, "CHANGELOG.md")
+
+	out, err := executeCommand(t,
+		"changelog", "--config", cfg, "--from", "v1.0.0", "--format", "keepachangelog",
+		"--polish", "--prepend", file,
+	)
+	if err != nil {
+		t.Fatalf("changelog failed: %v\n%s", err, out)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes := string(data)
+	for _, want := range []string{
+		"# Changelog\n",
+		"## [Unreleased]\n",
+		"### Added\n\n- Polished notes.\n",
+		"### Fixed\n\n- Polished notes.\n",
+	} {
+		if !strings.Contains(notes, want) {
+			t.Errorf("expected %q in CHANGELOG.md:\n%s", want, notes)
+		}
+	}
+	if strings.Contains(notes, "tidy") {
+		t.Errorf("unexpected chore in the keepachangelog format:\n%s", notes)
+	}
+}
+
+func TestChangelogCommandInvalidFormat(t *testing.T) {
+	setupRepo(t)
+	setupHistory(t)
+	cfg := writeConfig(t, nil)
+
+	_, err := executeCommand(t, "changelog", "--config", cfg, "--format", "html")
+	if err == nil || !strings.Contains(err.Error(), `unsupported changelog format "html"`) {
+		t.Fatalf("expected an unsupported format error, got %v", err)
+	}
+}
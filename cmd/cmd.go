@@ -19,6 +19,9 @@ var rootCmd = &cobra.Command{
 	Short:        "A git prepare-commit-msg hook using ChatGPT",
 	SilenceUsage: true,
 	Args:         cobra.MaximumNArgs(1),
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		usageCommand = cmd.Name()
+	},
 }
 
 // Used for flags.
@@ -30,12 +33,13 @@ var (
 
 const (
 	GITHUB = "github"
+	GITLAB = "gitlab"
 	DRONE  = "drone"
 )
 
 // sensitiveConfigKeys lists the config keys that should be stored in the
 // secure credential store rather than in the plaintext YAML config file.
-var sensitiveConfigKeys = []string{"openai.api_key", "gemini.api_key"}
+var sensitiveConfigKeys = []string{"openai.api_key", "gemini.api_key", "github.token", "gitlab.token"}
 
 // migrateCredentialsToStore moves any plaintext API keys found in the YAML
 // config into the secure credential store and clears them from the config file.
@@ -85,8 +89,13 @@ func init() {
 	rootCmd.AddCommand(commitCmd)
 	rootCmd.AddCommand(hookCmd)
 	rootCmd.AddCommand(reviewCmd)
+	rootCmd.AddCommand(prCmd)
+	rootCmd.AddCommand(changelogCmd)
+	rootCmd.AddCommand(releaseCmd)
 	rootCmd.AddCommand(CompletionCmd)
 	rootCmd.AddCommand(promptCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(usageCmd)
 
 	// hide completion command
 	rootCmd.CompletionOptions.HiddenDefaultCmd = true
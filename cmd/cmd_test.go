@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/provider/fake"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// setupRepo creates a git repository with a staged file in a temporary
+// directory, changes to it and points HOME to an empty directory.
+func setupRepo(t *testing.T) string {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	runGit(t, "init", "-q")
+	runGit(t, "config", "user.name", "CodeGPT")
+	runGit(t, "config", "user.email", "codegpt@example.com")
+	if err := os.WriteFile("hello.go", []byte("package main\n\nfunc hello() {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "add", "hello.go")
+	return dir
+}
+
+// runGit runs git with args in the current directory and returns its output.
+func runGit(t *testing.T, args ...string) string {
+	t.Helper()
+
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+// writeConfig writes a config file using the fake provider with the given
+// responses and returns its path.
+func writeConfig(t *testing.T, responses []fake.Response) string {
+	t.Helper()
+
+	data, err := json.Marshal(fake.Fixture{Responses: responses})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "fixture.json")
+	if err := os.WriteFile(fixture, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := "openai:\n  provider: fake\nfake:\n  fixture: " + fixture + "\n"
+	path := filepath.Join(dir, "codegpt.yaml")
+	if err := os.WriteFile(path, []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// appendConfig appends yaml to the config file written by writeConfig.
+func appendConfig(t *testing.T, path, yaml string) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(yaml); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// executeCommand runs the root command with args and returns the colored
+// output it printed. Flags and settings kept by earlier runs are reset first.
+func executeCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	preview, noConfirm, promptOnly, noCache = false, false, false, false
+	diffUnstaged, diffBase, prBase, prFile = false, "", "main", ""
+	changelogFrom, changelogTo, changelogVersion = "", "HEAD", ""
+	changelogFormat, changelogPolish, changelogPrepend = "markdown", false, ""
+	releaseVersion, releaseDryRun = "", false
+	commitSplit, commitTrailers, commitAmend = false, nil, false
+	for _, c := range []*cobra.Command{commitCmd, reviewCmd, prCmd} {
+		// Flags bound to settings
+		for _, name := range []string{
+			"stream", "history", "no_verify", "signoff", "gpg_sign", "gpg_key", "author", "allow_empty",
+			"format", "fail_on", "post",
+		} {
+			if f := c.PersistentFlags().Lookup(name); f != nil {
+				_ = f.Value.Set(f.DefValue)
+				f.Changed = false
+			}
+		}
+		// Forget where -- was found by the previous run, keeping cobra's
+		// ContinueOnError handling
+		c.Flags().Init(c.Name(), 0)
+	}
+	viper.Set("prompt.folder", "")
+	viper.Set("openai.max_tokens", nil)
+
+	var buf bytes.Buffer
+	output, noColor := color.Output, color.NoColor
+	color.Output, color.NoColor = &buf, true
+	t.Cleanup(func() {
+		color.Output, color.NoColor = output, noColor
+		rootCmd.SetErr(nil)
+	})
+
+	rootCmd.SetArgs(args)
+	rootCmd.SetErr(&buf)
+	err := rootCmd.ExecuteContext(context.Background())
+	return buf.String(), err
+}
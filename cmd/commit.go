@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"html"
 	"io"
@@ -41,6 +42,7 @@ var (
 
 	templateVars     []string
 	templateVarsFile string
+	commitTrailers   []string
 
 	defaultTimeout = 30 * time.Second
 	noConfirm      = false
@@ -70,6 +72,14 @@ func init() {
 		StringVar(&templateVarsFile, "template_vars_file", "", "specify file containing template variables")
 	commitCmd.PersistentFlags().BoolVar(&commitAmend, "amend", false,
 		"amend the previous commit instead of creating a new one")
+	commitCmd.PersistentFlags().StringArrayVar(&commitTrailers, "trailer", []string{},
+		"add a trailer such as \"Reviewed-by: Jane Doe <jane@example.com>\", in addition to git.trailers")
+	commitCmd.PersistentFlags().BoolVar(&commitSplit, "split", false,
+		"split the staged changes into several commits, each with its own message")
+	commitCmd.PersistentFlags().BoolVar(&diffUnstaged, "unstaged", false,
+		"use unstaged changes instead of staged changes, requires --prompt_only")
+	commitCmd.PersistentFlags().StringVar(&diffBase, "base", "",
+		"use changes on the current branch since it forked from <ref>, requires --prompt_only")
 	commitCmd.PersistentFlags().
 		DurationVarP(&timeout, "timeout", "t", defaultTimeout, "set API request timeout duration")
 	commitCmd.PersistentFlags().BoolVar(&promptOnly, "prompt_only", false,
@@ -78,8 +88,43 @@ func init() {
 		"skip all confirmation prompts")
 	commitCmd.PersistentFlags().Bool("stream", false,
 		"enable streaming output for real-time token display")
+	commitCmd.PersistentFlags().BoolVar(&noCache, "no_cache", false,
+		"bypass the local response cache for this run")
+	commitCmd.PersistentFlags().Int("history", 0,
+		"show the model the last <n> commit messages as examples of the repository style")
+	commitCmd.PersistentFlags().Bool("no_verify", true,
+		"skip the pre-commit and commit-msg hooks, use --no_verify=false to run them")
+	commitCmd.PersistentFlags().Bool("signoff", true,
+		"add a Signed-off-by trailer, use --signoff=false to leave it out")
+	commitCmd.PersistentFlags().BoolP("gpg_sign", "S", false,
+		"sign the commit with your GPG or SSH key")
+	commitCmd.PersistentFlags().String("gpg_key", "",
+		"sign the commit with the given key ID, implies --gpg_sign")
+	commitCmd.PersistentFlags().String("author", "",
+		"override the commit author, e.g. \"Jane Doe <jane@example.com>\"")
+	commitCmd.PersistentFlags().Bool("allow_empty", false,
+		"allow a commit without changes")
 	_ = viper.BindPFlag("openai.stream", commitCmd.PersistentFlags().Lookup("stream"))
 	_ = viper.BindPFlag("output.file", commitCmd.PersistentFlags().Lookup("file"))
+	_ = viper.BindPFlag("history.count", commitCmd.PersistentFlags().Lookup("history"))
+	_ = viper.BindPFlag("git.no_verify", commitCmd.PersistentFlags().Lookup("no_verify"))
+	_ = viper.BindPFlag("git.signoff", commitCmd.PersistentFlags().Lookup("signoff"))
+	_ = viper.BindPFlag("git.gpg_sign", commitCmd.PersistentFlags().Lookup("gpg_sign"))
+	_ = viper.BindPFlag("git.gpg_key", commitCmd.PersistentFlags().Lookup("gpg_key"))
+	_ = viper.BindPFlag("git.author", commitCmd.PersistentFlags().Lookup("author"))
+	_ = viper.BindPFlag("git.allow_empty", commitCmd.PersistentFlags().Lookup("allow_empty"))
+}
+
+// commitOptions returns the git options controlling how commits are created.
+func commitOptions() []git.Option {
+	return []git.Option{
+		git.WithNoVerify(viper.GetBool("git.no_verify")),
+		git.WithSignoff(viper.GetBool("git.signoff")),
+		git.WithGPGSign(viper.GetBool("git.gpg_sign")),
+		git.WithSigningKey(viper.GetString("git.gpg_key")),
+		git.WithAuthor(viper.GetString("git.author")),
+		git.WithAllowEmpty(viper.GetBool("git.allow_empty")),
+	}
 }
 
 func callCompletion(
@@ -99,223 +144,399 @@ func callCompletion(
 	return client.Completion(ctx, content)
 }
 
-// commitCmd represents the commit command.
-var commitCmd = &cobra.Command{
-	Use:   "commit",
-	Short: "Automatically generate commit message",
-	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := check(cmd.Context()); err != nil {
-			return err
+// commitTemplateData returns the template variables given with --template_vars and
+// --template_vars_file, and the issue key found in the branch name.
+func commitTemplateData(ctx context.Context) (util.Data, error) {
+	data := util.Data{}
+	// Add the issue key of the current branch, an empty pattern disables it
+	pattern := git.DefaultIssuePattern
+	if viper.IsSet("git.issue_pattern") {
+		pattern = viper.GetString("git.issue_pattern")
+	}
+	if pattern != "" {
+		branch, err := git.New().CurrentBranch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		issueKey, err := git.IssueKey(pattern, branch)
+		if err != nil {
+			return nil, err
 		}
+		data[prompt.IssueKeyKey] = issueKey
+	}
 
-		g := git.New(
-			git.WithDiffUnified(viper.GetInt("git.diff_unified")),
-			git.WithExcludeList(viper.GetStringSlice("git.exclude_list")),
-			git.WithEnableAmend(commitAmend),
-		)
-		diff, err := g.DiffFiles(cmd.Context())
+	// Add template variables
+	if vars := util.ConvertToMap(templateVars); len(vars) > 0 {
+		maps.Copy(data, vars)
+	}
+
+	// Add template variables from file
+	if templateVarsFile != "" {
+		allENV, err := godotenv.Read(templateVarsFile)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		for k, v := range allENV {
+			data[k] = v
 		}
+	}
+	return data, nil
+}
 
-		// Update the OpenAI client request timeout if the timeout value is greater than the default openai.timeout
-		if timeout > viper.GetDuration("openai.timeout") ||
-			timeout != defaultTimeout {
-			viper.Set("openai.timeout", timeout)
+// renderCommitMessage renders the commit message template, git.template_file or
+// git.template_string when set, with data.
+func renderCommitMessage(data util.Data) (string, error) {
+	if viper.GetString("git.template_file") != "" {
+		format, err := os.ReadFile(viper.GetString("git.template_file"))
+		if err != nil {
+			return "", err
 		}
+		return util.NewTemplateByString(string(format), data)
+	}
+	if viper.GetString("git.template_string") != "" {
+		return util.NewTemplateByString(viper.GetString("git.template_string"), data)
+	}
+	return util.GetTemplateByString(git.CommitMessageTemplate, data)
+}
 
-		// Check provider
-		provider := core.Platform(viper.GetString("openai.provider"))
-		client, err := GetClient(cmd.Context(), provider)
-		if err != nil && !promptOnly {
-			return err
+// addTrailers renders the trailers of git.trailers and --trailer with data and
+// appends them to message. Trailers with an empty value, such as a missing issue
+// key, are skipped.
+func addTrailers(ctx context.Context, message string, data util.Data) (string, error) {
+	var trailers []string
+	for _, tmpl := range append(viper.GetStringSlice("git.trailers"), commitTrailers...) {
+		trailer, err := util.NewTemplateByString(tmpl, data)
+		if err != nil {
+			return "", fmt.Errorf("failed to render trailer %q: %w", tmpl, err)
+		}
+		key, value, ok := strings.Cut(html.UnescapeString(trailer), ":")
+		if !ok {
+			return "", fmt.Errorf("invalid trailer %q, expected \"Key: value\"", tmpl)
 		}
+		if value = strings.TrimSpace(value); value != "" {
+			trailers = append(trailers, strings.TrimSpace(key)+": "+value)
+		}
+	}
 
-		currentModel := viper.GetString("openai.model")
-		color.Green("Summarizing commit message using " + currentModel + " model")
+	return git.New().InterpretTrailers(ctx, message, trailers)
+}
 
-		data := util.Data{}
-		// Add template variables
-		if vars := util.ConvertToMap(templateVars); len(vars) > 0 {
-			maps.Copy(data, vars)
+// generateCommitMessage generates the commit message of diff with the steps:
+// the summary, title and conventional commit prefix of the changes, rendered with
+// the commit message template, translated to output.lang and followed by the
+// configured trailers. Values already set in data, such as template variables,
+// are not generated again, the generated ones are added to data. With
+// --prompt_only the summarize prompt is printed and an empty message returned.
+func generateCommitMessage(
+	ctx context.Context,
+	steps *stepClients,
+	diff string,
+	data util.Data,
+	history string,
+) (string, error) {
+	// Summarize large changesets file by file, the per-file summaries
+	// are then used to generate the title and the conventional commit prefix
+	if _, ok := data[prompt.SummarizeMessageKey]; !ok {
+		files := git.SplitDiff(diff)
+		vars := util.Data{prompt.CommitHistoryKey: history}
+		mapReduce, err := useMapReduce(diff, files, prompt.SummarizeFileDiffTemplate, stepSummarize, vars)
+		if err != nil {
+			return "", err
 		}
+		if mapReduce {
+			prompts, err := renderFilePrompts(files, prompt.SummarizeFileDiffTemplate, stepSummarize, vars)
+			if err != nil {
+				return "", err
+			}
+
+			// Determine if the user wants to use the prompt only
+			if promptOnly {
+				printFilePrompts(files, prompts)
+				return "", nil
+			}
 
-		// Add template variables from file
-		if templateVarsFile != "" {
-			allENV, err := godotenv.Read(templateVarsFile)
+			stepCtx, err := systemContext(ctx, prompt.SummarizeFileDiffTemplate)
 			if err != nil {
-				return err
+				return "", err
 			}
-			for k, v := range allENV {
-				data[k] = v
+			stepClient, err := steps.get(stepSummarize)
+			if err != nil {
+				return "", err
 			}
-		}
 
-		// Get code review message from diff data
-		if _, ok := data[prompt.SummarizeMessageKey]; !ok {
-			out, err := util.GetTemplateByString(
-				prompt.SummarizeFileDiffTemplate,
-				util.Data{
-					"file_diffs": diff,
-				},
-			)
+			color.Cyan("Summarizing git diff of %d files...", len(files))
+			results, err := completeFiles(stepCtx, stepClient.Completion, files, prompts)
 			if err != nil {
-				return err
+				return "", err
 			}
+			printFileUsage(results)
 
-			// Determine if the user wants to use the prompt only
-			if promptOnly {
-				color.Yellow("====================Prompt========================")
-				color.Yellow("\n" + strings.TrimSpace(out) + "\n\n")
-				color.Yellow("==================================================")
-				return nil
+			points := make([]string, 0, len(results))
+			for _, r := range results {
+				points = append(points, strings.TrimSpace(r.resp.Content))
 			}
+			data[prompt.SummarizeMessageKey] = strings.Join(points, "\n")
+		}
+	}
+
+	// Get code review message from diff data
+	if _, ok := data[prompt.SummarizeMessageKey]; !ok {
+		// Trim the diff so the request fits the model's context window
+		diff, err := fitDiffWith(
+			diff, prompt.SummarizeFileDiffTemplate, stepSummarize,
+			util.Data{prompt.CommitHistoryKey: history},
+		)
+		if err != nil {
+			return "", err
+		}
+
+		out, err := util.GetTemplateByString(
+			prompt.SummarizeFileDiffTemplate,
+			util.Data{
+				"file_diffs":            diff,
+				prompt.CommitHistoryKey: history,
+			},
+		)
+		if err != nil {
+			return "", err
+		}
+
+		// Determine if the user wants to use the prompt only
+		if promptOnly {
+			color.Yellow("====================Prompt========================")
+			color.Yellow("\n" + strings.TrimSpace(out) + "\n\n")
+			color.Yellow("==================================================")
+			return "", nil
+		}
+
+		// Get summarized comment from diff data
+		stepCtx, err := systemContext(ctx, prompt.SummarizeFileDiffTemplate)
+		if err != nil {
+			return "", err
+		}
+		stepClient, err := steps.get(stepSummarize)
+		if err != nil {
+			return "", err
+		}
+		color.Cyan("Summarizing git diff...")
+		resp, err := callCompletion(stepCtx, stepClient, out, os.Stdout)
+		if err != nil {
+			return "", err
+		}
+		data[prompt.SummarizeMessageKey] = strings.TrimSpace(resp.Content)
+		color.Magenta(resp.Usage.String())
+	}
+
+	// Get summarized title from diff data
+	if _, ok := data[prompt.SummarizeTitleKey]; !ok {
+		out, err := util.GetTemplateByString(
+			prompt.SummarizeTitleTemplate,
+			util.Data{
+				"summary_points":        data[prompt.SummarizeMessageKey],
+				prompt.CommitHistoryKey: history,
+			},
+		)
+		if err != nil {
+			return "", err
+		}
+
+		stepCtx, err := systemContext(ctx, prompt.SummarizeTitleTemplate)
+		if err != nil {
+			return "", err
+		}
+		stepClient, err := steps.get(stepTitle)
+		if err != nil {
+			return "", err
+		}
+
+		// Generate title for pull request with retry if empty
+		color.Cyan("Generating title for pull request...")
+		const maxRetries = 3
+		const retryDelay = 500 * time.Millisecond
 
-			// Get summarized comment from diff data
-			color.Cyan("Summarizing git diff...")
-			resp, err := callCompletion(cmd.Context(), client, out, os.Stdout)
+		var summarizeTitle string
+		var resp *core.Response
+
+		for attempt := 1; attempt <= maxRetries; attempt++ {
+			resp, err = stepClient.Completion(stepCtx, out)
 			if err != nil {
-				return err
+				return "", err
 			}
-			data[prompt.SummarizeMessageKey] = strings.TrimSpace(resp.Content)
+
+			summarizeTitle = strings.TrimSpace(resp.Content)
 			color.Magenta(resp.Usage.String())
-		}
 
-		// Get summarized title from diff data
-		if _, ok := data[prompt.SummarizeTitleKey]; !ok {
-			out, err := util.GetTemplateByString(
-				prompt.SummarizeTitleTemplate,
-				util.Data{
-					"summary_points": data[prompt.SummarizeMessageKey],
-				},
-			)
-			if err != nil {
-				return err
+			if len(summarizeTitle) > 0 {
+				break
 			}
 
-			// Generate title for pull request with retry if empty
-			color.Cyan("Generating title for pull request...")
-			const maxRetries = 3
-			const retryDelay = 500 * time.Millisecond
+			if attempt < maxRetries {
+				color.Cyan("Empty title response, retrying (%d/%d)...", attempt, maxRetries)
+				time.Sleep(retryDelay)
+			}
+		}
 
-			var summarizeTitle string
-			var resp *core.Response
+		if len(summarizeTitle) == 0 {
+			return "", fmt.Errorf("failed to get valid title after %d attempts", maxRetries)
+		}
 
-			for attempt := 1; attempt <= maxRetries; attempt++ {
-				resp, err = client.Completion(cmd.Context(), out)
-				if err != nil {
-					return err
-				}
+		// Lowercase the first character of first word of the commit message and remove the trailing period
+		summarizeTitle = strings.TrimRight(
+			strings.ToLower(string(summarizeTitle[0]))+summarizeTitle[1:],
+			".",
+		)
+		data[prompt.SummarizeTitleKey] = strings.TrimSpace(summarizeTitle)
+	}
 
-				summarizeTitle = strings.TrimSpace(resp.Content)
-				color.Magenta(resp.Usage.String())
+	if _, ok := data[prompt.SummarizePrefixKey]; !ok {
+		out, err := util.GetTemplateByString(
+			prompt.ConventionalCommitTemplate,
+			util.Data{
+				"summary_points": data[prompt.SummarizeMessageKey],
+			},
+		)
+		if err != nil {
+			return "", err
+		}
 
-				if len(summarizeTitle) > 0 {
-					break
-				}
+		stepCtx, err := systemContext(ctx, prompt.ConventionalCommitTemplate)
+		if err != nil {
+			return "", err
+		}
+		stepClient, err := steps.get(stepPrefix)
+		if err != nil {
+			return "", err
+		}
+		message := "Generating conventional commit prefix"
+		summaryPrix := ""
+		color.Cyan(message + " (Tools)")
+		resp, err := stepClient.GetSummaryPrefix(stepCtx, out)
+		if err != nil {
+			return "", err
+		}
+		summaryPrix = resp.Content
 
-				if attempt < maxRetries {
-					color.Cyan("Empty title response, retrying (%d/%d)...", attempt, maxRetries)
-					time.Sleep(retryDelay)
-				}
-			}
+		color.Magenta(resp.Usage.String())
 
-			if len(summarizeTitle) == 0 {
-				return fmt.Errorf("failed to get valid title after %d attempts", maxRetries)
-			}
+		data[prompt.SummarizePrefixKey] = summaryPrix
+	}
 
-			// Lowercase the first character of first word of the commit message and remove the trailing period
-			summarizeTitle = strings.TrimRight(
-				strings.ToLower(string(summarizeTitle[0]))+summarizeTitle[1:],
-				".",
-			)
-			data[prompt.SummarizeTitleKey] = strings.TrimSpace(summarizeTitle)
+	commitMessage, err := renderCommitMessage(data)
+	if err != nil {
+		return "", err
+	}
+
+	if prompt.GetLanguage(viper.GetString("output.lang")) != prompt.DefaultLanguage {
+		out, err := util.GetTemplateByString(
+			prompt.TranslationTemplate,
+			util.Data{
+				"output_language": prompt.GetLanguage(viper.GetString("output.lang")),
+				"output_message":  commitMessage,
+			},
+		)
+		if err != nil {
+			return "", err
 		}
 
-		if _, ok := data[prompt.SummarizePrefixKey]; !ok {
-			out, err := util.GetTemplateByString(
-				prompt.ConventionalCommitTemplate,
-				util.Data{
-					"summary_points": data[prompt.SummarizeMessageKey],
-				},
-			)
-			if err != nil {
-				return err
-			}
-			message := "Generating conventional commit prefix"
-			summaryPrix := ""
-			color.Cyan(message + " (Tools)")
-			resp, err := client.GetSummaryPrefix(cmd.Context(), out)
-			if err != nil {
-				return err
-			}
-			summaryPrix = resp.Content
+		stepCtx, err := systemContext(ctx, prompt.TranslationTemplate)
+		if err != nil {
+			return "", err
+		}
+		stepClient, err := steps.get(stepTranslate)
+		if err != nil {
+			return "", err
+		}
 
-			color.Magenta(resp.Usage.String())
+		// Translate git commit message
+		color.Cyan(
+			"Translating git commit message to " + prompt.GetLanguage(
+				viper.GetString("output.lang"),
+			),
+		)
+		resp, err := callCompletion(stepCtx, stepClient, out, os.Stdout)
+		if err != nil {
+			return "", err
+		}
+		color.Magenta(resp.Usage.String())
+		commitMessage = resp.Content
+	}
 
-			data[prompt.SummarizePrefixKey] = summaryPrix
+	// Unescape HTML entities in commit message
+	commitMessage = html.UnescapeString(commitMessage)
+	commitMessage = strings.TrimSpace(commitMessage)
+
+	// Append the trailers after translating, so their keys stay untouched
+	return addTrailers(ctx, commitMessage, data)
+}
+
+// commitCmd represents the commit command.
+var commitCmd = &cobra.Command{
+	Use:   "commit " + diffArgsUsage,
+	Short: "Automatically generate commit message",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := check(cmd.Context()); err != nil {
+			return err
 		}
 
-		var commitMessage string
-		if viper.GetString("git.template_file") != "" {
-			format, err := os.ReadFile(viper.GetString("git.template_file"))
-			if err != nil {
-				return err
-			}
-			commitMessage, err = util.NewTemplateByString(
-				string(format),
-				data,
+		// Only the staged changes can be committed, other diffs are for prompts
+		if hasCustomDiff(args) && !promptOnly {
+			return errors.New(
+				"--unstaged, --base, revision ranges and pathspecs require --prompt_only",
 			)
-			if err != nil {
-				return err
-			}
-		} else if viper.GetString("git.template_string") != "" {
-			commitMessage, err = util.NewTemplateByString(
-				viper.GetString("git.template_string"),
-				data,
-			)
-			if err != nil {
-				return err
-			}
-		} else {
-			commitMessage, err = util.GetTemplateByString(
-				git.CommitMessageTemplate,
-				data,
-			)
-			if err != nil {
-				return err
-			}
 		}
 
-		if prompt.GetLanguage(viper.GetString("output.lang")) != prompt.DefaultLanguage {
-			out, err := util.GetTemplateByString(
-				prompt.TranslationTemplate,
-				util.Data{
-					"output_language": prompt.GetLanguage(viper.GetString("output.lang")),
-					"output_message":  commitMessage,
-				},
-			)
-			if err != nil {
-				return err
-			}
+		if commitSplit {
+			return runSplit(cmd)
+		}
 
-			// Translate git commit message
-			color.Cyan(
-				"Translating git commit message to " + prompt.GetLanguage(
-					viper.GetString("output.lang"),
-				),
-			)
-			resp, err := callCompletion(cmd.Context(), client, out, os.Stdout)
-			if err != nil {
+		g, err := newGitCommand(cmd, args)
+		if err != nil {
+			return err
+		}
+		files, err := g.ChangedFiles(cmd.Context())
+		if err != nil {
+			return err
+		}
+		// An empty commit has no diff, its message is generated without one
+		var diff string
+		if len(files) > 0 || !viper.GetBool("git.allow_empty") {
+			if diff, err = g.DiffFiles(cmd.Context()); err != nil {
 				return err
 			}
-			color.Magenta(resp.Usage.String())
-			commitMessage = resp.Content
 		}
 
-		// Unescape HTML entities in commit message
-		commitMessage = html.UnescapeString(commitMessage)
-		commitMessage = strings.TrimSpace(commitMessage)
+		// Update the OpenAI client request timeout if the timeout value is greater than the default openai.timeout
+		if timeout > viper.GetDuration("openai.timeout") ||
+			timeout != defaultTimeout {
+			viper.Set("openai.timeout", timeout)
+		}
+
+		// Check provider
+		provider := core.Platform(viper.GetString("openai.provider"))
+		client, err := GetClient(cmd.Context(), provider)
+		if err != nil && !promptOnly {
+			return err
+		}
+
+		_, currentModel := stepModel(stepSummarize)
+		color.Green("Summarizing commit message using " + currentModel + " model")
+		steps := newStepClients(cmd.Context(), client)
+
+		data, err := commitTemplateData(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		// Recent commit messages show the model the house style of the repository
+		history, err := commitHistory(cmd.Context(), stepSummarize)
+		if err != nil {
+			return err
+		}
+
+		commitMessage, err := generateCommitMessage(cmd.Context(), steps, diff, data, history)
+		if err != nil || promptOnly {
+			return err
+		}
 
 		// Output commit summary data from AI
 		color.Yellow("================Commit Summary====================")
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appleboy/CodeGPT/core/usage"
+	"github.com/appleboy/CodeGPT/prompt"
+	"github.com/appleboy/CodeGPT/provider/fake"
+)
+
+var commitResponses = []fake.Response{
+	{Template: prompt.SummarizeFileDiffTemplate, Content: "- add the hello function"},
+	{Template: prompt.SummarizeTitleTemplate, Content: "Add hello function."},
+	{Kind: fake.KindSummaryPrefix, Content: "feat"},
+}
+
+func TestCommitCommand(t *testing.T) {
+	setupRepo(t)
+	cfg := writeConfig(t, commitResponses)
+
+	out, err := executeCommand(t, "commit", "--config", cfg, "--no_confirm")
+	if err != nil {
+		t.Fatalf("commit failed: %v\n%s", err, out)
+	}
+
+	want := "feat: add hello function\n\n- add the hello function"
+	if got := strings.TrimSpace(runGit(t, "log", "-1", "--format=%B")); !strings.HasPrefix(got, want) {
+		t.Errorf("unexpected commit message:\n%s\nwant prefix:\n%s", got, want)
+	}
+
+	// Every request is recorded to the usage ledger.
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger := usage.NewLedger(filepath.Join(home, ".config", "codegpt", "usage.jsonl"))
+	records, err := ledger.Records(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[0].Command != "commit" || records[0].Provider != "fake" {
+		t.Errorf("unexpected usage records: %+v", records)
+	}
+}
+
+func TestCommitCommandPreview(t *testing.T) {
+	dir := setupRepo(t)
+	cfg := writeConfig(t, commitResponses)
+
+	out, err := executeCommand(t, "commit", "--config", cfg, "--preview", "--no_confirm")
+	if err != nil {
+		t.Fatalf("commit failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "feat: add hello function") {
+		t.Errorf("expected the commit summary in the output:\n%s", out)
+	}
+
+	msg, err := os.ReadFile(filepath.Join(dir, ".git", "COMMIT_EDITMSG"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(msg), "feat: add hello function") {
+		t.Errorf("unexpected COMMIT_EDITMSG: %s", msg)
+	}
+
+	// A preview does not commit.
+	if out := runGit(t, "status", "--porcelain"); !strings.Contains(out, "A  hello.go") {
+		t.Errorf("expected hello.go to stay staged, got %q", out)
+	}
+}
+
+func TestCommitCommandProviderError(t *testing.T) {
+	setupRepo(t)
+	cfg := writeConfig(t, []fake.Response{
+		{Template: prompt.SummarizeFileDiffTemplate, Error: "rate limit exceeded"},
+	})
+
+	_, err := executeCommand(t, "commit", "--config", cfg, "--no_confirm")
+	if err == nil || !strings.Contains(err.Error(), "rate limit exceeded") {
+		t.Fatalf("expected the provider error, got %v", err)
+	}
+}
+
+func TestCommitCommandCustomDiffRequiresPromptOnly(t *testing.T) {
+	setupRepo(t)
+	cfg := writeConfig(t, nil)
+
+	_, err := executeCommand(t, "commit", "--config", cfg, "--unstaged")
+	if err == nil || !strings.Contains(err.Error(), "require --prompt_only") {
+		t.Fatalf("expected a --prompt_only error, got %v", err)
+	}
+
+	out, err := executeCommand(t, "commit", "--config", cfg, "--prompt_only", "--", "hello.go")
+	if err != nil {
+		t.Fatalf("commit failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "b/hello.go") {
+		t.Errorf("expected the staged diff in the prompt:\n%s", out)
+	}
+}
+
+func TestCommitCommandTrailers(t *testing.T) {
+	setupRepo(t)
+	runGit(t, "checkout", "-q", "-b", "feature/PROJ-42-hello")
+	cfg := writeConfig(t, commitResponses)
+	appendConfig(t, cfg, "git:\n  trailers:\n    - 'Refs: {{ .issue_key }}'\n")
+
+	out, err := executeCommand(t, "commit", "--config", cfg, "--no_confirm",
+		"--trailer", "Reviewed-by: Jane Doe <jane@example.com>")
+	if err != nil {
+		t.Fatalf("commit failed: %v\n%s", err, out)
+	}
+
+	want := "\n\nRefs: PROJ-42\nReviewed-by: Jane Doe <jane@example.com>\nSigned-off-by: CodeGPT <codegpt@example.com>"
+	if got := strings.TrimSpace(runGit(t, "log", "-1", "--format=%B")); !strings.HasSuffix(got, want) {
+		t.Errorf("unexpected commit message:\n%s\nwant suffix:\n%s", got, want)
+	}
+
+	// Without an issue key in the branch name, the Refs trailer is skipped.
+	runGit(t, "checkout", "-q", "-b", "main")
+	if err := os.WriteFile("hello.go", []byte("package main\n\nfunc hello() { println() }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "add", "hello.go")
+	out, err = executeCommand(t, "commit", "--config", cfg, "--no_confirm")
+	if err != nil {
+		t.Fatalf("commit failed: %v\n%s", err, out)
+	}
+	if got := runGit(t, "log", "-1", "--format=%B"); strings.Contains(got, "Refs:") {
+		t.Errorf("unexpected Refs trailer:\n%s", got)
+	}
+}
+
+func TestCommitCommandOptions(t *testing.T) {
+	dir := setupRepo(t)
+	cfg := writeConfig(t, commitResponses)
+	hook := filepath.Join(dir, ".git", "hooks", "pre-commit")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\necho blocked by pre-commit\nexit 1\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hooks are skipped by default.
+	out, err := executeCommand(t, "commit", "--config", cfg, "--no_confirm",
+		"--signoff=false", "--author", "Jane Doe <jane@example.com>")
+	if err != nil {
+		t.Fatalf("commit failed: %v\n%s", err, out)
+	}
+	if got := runGit(t, "log", "-1", "--format=%an <%ae>%n%B"); !strings.HasPrefix(got, "Jane Doe <jane@example.com>\n") ||
+		strings.Contains(got, "Signed-off-by:") {
+		t.Errorf("unexpected commit:\n%s", got)
+	}
+
+	if err := os.WriteFile("hello.go", []byte("package main\n\nfunc hello() { println() }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "add", "hello.go")
+	_, err = executeCommand(t, "commit", "--config", cfg, "--no_confirm", "--no_verify=false")
+	if err == nil || !strings.Contains(err.Error(), "blocked by pre-commit") {
+		t.Fatalf("expected the pre-commit hook to run, got %v", err)
+	}
+}
+
+func TestCommitCommandAllowEmpty(t *testing.T) {
+	setupRepo(t)
+	runGit(t, "commit", "-q", "-m", "hello: add the greeting")
+	cfg := writeConfig(t, commitResponses)
+
+	if _, err := executeCommand(t, "commit", "--config", cfg, "--no_confirm"); err == nil ||
+		!strings.Contains(err.Error(), "please add your staged changes") {
+		t.Fatalf("expected an error without staged changes, got %v", err)
+	}
+
+	out, err := executeCommand(t, "commit", "--config", cfg, "--no_confirm", "--allow_empty")
+	if err != nil {
+		t.Fatalf("commit --allow_empty failed: %v\n%s", err, out)
+	}
+	if got := runGit(t, "log", "-1", "--format=%s", "--name-only"); got != "feat: add hello function\n" {
+		t.Errorf("expected an empty commit, got:\n%s", got)
+	}
+
+	// The last commit is empty now, amending it needs --allow_empty too.
+	out, err = executeCommand(t, "commit", "--config", cfg, "--no_confirm", "--amend", "--allow_empty")
+	if err != nil {
+		t.Fatalf("commit --amend --allow_empty failed: %v\n%s", err, out)
+	}
+	if count := strings.TrimSpace(runGit(t, "rev-list", "--count", "HEAD")); count != "2" {
+		t.Errorf("expected the empty commit to be amended, got %s commits", count)
+	}
+}
+
+func TestCommitCommandHistory(t *testing.T) {
+	setupRepo(t)
+	runGit(t, "commit", "-q", "-m", "hello: add the greeting", "-m", "Signed-off-by: CodeGPT <codegpt@example.com>")
+	if err := os.WriteFile("world.go", []byte("package main\n\nfunc world() {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "add", "world.go")
+	runGit(t, "commit", "-q", "-m", "world: add the world")
+	if err := os.WriteFile("hello.go", []byte("package main\n\nfunc hello() { println() }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "add", "hello.go")
+	cfg := writeConfig(t, nil)
+
+	out, err := executeCommand(t, "commit", "--config", cfg, "--prompt_only", "--history", "5")
+	if err != nil {
+		t.Fatalf("commit failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "world: add the world\n\n---\n\nhello: add the greeting\n") {
+		t.Errorf("expected the recent commit messages in the prompt:\n%s", out)
+	}
+	if strings.Contains(out, "Signed-off-by") {
+		t.Errorf("unexpected sign-off in the examples:\n%s", out)
+	}
+
+	// Only the messages touching history.paths fit the token cap.
+	appendConfig(t, cfg, "history:\n  count: 5\n  paths: [hello.go]\n  max_tokens: 20\n")
+	out, err = executeCommand(t, "commit", "--config", cfg, "--prompt_only")
+	if err != nil {
+		t.Fatalf("commit failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "hello: add the greeting") || strings.Contains(out, "world: add the world") {
+		t.Errorf("expected only the commit touching hello.go in the prompt:\n%s", out)
+	}
+}
+
+func TestCommitCommandMapReduceHistory(t *testing.T) {
+	setupRepo(t)
+	runGit(t, "commit", "-q", "-m", "hello: add the greeting")
+	if err := os.WriteFile("hello.go", []byte("package main\n\nfunc hello() { println() }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("world.go", []byte("package main\n\nfunc world() {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "add", ".")
+	cfg := writeConfig(t, nil)
+	appendConfig(t, cfg, "map_reduce:\n  enabled: true\n")
+
+	out, err := executeCommand(t, "commit", "--config", cfg, "--prompt_only", "--history", "5")
+	if err != nil {
+		t.Fatalf("commit failed: %v\n%s", err, out)
+	}
+	prompts := strings.Split(out, "====================Prompt: ")[1:]
+	if len(prompts) != 2 {
+		t.Fatalf("expected a prompt per file:\n%s", out)
+	}
+	for _, p := range prompts {
+		if !strings.Contains(p, "hello: add the greeting") {
+			t.Errorf("expected the recent commit messages in the prompt:\n%s", p)
+		}
+	}
+}
@@ -22,6 +22,14 @@ var availableKeys = map[string]string{
 	"git.exclude_list":                       "Files to exclude from git diff command",
 	"git.template_file":                      "Path to template file for commit messages",
 	"git.template_string":                    "Template string for formatting commit messages",
+	"git.no_verify":                          "Skip the pre-commit and commit-msg hooks when committing (default: true)",
+	"git.signoff":                            "Add a Signed-off-by trailer to commits (default: true)",
+	"git.gpg_sign":                           "Sign commits with your GPG or SSH key (default: false)",
+	"git.gpg_key":                            "Key ID used to sign commits, implies git.gpg_sign",
+	"git.author":                             "Override the commit author, e.g. 'Jane Doe <jane@example.com>'",
+	"git.allow_empty":                        "Allow commits without changes (default: false)",
+	"git.issue_pattern":                      "Regular expression extracting the issue key from the branch name (default: Jira keys like PROJ-123, empty disables)",
+	"git.trailers":                           "Commit trailers to append, e.g. 'Refs: {{ .issue_key }}', skipped when their value is empty",
 	"openai.socks":                           "SOCKS proxy URL for API connections",
 	"openai.api_key":                         "Authentication key for OpenAI API access",
 	"openai.api_key_helper":                  "Shell command to dynamically generate API key",
@@ -33,8 +41,10 @@ var availableKeys = map[string]string{
 	"openai.base_url":                        "Custom base URL for API requests",
 	"openai.timeout":                         "Maximum duration to wait for API response",
 	"openai.max_tokens":                      "Maximum token limit for generated completions",
+	"openai.context_limit":                   "Context window in tokens, larger diffs are trimmed to fit (default: 128000, Ollama uses num_ctx)",
+	"openai.max_retries":                     "Number of retries for rate limited (429) or failed (5xx) requests (default: 2, 0 disables)",
 	"openai.temperature":                     "Randomness control parameter (0-1): lower values for focused results, higher for creative variety",
-	"openai.provider":                        "Service provider selection ('openai' or 'azure')",
+	"openai.provider":                        "Service provider selection ('openai', 'azure', 'gemini', 'anthropic' or 'ollama')",
 	"openai.skip_verify":                     "Option to bypass TLS certificate verification",
 	"openai.headers":                         "Additional custom HTTP headers for API requests",
 	"openai.api_version":                     "Specific API version to target",
@@ -43,12 +53,48 @@ var availableKeys = map[string]string{
 	"openai.presence_penalty":                "Parameter to encourage topic diversity by penalizing previously used tokens",
 	"openai.stream":                          "Enable streaming output for real-time token display",
 	"prompt.folder":                          "Directory path for custom prompt templates",
+	"prompt.system":                          "System prompt sent to every provider, e.g. your team's house style (default: provider specific)",
+	"providers":                              "Ordered fallback list of providers, each 'provider' or 'provider:model' (e.g. anthropic,openai:gpt-4o)",
 	"gemini.project_id":                      "VertexAI project for Gemini provider",
 	"gemini.location":                        "VertexAI location for Gemini provider",
 	"gemini.backend":                         "Gemini backend (BackendGeminiAPI or BackendVertexAI)",
 	"gemini.api_key":                         "API key for Gemini provider",
 	"gemini.api_key_helper":                  "Shell command to dynamically generate Gemini API key",
 	"gemini.api_key_helper_refresh_interval": "Interval in seconds to refresh Gemini credentials from apiKeyHelper (default: 900)",
+	"ollama.base_url":                        "Base URL of the Ollama server (default: http://localhost:11434)",
+	"ollama.num_ctx":                         "Context window size for Ollama models (default: model setting)",
+	"ollama.keep_alive":                      "How long Ollama keeps the model loaded after a request (e.g. 5m, -1)",
+	"ollama.format":                          "Response format for Ollama completions (empty or 'json')",
+	"steps.summarize.provider":               "Provider used for summarizing the diff (default: openai.provider)",
+	"steps.summarize.model":                  "Model used for summarizing the diff (default: openai.model, or the default model of the step provider)",
+	"steps.title.provider":                   "Provider used for generating the commit title (default: openai.provider)",
+	"steps.title.model":                      "Model used for generating the commit title (default: openai.model, or the default model of the step provider)",
+	"steps.prefix.provider":                  "Provider used for generating the conventional commit prefix (default: openai.provider)",
+	"steps.prefix.model":                     "Model used for generating the conventional commit prefix (default: openai.model, or the default model of the step provider)",
+	"steps.translate.provider":               "Provider used for translating the output (default: openai.provider)",
+	"steps.translate.model":                  "Model used for translating the output (default: openai.model, or the default model of the step provider)",
+	"steps.review.provider":                  "Provider used for reviewing code changes (default: openai.provider)",
+	"steps.review.model":                     "Model used for reviewing code changes (default: openai.model, or the default model of the step provider)",
+	"map_reduce.enabled":                     "Summarize and review each changed file separately, then combine the results (default: false)",
+	"map_reduce.concurrency":                 "Number of files summarized concurrently in map-reduce mode (default: 4)",
+	"cache.enabled":                          "Cache responses on disk and reuse them for identical requests (default: false)",
+	"cache.ttl":                              "How long cached responses stay valid, e.g. 24h (default: 24h, 0 never expires)",
+	"cache.dir":                              "Directory for cached responses (default: $HOME/.config/codegpt/.cache/responses)",
+	"fake.fixture":                           "Path of the JSON fixture with the scripted responses of the fake provider",
+	"history.count":                          "Number of recent commit messages shown to the model as examples of the repository style (default: 0, disabled)",
+	"history.author":                         "Only use commit messages by this author as examples",
+	"history.paths":                          "Only use commit messages of commits touching these paths as examples",
+	"history.max_tokens":                     "Token cap of the commit message examples (default: 1000)",
+	"review.format":                          "Output format of the review: table, json, markdown, sarif or github (default: github on GitHub Actions, table otherwise)",
+	"review.fail_on":                         "Fail the review when findings of this severity or higher exist: critical, high, medium, low or info (default: disabled)",
+	"review.max_tokens":                      "Maximum tokens of a review (default: openai.max_tokens, at least 2048)",
+	"review.post":                            "Post the review to the pull or merge request of the CI run: github or gitlab (default: disabled)",
+	"github.token":                           "Token used to post reviews to GitHub pull requests (default: $GITHUB_TOKEN)",
+	"github.base_url":                        "GitHub REST API URL, e.g. https://github.example.com/api/v3 (default: $GITHUB_API_URL or https://api.github.com)",
+	"gitlab.token":                           "Token used to post reviews to GitLab merge requests (default: $GITLAB_TOKEN)",
+	"gitlab.base_url":                        "GitLab REST API URL, e.g. https://gitlab.example.com/api/v4 (default: $CI_API_V4_URL or https://gitlab.com/api/v4)",
+	"usage.enabled":                          "Record the token usage of every request to the usage ledger (default: true)",
+	"usage.path":                             "Path of the usage ledger (default: $HOME/.config/codegpt/usage.jsonl)",
 }
 
 // configListCmd represents the command to list the configuration values.
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/appleboy/CodeGPT/core/transport"
 	"github.com/appleboy/CodeGPT/util"
 
 	"github.com/fatih/color"
@@ -30,6 +31,8 @@ func init() {
 	configSetCmd.Flags().IntP("diff_unified", "", 3, availableKeys["git.diff_unified"])
 	configSetCmd.Flags().StringP("exclude_list", "", "", availableKeys["git.exclude_list"])
 	configSetCmd.Flags().IntP("max_tokens", "", 300, availableKeys["openai.max_tokens"])
+	configSetCmd.Flags().
+		IntP("max_retries", "", transport.DefaultMaxRetries, availableKeys["openai.max_retries"])
 	configSetCmd.Flags().Float32P("temperature", "", 1.0, availableKeys["openai.temperature"])
 	configSetCmd.Flags().Float32P("top_p", "", 1.0, availableKeys["openai.top_p"])
 	configSetCmd.Flags().
@@ -50,6 +53,11 @@ func init() {
 	configSetCmd.Flags().String("gemini.api_key_helper", "", availableKeys["gemini.api_key_helper"])
 	configSetCmd.Flags().
 		Int("gemini.api_key_helper_refresh_interval", 900, availableKeys["gemini.api_key_helper_refresh_interval"])
+	// Ollama flags
+	configSetCmd.Flags().String("ollama.base_url", "", availableKeys["ollama.base_url"])
+	configSetCmd.Flags().Int("ollama.num_ctx", 0, availableKeys["ollama.num_ctx"])
+	configSetCmd.Flags().String("ollama.keep_alive", "", availableKeys["ollama.keep_alive"])
+	configSetCmd.Flags().String("ollama.format", "", availableKeys["ollama.format"])
 
 	_ = viper.BindPFlag("openai.base_url", configSetCmd.Flags().Lookup("base_url"))
 	_ = viper.BindPFlag("openai.org_id", configSetCmd.Flags().Lookup("org_id"))
@@ -64,6 +72,7 @@ func init() {
 	_ = viper.BindPFlag("openai.socks", configSetCmd.Flags().Lookup("socks"))
 	_ = viper.BindPFlag("openai.timeout", configSetCmd.Flags().Lookup("timeout"))
 	_ = viper.BindPFlag("openai.max_tokens", configSetCmd.Flags().Lookup("max_tokens"))
+	_ = viper.BindPFlag("openai.max_retries", configSetCmd.Flags().Lookup("max_retries"))
 	_ = viper.BindPFlag("openai.temperature", configSetCmd.Flags().Lookup("temperature"))
 	_ = viper.BindPFlag("output.lang", configSetCmd.Flags().Lookup("lang"))
 	_ = viper.BindPFlag("git.diff_unified", configSetCmd.Flags().Lookup("diff_unified"))
@@ -87,12 +96,17 @@ func init() {
 		"gemini.api_key_helper_refresh_interval",
 		configSetCmd.Flags().Lookup("gemini.api_key_helper_refresh_interval"),
 	)
+	_ = viper.BindPFlag("ollama.base_url", configSetCmd.Flags().Lookup("ollama.base_url"))
+	_ = viper.BindPFlag("ollama.num_ctx", configSetCmd.Flags().Lookup("ollama.num_ctx"))
+	_ = viper.BindPFlag("ollama.keep_alive", configSetCmd.Flags().Lookup("ollama.keep_alive"))
+	_ = viper.BindPFlag("ollama.format", configSetCmd.Flags().Lookup("ollama.format"))
 }
 
 // configSetCmd updates the config value.
 // It takes at least two arguments, the first one being the key and the second one being the value.
 // If the key is not available, it returns an error message.
-// If the key is "git.exclude_list", it sets the value as a slice of strings.
+// If the key is "git.exclude_list", "git.trailers", "history.paths" or "providers", it sets the value
+// as a slice of strings.
 // It writes the config to file and prints a success message with the config file location.
 var configSetCmd = &cobra.Command{
 	Use:   "set",
@@ -123,7 +137,8 @@ var configSetCmd = &cobra.Command{
 		}
 
 		// Set config value in viper
-		if args[0] == "git.exclude_list" {
+		if args[0] == "git.exclude_list" || args[0] == "git.trailers" || args[0] == "history.paths" ||
+			args[0] == "providers" {
 			viper.Set(args[0], strings.Split(args[1], ","))
 		} else {
 			viper.Set(args[0], args[1])
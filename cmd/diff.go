@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/appleboy/CodeGPT/git"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// diffUnstaged diffs the working tree instead of the staged changes.
+	diffUnstaged bool
+	// diffBase diffs the current branch against its merge base with this ref.
+	diffBase string
+)
+
+// diffArgsUsage documents the positional arguments accepted by diffArgs.
+const diffArgsUsage = "[<rev-range>] [-- <pathspec>...]"
+
+// diffArgs splits the positional arguments into an optional revision range,
+// given before --, and pathspecs, given after it.
+func diffArgs(cmd *cobra.Command, args []string) (string, []string, error) {
+	revs, paths := args, []string(nil)
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		revs, paths = args[:dash], args[dash:]
+	}
+	switch len(revs) {
+	case 0:
+		return "", paths, nil
+	case 1:
+		return revs[0], paths, nil
+	}
+	return "", nil, errors.New("only one revision range is allowed, put pathspecs after --")
+}
+
+// hasCustomDiff reports whether the command was asked to diff anything other than
+// the staged changes or the last commit.
+func hasCustomDiff(args []string) bool {
+	return diffUnstaged || diffBase != "" || len(args) > 0
+}
+
+// newGitCommand returns a git command that diffs the changes selected by the --amend,
+// --unstaged and --base flags and the revision range and pathspec arguments.
+func newGitCommand(cmd *cobra.Command, args []string) (*git.Command, error) {
+	rev, paths, err := diffArgs(cmd, args)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []git.Option{
+		git.WithDiffUnified(viper.GetInt("git.diff_unified")),
+		git.WithExcludeList(viper.GetStringSlice("git.exclude_list")),
+		git.WithEnableAmend(commitAmend),
+		git.WithPathspecs(paths),
+	}
+	opts = append(opts, commitOptions()...)
+
+	var sources []git.DiffSource
+	if commitAmend {
+		sources = append(sources, git.LastCommit())
+	}
+	if diffUnstaged {
+		sources = append(sources, git.Unstaged())
+	}
+	if diffBase != "" {
+		sources = append(sources, git.Base(diffBase))
+	}
+	if rev != "" {
+		sources = append(sources, git.Range(rev))
+	}
+	if len(sources) > 1 {
+		return nil, errors.New("use only one of --amend, --unstaged, --base or a revision range")
+	}
+	if len(sources) == 1 {
+		opts = append(opts, git.WithDiffSource(sources[0]))
+	}
+
+	return git.New(opts...), nil
+}
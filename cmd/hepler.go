@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/appleboy/CodeGPT/core"
 	"github.com/appleboy/CodeGPT/git"
 	"github.com/appleboy/CodeGPT/prompt"
 	"github.com/appleboy/CodeGPT/provider/openai"
@@ -96,3 +98,20 @@ func check(ctx context.Context) error {
 
 	return nil
 }
+
+// systemContext returns ctx carrying templateName and the system prompt for it, read
+// from the optional <name>.system.tmpl template in the prompt folder. Without such a
+// template, the providers use prompt.system.
+func systemContext(ctx context.Context, templateName string) (context.Context, error) {
+	ctx = core.WithTemplateName(ctx, templateName)
+	name := prompt.SystemTemplateName(templateName)
+	if !util.HasTemplate(name) {
+		return ctx, nil
+	}
+
+	out, err := util.GetTemplateByString(name, util.Data{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render system prompt %s: %w", name, err)
+	}
+	return core.WithSystemPrompt(ctx, strings.TrimSpace(out)), nil
+}
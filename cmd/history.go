@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/appleboy/CodeGPT/core/token"
+	"github.com/appleboy/CodeGPT/git"
+
+	"github.com/spf13/viper"
+)
+
+// defaultHistoryMaxTokens caps the commit history examples when history.max_tokens is not set.
+const defaultHistoryMaxTokens = 1000
+
+// signatureTrailer matches trailers such as Signed-off-by, which the examples
+// should not teach the model to write.
+var signatureTrailer = regexp.MustCompile(`(?m)^[A-Z][A-Za-z-]*-by: .*\n?`)
+
+// historyMaxTokens returns the token cap of the commit history examples.
+func historyMaxTokens() int {
+	if viper.IsSet("history.max_tokens") {
+		return viper.GetInt("history.max_tokens")
+	}
+	return defaultHistoryMaxTokens
+}
+
+// commitHistory returns the last history.count non-merge commit messages of the
+// repository, filtered by history.author and history.paths, as few-shot examples
+// of its house style. Messages are added newest first until the next one would
+// exceed history.max_tokens, counted for the model of step. It returns an empty
+// string when history.count is not set.
+func commitHistory(ctx context.Context, step string) (string, error) {
+	count := viper.GetInt("history.count")
+	if count <= 0 {
+		return "", nil
+	}
+
+	g := git.New(git.WithPathspecs(viper.GetStringSlice("history.paths")))
+	messages, err := g.RecentMessages(ctx, count, viper.GetString("history.author"))
+	if err != nil {
+		return "", err
+	}
+
+	provider, model := stepModel(step)
+	estimator := token.NewEstimator(provider, model)
+	maxTokens := historyMaxTokens()
+
+	var examples []string
+	used := 0
+	for _, message := range messages {
+		message = strings.TrimSpace(signatureTrailer.ReplaceAllString(message, ""))
+		if message == "" {
+			continue
+		}
+		tokens := estimator.Count(message)
+		if used+tokens > maxTokens {
+			break
+		}
+		used += tokens
+		examples = append(examples, message)
+	}
+	return strings.Join(examples, "\n\n---\n\n"), nil
+}
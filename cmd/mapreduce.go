@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/git"
+	"github.com/appleboy/CodeGPT/util"
+
+	"github.com/fatih/color"
+	"github.com/spf13/viper"
+)
+
+// defaultMapReduceConcurrency is the number of files summarized at the same time.
+const defaultMapReduceConcurrency = 4
+
+// fileResponse is the model response for a single file of a map-reduce run.
+type fileResponse struct {
+	name string
+	resp *core.Response
+}
+
+// getMapReduceConcurrency returns the size of the worker pool for per-file requests.
+func getMapReduceConcurrency() int {
+	if n := viper.GetInt("map_reduce.concurrency"); n > 0 {
+		return n
+	}
+	return defaultMapReduceConcurrency
+}
+
+// useMapReduce reports whether the changed files should be handled one by one.
+// It is the case when map_reduce.enabled is set, or when the whole diff does
+// not fit templateName, rendered with vars, for the model used by step, as long
+// as more than one file changed.
+func useMapReduce(
+	diff string,
+	files []git.FileChange,
+	templateName, step string,
+	vars util.Data,
+) (bool, error) {
+	if len(files) < 2 {
+		return false, nil
+	}
+	if viper.GetBool("map_reduce.enabled") {
+		return true, nil
+	}
+
+	budget, estimator, err := diffBudget(templateName, step, vars)
+	if err != nil {
+		return false, err
+	}
+	return estimator.Count(diff) > budget, nil
+}
+
+// renderFilePrompts renders templateName with vars for every changed file,
+// trimming each file diff to the context limit of the model used by step on
+// its own.
+func renderFilePrompts(
+	files []git.FileChange,
+	templateName, step string,
+	vars util.Data,
+) ([]string, error) {
+	prompts := make([]string, len(files))
+	for i, f := range files {
+		diff, err := fitDiffWith(f.Diff, templateName, step, vars)
+		if err != nil {
+			return nil, err
+		}
+		data := util.Data{"file_diffs": diff}
+		maps.Copy(data, vars)
+		out, err := util.GetTemplateByString(templateName, data)
+		if err != nil {
+			return nil, err
+		}
+		prompts[i] = out
+	}
+	return prompts, nil
+}
+
+// completeFunc sends a prompt to a provider, e.g. core.Generative.Completion.
+type completeFunc func(ctx context.Context, content string) (*core.Response, error)
+
+// completeFiles sends the per-file prompts with complete concurrently, with at
+// most map_reduce.concurrency requests in flight. The responses keep the order
+// of the files. The first failure cancels the remaining requests.
+func completeFiles(
+	ctx context.Context,
+	complete completeFunc,
+	files []git.FileChange,
+	prompts []string,
+) ([]fileResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	results := make([]fileResponse, len(files))
+	sem := make(chan struct{}, getMapReduceConcurrency())
+
+	for i, f := range files {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if ctx.Err() != nil {
+				return
+			}
+			resp, err := complete(ctx, prompts[i])
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("%s: %w", f.Name, err)
+					cancel()
+				})
+				return
+			}
+			results[i] = fileResponse{name: f.Name, resp: resp}
+		})
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+// printFilePrompts prints the per-file prompts for --prompt_only.
+func printFilePrompts(files []git.FileChange, prompts []string) {
+	for i, f := range files {
+		color.Yellow("====================Prompt: %s========================", f.Name)
+		color.Yellow("\n" + strings.TrimSpace(prompts[i]) + "\n\n")
+	}
+	color.Yellow("==================================================")
+}
+
+// printFileUsage prints the token usage of every per-file response.
+func printFileUsage(results []fileResponse) {
+	for _, r := range results {
+		color.Magenta("%s: %s", r.name, r.resp.Usage.String())
+	}
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/proxy"
+	"github.com/appleboy/CodeGPT/review/github"
+	"github.com/appleboy/CodeGPT/review/gitlab"
+
+	"github.com/fatih/color"
+	"github.com/spf13/viper"
+)
+
+// reviewPost returns where the review is posted, or "" to only print it.
+func reviewPost() (string, error) {
+	switch target := viper.GetString("review.post"); target {
+	case "", GITHUB, GITLAB:
+		return target, nil
+	default:
+		return "", fmt.Errorf("unsupported review destination %q, use github or gitlab", target)
+	}
+}
+
+// postReview posts the review findings to the pull or merge request of the CI run.
+func postReview(ctx context.Context, target string, r *core.Review) error {
+	switch target {
+	case GITHUB:
+		return postGitHubReview(ctx, r)
+	case GITLAB:
+		return postGitLabReview(ctx, r)
+	default:
+		return nil
+	}
+}
+
+// postGitHubReview posts the review findings to the pull request that triggered
+// the GitHub Actions workflow, as a review with inline comments.
+func postGitHubReview(ctx context.Context, r *core.Review) error {
+	pr, err := github.ReadEvent(os.Getenv("GITHUB_EVENT_PATH"))
+	if err != nil {
+		return err
+	}
+
+	// The token and API URL of the workflow are used unless configured
+	token, err := getAPIKey("github.token")
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	baseURL := viper.GetString("github.base_url")
+	if baseURL == "" {
+		baseURL = os.Getenv("GITHUB_API_URL")
+	}
+
+	httpClient, err := postHTTPClient()
+	if err != nil {
+		return err
+	}
+	client, err := github.New(
+		github.WithBaseURL(baseURL),
+		github.WithToken(token),
+		github.WithHTTPClient(httpClient),
+	)
+	if err != nil {
+		return err
+	}
+
+	color.Cyan("Posting the review to %s#%d", pr.Repository, pr.Number)
+	url, err := client.PostReview(ctx, pr, r)
+	if err != nil {
+		return fmt.Errorf("failed to post the review: %w", err)
+	}
+	color.Green("Review posted: %s", url)
+	return nil
+}
+
+// postGitLabReview posts the review findings to the merge request of the
+// GitLab CI pipeline, as discussions on the changed lines.
+func postGitLabReview(ctx context.Context, r *core.Review) error {
+	mr, err := gitlab.ReadCI(os.Getenv)
+	if err != nil {
+		return err
+	}
+
+	// CI_JOB_TOKEN cannot write notes, so a project or personal access token is needed
+	token, err := getAPIKey("gitlab.token")
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		token = os.Getenv("GITLAB_TOKEN")
+	}
+	baseURL := viper.GetString("gitlab.base_url")
+	if baseURL == "" {
+		baseURL = os.Getenv("CI_API_V4_URL")
+	}
+
+	httpClient, err := postHTTPClient()
+	if err != nil {
+		return err
+	}
+	client, err := gitlab.New(
+		gitlab.WithBaseURL(baseURL),
+		gitlab.WithToken(token),
+		gitlab.WithHTTPClient(httpClient),
+	)
+	if err != nil {
+		return err
+	}
+
+	color.Cyan("Posting the review to %s!%d", mr.Project, mr.IID)
+	url, err := client.PostReview(ctx, mr, r)
+	if err != nil {
+		return fmt.Errorf("failed to post the review: %w", err)
+	}
+	color.Green("Review posted: %s", url)
+	return nil
+}
+
+// postHTTPClient returns the HTTP client used to post reviews, with the proxy
+// settings of the providers.
+func postHTTPClient() (*http.Client, error) {
+	httpClient, err := proxy.New(
+		proxy.WithProxyURL(viper.GetString("openai.proxy")),
+		proxy.WithSocksURL(viper.GetString("openai.socks")),
+		proxy.WithSkipVerify(viper.GetBool("openai.skip_verify")),
+		proxy.WithTimeout(viper.GetDuration("openai.timeout")),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("can't create a new HTTP client: %w", err)
+	}
+	return httpClient, nil
+}
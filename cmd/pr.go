@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/git"
+	"github.com/appleboy/CodeGPT/prompt"
+	"github.com/appleboy/CodeGPT/util"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// prBase is the branch the pull request will be merged into.
+	prBase string
+	// prFile is the file the pull request description is written to.
+	prFile string
+)
+
+func init() {
+	prCmd.PersistentFlags().StringVar(&prBase, "base", "main",
+		"branch the pull request will be merged into")
+	prCmd.PersistentFlags().StringVarP(&prFile, "file", "f", "",
+		"write the pull request description to a file, e.g. for gh pr create --body-file")
+	prCmd.PersistentFlags().IntVar(&diffUnified, "diff_unified", 3,
+		"generate diffs with <n> lines of context (default: 3)")
+	prCmd.PersistentFlags().IntVar(&maxTokens, "max_tokens", 300,
+		"maximum number of tokens to generate in the chat completion")
+	prCmd.PersistentFlags().
+		StringVar(&commitModel, "model", "gpt-4o", "specify which OpenAI model to use for generation")
+	prCmd.PersistentFlags().
+		StringVar(&commitLang, "lang", "en", "set output language for the pull request (default: English)")
+	prCmd.PersistentFlags().StringSliceVar(&excludeList, "exclude_list", []string{},
+		"specify files to exclude from git diff")
+	prCmd.PersistentFlags().BoolVar(&promptOnly, "prompt_only", false,
+		"display the prompt without sending to OpenAI")
+	prCmd.PersistentFlags().BoolVar(&noCache, "no_cache", false,
+		"bypass the local response cache for this run")
+}
+
+// prCmd generates the title and description of a pull request from the commits and
+// the combined diff of the current branch.
+var prCmd = &cobra.Command{
+	Use:   "pr [-- <pathspec>...]",
+	Short: "Generate a pull request title and description",
+	Example: `  codegpt pr --base main
+  codegpt pr --base main --file pr.md`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := check(cmd.Context()); err != nil {
+			return err
+		}
+		if cmd.ArgsLenAtDash() != 0 && len(args) > 0 {
+			return errors.New("put pathspecs after --")
+		}
+
+		g := git.New(
+			git.WithDiffUnified(viper.GetInt("git.diff_unified")),
+			git.WithExcludeList(viper.GetStringSlice("git.exclude_list")),
+			git.WithDiffSource(git.Base(prBase)),
+			git.WithPathspecs(args),
+		)
+		commitLog, err := g.CommitLog(cmd.Context())
+		if err != nil {
+			return err
+		}
+		diff, err := g.DiffFiles(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		// Update the OpenAI client request timeout if the timeout value is greater than the default openai.timeout
+		if timeout > viper.GetDuration("openai.timeout") ||
+			timeout != defaultTimeout {
+			viper.Set("openai.timeout", timeout)
+		}
+
+		// Check provider
+		provider := core.Platform(viper.GetString("openai.provider"))
+		client, err := GetClient(cmd.Context(), provider)
+		if err != nil && !promptOnly {
+			return err
+		}
+
+		_, currentModel := stepModel(stepPR)
+		color.Green("Describing pull request against " + prBase + " using " + currentModel + " model")
+		steps := newStepClients(cmd.Context(), client)
+
+		data := util.Data{"commit_log": commitLog}
+
+		// Summarize large branches file by file when the diff and the commit log
+		// do not fit the pull request prompt, which is then rendered from the
+		// per-file summaries
+		files := git.SplitDiff(diff)
+		mapReduce, err := useMapReduce(diff, files, prompt.PullRequestTemplate, stepPR, data)
+		if err != nil {
+			return err
+		}
+		if mapReduce {
+			prompts, err := renderFilePrompts(files, prompt.SummarizeFileDiffTemplate, stepSummarize, nil)
+			if err != nil {
+				return err
+			}
+
+			// Determine if the user wants to use the prompt only
+			if promptOnly {
+				printFilePrompts(files, prompts)
+				return nil
+			}
+
+			ctx, err := systemContext(cmd.Context(), prompt.SummarizeFileDiffTemplate)
+			if err != nil {
+				return err
+			}
+			stepClient, err := steps.get(stepSummarize)
+			if err != nil {
+				return err
+			}
+
+			color.Cyan("Summarizing git diff of %d files...", len(files))
+			results, err := completeFiles(ctx, stepClient.Completion, files, prompts)
+			if err != nil {
+				return err
+			}
+			printFileUsage(results)
+
+			var sb strings.Builder
+			for _, r := range results {
+				fmt.Fprintf(&sb, "### %s\n\n%s\n\n", r.name, strings.TrimSpace(r.resp.Content))
+			}
+			data["file_summaries"] = strings.TrimSpace(sb.String())
+		} else {
+			// Trim the diff so the request fits the model's context window
+			diff, err = fitDiffWith(diff, prompt.PullRequestTemplate, stepPR, util.Data{"commit_log": commitLog})
+			if err != nil {
+				return err
+			}
+			data["file_diffs"] = diff
+		}
+
+		out, err := util.GetTemplateByString(prompt.PullRequestTemplate, data)
+		if err != nil {
+			return err
+		}
+
+		// Determine if the user wants to use the prompt only
+		if promptOnly {
+			color.Yellow("====================Prompt========================")
+			color.Yellow("\n" + strings.TrimSpace(out) + "\n\n")
+			color.Yellow("==================================================")
+			return nil
+		}
+
+		ctx, err := systemContext(cmd.Context(), prompt.PullRequestTemplate)
+		if err != nil {
+			return err
+		}
+		stepClient, err := steps.get(stepPR)
+		if err != nil {
+			return err
+		}
+
+		color.Cyan("Describing pull request...")
+		resp, err := callCompletion(ctx, stepClient, out, os.Stdout)
+		if err != nil {
+			return err
+		}
+		color.Magenta(resp.Usage.String())
+		description := strings.TrimSpace(resp.Content)
+
+		// Generate the title from the description
+		out, err = util.GetTemplateByString(
+			prompt.SummarizeTitleTemplate,
+			util.Data{
+				"summary_points": description,
+			},
+		)
+		if err != nil {
+			return err
+		}
+		ctx, err = systemContext(cmd.Context(), prompt.SummarizeTitleTemplate)
+		if err != nil {
+			return err
+		}
+		stepClient, err = steps.get(stepTitle)
+		if err != nil {
+			return err
+		}
+
+		color.Cyan("Generating title for pull request...")
+		resp, err = stepClient.Completion(ctx, out)
+		if err != nil {
+			return err
+		}
+		color.Magenta(resp.Usage.String())
+		title := strings.TrimRight(strings.TrimSpace(resp.Content), ".")
+		if title == "" {
+			return errors.New("failed to get a pull request title")
+		}
+
+		if prompt.GetLanguage(viper.GetString("output.lang")) != prompt.DefaultLanguage {
+			title, err = translatePR(cmd.Context(), steps, title)
+			if err != nil {
+				return err
+			}
+			description, err = translatePR(cmd.Context(), steps, description)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Output pull request title and description
+		color.Yellow("================Pull Request Title================")
+		color.Yellow("\n" + title + "\n\n")
+		color.Yellow("=============Pull Request Description=============")
+		color.Yellow("\n" + description + "\n\n")
+		color.Yellow("==================================================")
+
+		if prFile == "" {
+			return nil
+		}
+		color.Cyan("Writing pull request description to " + prFile)
+		if err := os.WriteFile(prFile, []byte(description+"\n"), 0o600); err != nil {
+			return err
+		}
+		color.Cyan("Create the pull request with:")
+		color.Cyan("  gh pr create --base %s --title %q --body-file %s", prBase, title, prFile)
+		return nil
+	},
+}
+
+// translatePR translates a pull request title or description to output.lang.
+func translatePR(ctx context.Context, steps *stepClients, message string) (string, error) {
+	out, err := util.GetTemplateByString(
+		prompt.TranslationTemplate,
+		util.Data{
+			"output_language": prompt.GetLanguage(viper.GetString("output.lang")),
+			"output_message":  message,
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, err = systemContext(ctx, prompt.TranslationTemplate)
+	if err != nil {
+		return "", err
+	}
+	stepClient, err := steps.get(stepTranslate)
+	if err != nil {
+		return "", err
+	}
+
+	color.Cyan("Translating pull request to " + prompt.GetLanguage(viper.GetString("output.lang")))
+	resp, err := stepClient.Completion(ctx, out)
+	if err != nil {
+		return "", err
+	}
+	color.Magenta(resp.Usage.String())
+	return strings.TrimSpace(resp.Content), nil
+}
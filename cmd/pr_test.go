@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/prompt"
+	"github.com/appleboy/CodeGPT/provider/fake"
+
+	"github.com/spf13/viper"
+)
+
+// setupBranch commits the staged file of setupRepo to main and adds a feature
+// branch with one more commit.
+func setupBranch(t *testing.T) {
+	t.Helper()
+
+	runGit(t, "commit", "-q", "-m", "add hello")
+	runGit(t, "branch", "-M", "main")
+	runGit(t, "checkout", "-q", "-b", "feature")
+	if err := os.WriteFile("world.go", []byte("package main\n\nfunc world() {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "add", "world.go")
+	runGit(t, "commit", "-q", "-m", "add world", "-m", "World greets the whole world.")
+}
+
+func TestPRCommand(t *testing.T) {
+	dir := setupRepo(t)
+	setupBranch(t)
+	description := "## Summary\n\nAdd world.\n\n## Breaking Changes\n\nNone."
+	cfg := writeConfig(t, []fake.Response{
+		{Template: prompt.PullRequestTemplate, Content: description},
+		{Template: prompt.SummarizeTitleTemplate, Content: "Add a world function."},
+	})
+	file := filepath.Join(dir, "pr.md")
+
+	out, err := executeCommand(t, "pr", "--config", cfg, "--base", "main", "--file", file)
+	if err != nil {
+		t.Fatalf("pr failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Add a world function\n") {
+		t.Errorf("expected the title without trailing period in the output:\n%s", out)
+	}
+	if !strings.Contains(out, "--title \"Add a world function\" --body-file "+file) {
+		t.Errorf("expected the gh command in the output:\n%s", out)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != description+"\n" {
+		t.Errorf("unexpected description file:\n%s", data)
+	}
+}
+
+func TestPRCommandPromptOnly(t *testing.T) {
+	setupRepo(t)
+	setupBranch(t)
+	cfg := writeConfig(t, nil)
+
+	out, err := executeCommand(t, "pr", "--config", cfg, "--prompt_only")
+	if err != nil {
+		t.Fatalf("pr failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{"- add world\n  World greets the whole world.", "b/world.go"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the prompt:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "b/hello.go") {
+		t.Errorf("unexpected changes from main in the prompt:\n%s", out)
+	}
+}
+
+func TestPRCommandNoCommits(t *testing.T) {
+	setupRepo(t)
+	setupBranch(t)
+	cfg := writeConfig(t, nil)
+
+	_, err := executeCommand(t, "pr", "--config", cfg, "--base", "feature")
+	if err == nil || !strings.Contains(err.Error(), "no commits found") {
+		t.Fatalf("expected a no commits error, got %v", err)
+	}
+}
+
+func TestPRCommandCommitLogBudget(t *testing.T) {
+	setupRepo(t)
+	setupBranch(t)
+	moon := "package main\n"
+	for i := range 50 {
+		moon += fmt.Sprintf("\nfunc moon%d() {}\n", i)
+	}
+	if err := os.WriteFile("moon.go", []byte(moon), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "add", "moon.go")
+	runGit(t, "commit", "-q", "-m", "add moon", "-m", strings.Repeat("The moon explains a lot. ", 60))
+	cfg := writeConfig(t, nil)
+
+	// Leave room for the diff, but not for the diff and the long commit log
+	const limit = 100000
+	viper.Set("openai.context_limit", limit)
+	t.Cleanup(func() { viper.Set("openai.context_limit", nil) })
+	budget, estimator, err := diffBudget(prompt.PullRequestTemplate, stepPR, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff := runGit(t, "diff", "main...HEAD")
+	viper.Set("openai.context_limit", limit-budget+estimator.Count(diff)+300)
+
+	out, err := executeCommand(t, "pr", "--config", cfg, "--base", "main", "--prompt_only")
+	if err != nil {
+		t.Fatalf("pr failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{"Prompt: moon.go", "Prompt: world.go"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected the branch to be summarized file by file, missing %q:\n%s", want, out)
+		}
+	}
+}
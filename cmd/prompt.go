@@ -23,6 +23,9 @@ var defaultPromptDataKeys = []string{
 	prompt.SummarizeFileDiffTemplate,
 	prompt.SummarizeTitleTemplate,
 	prompt.ConventionalCommitTemplate,
+	prompt.PullRequestTemplate,
+	prompt.ChangelogSectionTemplate,
+	prompt.SplitCommitsTemplate,
 }
 
 // promptCmd is a Cobra command to load default prompt data into a specified folder.
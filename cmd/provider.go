@@ -3,14 +3,21 @@ package cmd
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/core/transport"
 	"github.com/appleboy/CodeGPT/provider/anthropic"
+	"github.com/appleboy/CodeGPT/provider/fake"
+	"github.com/appleboy/CodeGPT/provider/fallback"
 	"github.com/appleboy/CodeGPT/provider/gemini"
+	"github.com/appleboy/CodeGPT/provider/ollama"
 	"github.com/appleboy/CodeGPT/provider/openai"
 	"github.com/appleboy/CodeGPT/util"
 
+	"github.com/fatih/color"
 	"github.com/spf13/viper"
 )
 
@@ -28,7 +35,17 @@ func getAPIKey(viperKey string) (string, error) {
 	return viper.GetString(viperKey), nil
 }
 
-func NewOpenAI(ctx context.Context) (*openai.Client, error) {
+// getMaxRetries returns the number of retries for rate limited or failed requests.
+// It uses openai.max_retries when set (0 disables retries), otherwise the transport default.
+func getMaxRetries() int {
+	if viper.IsSet("openai.max_retries") {
+		return viper.GetInt("openai.max_retries")
+	}
+	return transport.DefaultMaxRetries
+}
+
+// NewOpenAI returns a new OpenAI (or Azure OpenAI) client for the given model
+func NewOpenAI(ctx context.Context, model string) (*openai.Client, error) {
 	var apiKey string
 
 	// Try to get API key from helper first, fallback to static config
@@ -56,9 +73,14 @@ func NewOpenAI(ctx context.Context) (*openai.Client, error) {
 		apiKey = key
 	}
 
+	caps, err := getCapabilities(model)
+	if err != nil {
+		return nil, err
+	}
+
 	return openai.New(
 		openai.WithToken(apiKey),
-		openai.WithModel(viper.GetString("openai.model")),
+		openai.WithModel(model),
 		openai.WithOrgID(viper.GetString("openai.org_id")),
 		openai.WithProxyURL(viper.GetString("openai.proxy")),
 		openai.WithSocksURL(viper.GetString("openai.socks")),
@@ -73,11 +95,14 @@ func NewOpenAI(ctx context.Context) (*openai.Client, error) {
 		openai.WithTopP(float32(viper.GetFloat64("openai.top_p"))),
 		openai.WithFrequencyPenalty(float32(viper.GetFloat64("openai.frequency_penalty"))),
 		openai.WithPresencePenalty(float32(viper.GetFloat64("openai.presence_penalty"))),
+		openai.WithMaxRetries(getMaxRetries()),
+		openai.WithSystemPrompt(viper.GetString("prompt.system")),
+		openai.WithCapabilities(caps),
 	)
 }
 
-// NewGemini returns a new Gemini client
-func NewGemini(ctx context.Context) (*gemini.Client, error) {
+// NewGemini returns a new Gemini client for the given model
+func NewGemini(ctx context.Context, model string) (*gemini.Client, error) {
 	var apiKey string
 
 	// Try gemini.api_key_helper first
@@ -132,30 +157,39 @@ func NewGemini(ctx context.Context) (*gemini.Client, error) {
 		}
 	}
 
+	caps, err := getCapabilities(model)
+	if err != nil {
+		return nil, err
+	}
+
 	return gemini.New(
 		ctx,
 		gemini.WithToken(apiKey),
-		gemini.WithModel(viper.GetString("openai.model")),
+		gemini.WithModel(model),
 		gemini.WithMaxTokens(viper.GetInt32("openai.max_tokens")),
 		gemini.WithTemperature(float32(viper.GetFloat64("openai.temperature"))),
 		gemini.WithTopP(float32(viper.GetFloat64("openai.top_p"))),
 		gemini.WithBackend(viper.GetString("gemini.backend")),
 		gemini.WithProject(viper.GetString("gemini.project_id")),
 		gemini.WithLocation(viper.GetString("gemini.location")),
+		gemini.WithMaxRetries(getMaxRetries()),
+		gemini.WithSystemPrompt(viper.GetString("prompt.system")),
+		gemini.WithCapabilities(caps),
 	)
 }
 
 // NewAnthropic creates a new instance of the anthropic.Client using configuration
 // values retrieved from Viper. The configuration values include the API key,
-// model, maximum tokens, temperature, and top_p.
+// maximum tokens, temperature, and top_p.
 //
 // Parameters:
 //   - ctx: The context for the client.
+//   - model: The model to use for requests.
 //
 // Returns:
 //   - A pointer to an anthropic.Client instance.
 //   - An error if the client could not be created.
-func NewAnthropic(ctx context.Context) (*anthropic.Client, error) {
+func NewAnthropic(ctx context.Context, model string) (*anthropic.Client, error) {
 	var apiKey string
 
 	// Try to get API key from helper first, fallback to static config
@@ -183,9 +217,14 @@ func NewAnthropic(ctx context.Context) (*anthropic.Client, error) {
 		apiKey = key
 	}
 
+	caps, err := getCapabilities(model)
+	if err != nil {
+		return nil, err
+	}
+
 	return anthropic.New(
 		anthropic.WithAPIKey(apiKey),
-		anthropic.WithModel(viper.GetString("openai.model")),
+		anthropic.WithModel(model),
 		anthropic.WithMaxTokens(viper.GetInt("openai.max_tokens")),
 		anthropic.WithTemperature(float32(viper.GetFloat64("openai.temperature"))),
 		anthropic.WithTopP(float32(viper.GetFloat64("openai.top_p"))),
@@ -193,18 +232,177 @@ func NewAnthropic(ctx context.Context) (*anthropic.Client, error) {
 		anthropic.WithSocksURL(viper.GetString("openai.socks")),
 		anthropic.WithSkipVerify(viper.GetBool("openai.skip_verify")),
 		anthropic.WithTimeout(viper.GetDuration("openai.timeout")),
+		anthropic.WithMaxRetries(getMaxRetries()),
+		anthropic.WithSystemPrompt(viper.GetString("prompt.system")),
+		anthropic.WithCapabilities(caps),
 	)
 }
 
-// GetClient returns the generative client based on the platform
+// NewOllama creates a new instance of the ollama.Client for the given model using
+// configuration values retrieved from Viper. Ollama runs locally and does not need an API key,
+// the model parameters are shared with the other providers under the openai namespace.
+func NewOllama(ctx context.Context, model string) (*ollama.Client, error) {
+	caps, err := getCapabilities(model)
+	if err != nil {
+		return nil, err
+	}
+
+	return ollama.New(
+		ollama.WithBaseURL(viper.GetString("ollama.base_url")),
+		ollama.WithModel(model),
+		ollama.WithMaxTokens(viper.GetInt("openai.max_tokens")),
+		ollama.WithTemperature(float32(viper.GetFloat64("openai.temperature"))),
+		ollama.WithTopP(float32(viper.GetFloat64("openai.top_p"))),
+		ollama.WithNumCtx(viper.GetInt("ollama.num_ctx")),
+		ollama.WithKeepAlive(viper.GetString("ollama.keep_alive")),
+		ollama.WithFormat(viper.GetString("ollama.format")),
+		ollama.WithProxyURL(viper.GetString("openai.proxy")),
+		ollama.WithSocksURL(viper.GetString("openai.socks")),
+		ollama.WithSkipVerify(viper.GetBool("openai.skip_verify")),
+		ollama.WithTimeout(viper.GetDuration("openai.timeout")),
+		ollama.WithHeaders(viper.GetStringSlice("openai.headers")),
+		ollama.WithMaxRetries(getMaxRetries()),
+		ollama.WithSystemPrompt(viper.GetString("prompt.system")),
+		ollama.WithCapabilities(caps),
+	)
+}
+
+// NewFake creates a new instance of the fake.Client answering with the scripted
+// responses of the fake.fixture file. It needs no network access or API key.
+func NewFake(ctx context.Context, model string) (*fake.Client, error) {
+	return fake.New(
+		fake.WithFixture(viper.GetString("fake.fixture")),
+	)
+}
+
+// GetClient returns the generative client based on the platform.
+// When a providers list is configured, it returns a fallback chain that tries
+// each provider in order instead of the single platform.
+// When cache.enabled is set, responses are served from the local cache.
+// Every request is recorded to the usage ledger unless usage.enabled is false.
 func GetClient(ctx context.Context, p core.Platform) (core.Generative, error) {
+	var (
+		client core.Generative
+		err    error
+	)
+	if entries := viper.GetStringSlice("providers"); len(entries) > 0 {
+		client, err = newFallbackClient(ctx, entries)
+	} else {
+		client, err = newClient(ctx, p, viper.GetString("openai.model"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	client, err = withCache(client, cacheProvider(client, p), cacheModel(client))
+	if err != nil {
+		return nil, err
+	}
+	return withLedger(ctx, client, p, viper.GetString("openai.model"))
+}
+
+// cacheProvider returns the provider part of the cache key of client, "fallback"
+// for a fallback chain.
+func cacheProvider(client core.Generative, p core.Platform) string {
+	if _, ok := client.(*fallback.Client); ok {
+		return "fallback"
+	}
+	return p.String()
+}
+
+// cacheModel returns the model part of the cache key of client. A fallback
+// chain lists its backends, so editing or reordering the chain does not serve
+// responses cached for the previous one.
+func cacheModel(client core.Generative) string {
+	fc, ok := client.(*fallback.Client)
+	if !ok {
+		return viper.GetString("openai.model")
+	}
+	backends := fc.Backends()
+	chain := make([]string, 0, len(backends))
+	for _, b := range backends {
+		chain = append(chain, b.Provider.String()+":"+b.Model)
+	}
+	return strings.Join(chain, ",")
+}
+
+// newClient returns the generative client for the platform and model.
+func newClient(ctx context.Context, p core.Platform, model string) (core.Generative, error) {
 	switch p {
 	case core.Gemini:
-		return NewGemini(ctx)
+		return NewGemini(ctx, model)
 	case core.OpenAI, core.Azure:
-		return NewOpenAI(ctx)
+		return NewOpenAI(ctx, model)
 	case core.Anthropic:
-		return NewAnthropic(ctx)
+		return NewAnthropic(ctx, model)
+	case core.Ollama:
+		return NewOllama(ctx, model)
+	case core.Fake:
+		return NewFake(ctx, model)
 	}
 	return nil, errors.New("invalid provider")
 }
+
+// newFallbackClient builds a fallback chain from entries in the form
+// "provider" or "provider:model". Entries without a model use openai.model
+// for openai.provider, and the default model of their provider otherwise.
+func newFallbackClient(ctx context.Context, entries []string) (core.Generative, error) {
+	opts := []fallback.Option{
+		fallback.WithOnFallback(func(failed, next fallback.Backend, err error) {
+			color.Yellow("%s is unavailable (%v), falling back to %s", failed, err, next)
+		}),
+	}
+
+	for _, entry := range splitProviderEntries(entries) {
+		p, model, _ := strings.Cut(entry, ":")
+		provider := core.Platform(strings.TrimSpace(p))
+		if !provider.IsValid() {
+			return nil, fmt.Errorf("invalid provider %q in providers list", p)
+		}
+		model = strings.TrimSpace(model)
+		if model == "" {
+			model = entryModel(provider)
+		}
+
+		client, err := newClient(ctx, provider, model)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s client: %w", provider, err)
+		}
+		opts = append(opts, fallback.WithBackend(provider, model, client))
+	}
+
+	return fallback.New(opts...)
+}
+
+// entryModel returns the model of a providers list entry without one. The
+// configured openai.model belongs to openai.provider, so other providers use
+// their own default model instead of being sent a model they do not serve.
+func entryModel(p core.Platform) string {
+	if p == core.Platform(viper.GetString("openai.provider")) {
+		return viper.GetString("openai.model")
+	}
+	switch p {
+	case core.Gemini:
+		return gemini.DefaultModel
+	case core.Anthropic:
+		return anthropic.DefaultModel
+	case core.Ollama:
+		return ollama.DefaultModel
+	case core.OpenAI, core.Azure:
+		return openai.DefaultModel
+	}
+	return ""
+}
+
+// splitProviderEntries flattens comma separated entries, so the providers list
+// can be given both as a YAML list and as a single comma separated string.
+func splitProviderEntries(entries []string) []string {
+	var result []string
+	for _, entry := range entries {
+		for part := range strings.SplitSeq(entry, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/provider/anthropic"
+	"github.com/appleboy/CodeGPT/provider/fake"
+	"github.com/appleboy/CodeGPT/provider/fallback"
+	"github.com/appleboy/CodeGPT/provider/ollama"
+
+	"github.com/spf13/viper"
+)
+
+func TestEntryModel(t *testing.T) {
+	viper.Set("openai.provider", "openai")
+	viper.Set("openai.model", "gpt-4o-mini")
+	t.Cleanup(func() {
+		viper.Set("openai.provider", nil)
+		viper.Set("openai.model", nil)
+	})
+
+	tests := []struct {
+		provider core.Platform
+		want     string
+	}{
+		{core.OpenAI, "gpt-4o-mini"},
+		{core.Anthropic, anthropic.DefaultModel},
+		{core.Ollama, ollama.DefaultModel},
+	}
+	for _, tt := range tests {
+		if got := entryModel(tt.provider); got != tt.want {
+			t.Errorf("entryModel(%s) = %q, want %q", tt.provider, got, tt.want)
+		}
+	}
+}
+
+func TestCacheKeyFallbackChain(t *testing.T) {
+	chain := func(models ...string) core.Generative {
+		var opts []fallback.Option
+		for _, m := range models {
+			client, err := fake.New(fake.WithResponses(fake.Response{Content: "ok"}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts = append(opts, fallback.WithBackend(core.Fake, m, client))
+		}
+		client, err := fallback.New(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+
+	client := chain("a", "b")
+	if got := cacheProvider(client, core.OpenAI); got != "fallback" {
+		t.Errorf("cacheProvider() = %q, want fallback", got)
+	}
+	if got := cacheModel(client); got != "fake:a,fake:b" {
+		t.Errorf("cacheModel() = %q, want fake:a,fake:b", got)
+	}
+	if cacheModel(client) == cacheModel(chain("b", "a")) {
+		t.Error("expected reordering the chain to change the cache key")
+	}
+}
+
+func TestCacheParams(t *testing.T) {
+	t.Cleanup(func() {
+		for _, key := range []string{"ollama.format", "ollama.num_ctx", "openai.frequency_penalty", "models"} {
+			viper.Set(key, nil)
+		}
+	})
+
+	seen := map[string]bool{strings.Join(cacheParams(), "|"): true}
+	for key, val := range map[string]any{
+		"ollama.format":            "json",
+		"ollama.num_ctx":           8192,
+		"openai.frequency_penalty": 0.5,
+		"models":                   []map[string]any{{"name": "gpt-4o", "tools": false}},
+	} {
+		viper.Set(key, val)
+		params := strings.Join(cacheParams(), "|")
+		if seen[params] {
+			t.Errorf("expected %s to change the cache key", key)
+		}
+		seen[params] = true
+	}
+}
+
+func TestStepModel(t *testing.T) {
+	viper.Set("openai.provider", "openai")
+	viper.Set("openai.model", "gpt-4o-mini")
+	t.Cleanup(func() {
+		for _, key := range []string{
+			"openai.provider", "openai.model",
+			"steps.title.provider", "steps.title.model", "steps.review.model",
+		} {
+			viper.Set(key, nil)
+		}
+	})
+	viper.Set("steps.title.provider", "ollama")
+	viper.Set("steps.review.model", "o3-mini")
+
+	tests := []struct {
+		step         string
+		wantProvider core.Platform
+		wantModel    string
+	}{
+		{stepSummarize, core.OpenAI, "gpt-4o-mini"},
+		{stepTitle, core.Ollama, ollama.DefaultModel},
+		{stepReview, core.OpenAI, "o3-mini"},
+	}
+	for _, tt := range tests {
+		provider, model := stepModel(tt.step)
+		if provider != tt.wantProvider || model != tt.wantModel {
+			t.Errorf("stepModel(%s) = %s, %q, want %s, %q", tt.step, provider, model, tt.wantProvider, tt.wantModel)
+		}
+	}
+
+	viper.Set("steps.title.model", "llama3.1")
+	if _, model := stepModel(stepTitle); model != "llama3.1" {
+		t.Errorf("stepModel(title) model = %q, want the step model", model)
+	}
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/appleboy/CodeGPT/changelog"
+	"github.com/appleboy/CodeGPT/git"
+
+	"github.com/erikgeiser/promptkit/confirmation"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	releaseVersion string
+	releaseDryRun  bool
+)
+
+func init() {
+	releaseCmd.PersistentFlags().StringVar(&releaseVersion, "version", "",
+		"version to release instead of the suggested one")
+	releaseCmd.PersistentFlags().StringVar(&changelogFormat, "format", string(changelog.Markdown),
+		"release notes format, markdown or keepachangelog")
+	releaseCmd.PersistentFlags().BoolVar(&changelogPolish, "polish", false,
+		"ask the model to rewrite every section into user-facing release notes")
+	releaseCmd.PersistentFlags().BoolVar(&releaseDryRun, "dry_run", false,
+		"show the next version and release notes without creating a tag")
+	releaseCmd.PersistentFlags().BoolVar(&noConfirm, "no_confirm", false,
+		"create the tag without confirmation")
+	releaseCmd.PersistentFlags().
+		StringVar(&commitModel, "model", "gpt-4o", "specify which OpenAI model to use for polishing")
+	releaseCmd.PersistentFlags().BoolVar(&noCache, "no_cache", false,
+		"bypass the local response cache for this run")
+}
+
+// releaseCmd suggests the next semantic version from the conventional commits since
+// the latest release and tags HEAD with it.
+var releaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Suggest the next semantic version and create its tag",
+	Example: `  codegpt release --dry_run
+  codegpt release --polish`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := check(cmd.Context()); err != nil {
+			return err
+		}
+
+		format := changelog.Format(changelogFormat)
+		if !format.IsValid() {
+			return fmt.Errorf("unsupported changelog format %q, use markdown or keepachangelog", format)
+		}
+
+		g := git.New()
+		latest, tag, err := latestRelease(cmd.Context(), g)
+		if err != nil {
+			return err
+		}
+
+		// Without a release yet, the whole history goes into the first one
+		rev := "HEAD"
+		if tag != "" {
+			rev = tag + "..HEAD"
+		}
+		entries, err := changelogEntries(cmd.Context(), rev)
+		if err != nil {
+			return fmt.Errorf("nothing to release: %w", err)
+		}
+
+		bump := latest.Applied(changelog.NextBump(entries))
+		if bump == changelog.BumpNone && releaseVersion == "" {
+			return fmt.Errorf("nothing to release: none of the %d commits needs a version bump", len(entries))
+		}
+		next := latest.Bump(bump)
+		if releaseVersion != "" {
+			next, err = changelog.ParseVersion(releaseVersion)
+			if err != nil {
+				return err
+			}
+			if next.Compare(latest) <= 0 {
+				return fmt.Errorf("version %s is not newer than the latest release %s", next, latest)
+			}
+		}
+
+		if tag == "" {
+			color.Green("No release found, %d commits suggest a %s bump to %s", len(entries), bump, next)
+		} else {
+			color.Green(
+				"Latest release is %s, %d commits since suggest a %s bump to %s",
+				tag, len(entries), bump, next,
+			)
+		}
+
+		release := changelog.NewRelease(next.String(), time.Now(), format, entries)
+		if changelogPolish {
+			if err := polishRelease(cmd, release); err != nil {
+				return err
+			}
+		}
+		notes := release.String()
+
+		color.Yellow("==================Release Notes===================")
+		color.Yellow("\n" + notes + "\n")
+		color.Yellow("==================================================")
+
+		if releaseDryRun {
+			return nil
+		}
+		if !noConfirm {
+			ready, err := confirmation.New("Create annotated tag "+next.String()+"?", confirmation.Yes).
+				RunPrompt()
+			if err != nil || !ready {
+				return err
+			}
+		}
+
+		if err := g.Tag(cmd.Context(), next.String(), notes); err != nil {
+			return err
+		}
+		color.Cyan("Created tag %s, publish it with: git push origin %s", next, next)
+		return nil
+	},
+}
+
+// latestRelease returns the highest semantic version tagged on the history of HEAD,
+// ignoring pre-releases, and its tag. Without such a tag, it returns v0.0.0 and an
+// empty tag.
+func latestRelease(ctx context.Context, g *git.Command) (changelog.Version, string, error) {
+	tags, err := g.Tags(ctx)
+	if err != nil {
+		return changelog.Version{}, "", err
+	}
+
+	latest, latestTag := changelog.Version{Prefix: "v"}, ""
+	for _, tag := range tags {
+		v, err := changelog.ParseVersion(tag)
+		if err != nil || v.PreRelease != "" {
+			continue
+		}
+		if latestTag == "" || v.Compare(latest) > 0 {
+			latest, latestTag = v, tag
+		}
+	}
+	return latest, latestTag, nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReleaseCommand(t *testing.T) {
+	setupRepo(t)
+	setupHistory(t)
+	cfg := writeConfig(t, nil)
+
+	out, err := executeCommand(t, "release", "--config", cfg, "--dry_run")
+	if err != nil {
+		t.Fatalf("release failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Latest release is v1.0.0, 3 commits since suggest a minor bump to v1.1.0") ||
+		!strings.Contains(out, "## v1.1.0 (") {
+		t.Errorf("expected a minor bump to v1.1.0:\n%s", out)
+	}
+	if tags := runGit(t, "tag", "--list"); tags != "v1.0.0\n" {
+		t.Errorf("dry run created a tag: %q", tags)
+	}
+
+	runGit(t, "commit", "-q", "--allow-empty", "-m", "refactor!: drop the old config")
+	out, err = executeCommand(t, "release", "--config", cfg, "--no_confirm")
+	if err != nil {
+		t.Fatalf("release failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Created tag v2.0.0") {
+		t.Errorf("expected a major bump to v2.0.0:\n%s", out)
+	}
+	notes := runGit(t, "tag", "--list", "--format=%(contents)", "v2.0.0")
+	if !strings.Contains(notes, "### Breaking Changes\n\n- drop the old config") {
+		t.Errorf("expected the release notes in the tag message:\n%s", notes)
+	}
+
+	_, err = executeCommand(t, "release", "--config", cfg, "--no_confirm")
+	if err == nil || !strings.Contains(err.Error(), "nothing to release") {
+		t.Errorf("expected nothing to release, got %v", err)
+	}
+}
+
+func TestReleaseCommandVersion(t *testing.T) {
+	setupRepo(t)
+	runGit(t, "commit", "-q", "-m", "fix: first fix")
+	cfg := writeConfig(t, nil)
+
+	out, err := executeCommand(t, "release", "--config", cfg, "--dry_run")
+	if err != nil {
+		t.Fatalf("release failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "No release found, 1 commits suggest a patch bump to v0.0.1") {
+		t.Errorf("expected the first release to be v0.0.1:\n%s", out)
+	}
+
+	out, err = executeCommand(t, "release", "--config", cfg, "--no_confirm", "--version", "v1.0.0")
+	if err != nil {
+		t.Fatalf("release failed: %v\n%s", err, out)
+	}
+	if tags := runGit(t, "tag", "--list"); tags != "v1.0.0\n" {
+		t.Errorf("expected the v1.0.0 tag, got %q", tags)
+	}
+
+	runGit(t, "commit", "-q", "--allow-empty", "-m", "fix: second fix")
+	_, err = executeCommand(t, "release", "--config", cfg, "--version", "v0.9.0")
+	if err == nil || !strings.Contains(err.Error(), "is not newer than the latest release v1.0.0") {
+		t.Errorf("expected an older version error, got %v", err)
+	}
+}
+
+func TestReleaseCommandNoBump(t *testing.T) {
+	setupRepo(t)
+	runGit(t, "commit", "-q", "-m", "feat: add hello")
+	runGit(t, "tag", "v0.1.0")
+	cfg := writeConfig(t, nil)
+
+	for _, subject := range []string{"docs: explain hello", "chore: tidy"} {
+		runGit(t, "commit", "-q", "--allow-empty", "-m", subject)
+	}
+	_, err := executeCommand(t, "release", "--config", cfg, "--no_confirm")
+	if err == nil || !strings.Contains(err.Error(), "nothing to release") {
+		t.Errorf("expected nothing to release, got %v", err)
+	}
+	if tags := runGit(t, "tag", "--list"); tags != "v0.1.0\n" {
+		t.Errorf("expected no new tag, got %q", tags)
+	}
+
+	// Before 1.0.0, breaking changes only bump the minor version
+	runGit(t, "commit", "-q", "--allow-empty", "-m", "refactor!: drop the old config")
+	out, err := executeCommand(t, "release", "--config", cfg, "--dry_run")
+	if err != nil {
+		t.Fatalf("release failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Latest release is v0.1.0, 3 commits since suggest a minor bump to v0.2.0") {
+		t.Errorf("expected a minor bump to v0.2.0:\n%s", out)
+	}
+}
@@ -1,15 +1,19 @@
 package cmd
 
 import (
-	"os"
+	"context"
+	"fmt"
+	"maps"
 	"strings"
 
 	"github.com/appleboy/CodeGPT/core"
 	"github.com/appleboy/CodeGPT/git"
 	"github.com/appleboy/CodeGPT/prompt"
+	"github.com/appleboy/CodeGPT/review"
 	"github.com/appleboy/CodeGPT/util"
 
 	"github.com/fatih/color"
+	"github.com/rodaine/table"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -18,6 +22,19 @@ import (
 // The total length of input tokens and generated tokens is limited by the model's context length.
 var maxTokens int
 
+// defaultReviewMaxTokens is the response budget of a review when review.max_tokens is
+// not set, as structured findings do not fit the default openai.max_tokens.
+const defaultReviewMaxTokens = 2048
+
+// reviewMaxTokens returns the maximum number of tokens of a review: review.max_tokens
+// when set, otherwise openai.max_tokens raised to defaultReviewMaxTokens.
+func reviewMaxTokens() int {
+	if viper.IsSet("review.max_tokens") {
+		return viper.GetInt("review.max_tokens")
+	}
+	return max(viper.GetInt("openai.max_tokens"), defaultReviewMaxTokens)
+}
+
 func init() {
 	reviewCmd.PersistentFlags().IntVar(&diffUnified, "diff_unified", 3,
 		"Generate diffs with <n> lines of context (default: 3)")
@@ -30,26 +47,66 @@ func init() {
 		StringSliceVar(&excludeList, "exclude_list", []string{}, "Files to exclude from git diff")
 	reviewCmd.PersistentFlags().BoolVar(&commitAmend, "amend", false,
 		"Replace the tip of the current branch by creating a new commit")
+	reviewCmd.PersistentFlags().BoolVar(&diffUnstaged, "unstaged", false,
+		"Review unstaged changes instead of staged changes")
+	reviewCmd.PersistentFlags().StringVar(&diffBase, "base", "",
+		"Review changes on the current branch since it forked from <ref>")
 	reviewCmd.PersistentFlags().BoolVar(&promptOnly, "prompt_only", false,
 		"Show prompt only without sending request to OpenAI")
 	reviewCmd.PersistentFlags().Bool("stream", false,
 		"enable streaming output for real-time token display")
+	reviewCmd.PersistentFlags().BoolVar(&noCache, "no_cache", false,
+		"bypass the local response cache for this run")
+	reviewCmd.PersistentFlags().String("format", "",
+		"output format: table, json, markdown, sarif or github (default: github on GitHub Actions, table otherwise)")
+	reviewCmd.PersistentFlags().String("fail_on", "",
+		"exit with an error when findings of this severity or higher exist: critical, high, medium, low or info")
+	reviewCmd.PersistentFlags().String("post", "",
+		"post the review to the pull or merge request of the CI run: github or gitlab")
 	_ = viper.BindPFlag("openai.stream", reviewCmd.PersistentFlags().Lookup("stream"))
+	_ = viper.BindPFlag("review.format", reviewCmd.PersistentFlags().Lookup("format"))
+	_ = viper.BindPFlag("review.fail_on", reviewCmd.PersistentFlags().Lookup("fail_on"))
+	_ = viper.BindPFlag("review.post", reviewCmd.PersistentFlags().Lookup("post"))
 }
 
 var reviewCmd = &cobra.Command{
-	Use:   "review",
+	Use:   "review " + diffArgsUsage,
 	Short: "Auto review code changes",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := check(cmd.Context()); err != nil {
 			return err
 		}
 
-		g := git.New(
-			git.WithDiffUnified(viper.GetInt("git.diff_unified")),
-			git.WithExcludeList(viper.GetStringSlice("git.exclude_list")),
-			git.WithEnableAmend(commitAmend),
-		)
+		// An explicit --max_tokens wins over review.max_tokens
+		if !cmd.Flags().Changed("max_tokens") {
+			viper.Set("openai.max_tokens", reviewMaxTokens())
+		}
+
+		format, err := reviewFormat()
+		if err != nil {
+			return err
+		}
+		post, err := reviewPost()
+		if err != nil {
+			return err
+		}
+		failOn := core.Severity(strings.ToLower(viper.GetString("review.fail_on")))
+		if failOn != "" && !failOn.IsValid() {
+			return fmt.Errorf("unsupported severity %q, use critical, high, medium, low or info", failOn)
+		}
+		// Gating or posting an unreadable review would silently pass it
+		strict := failOn != "" || post != ""
+		// Keep stdout for the review document, so it can be redirected to a file
+		if format != review.Table {
+			output := color.Output
+			color.Output = cmd.ErrOrStderr()
+			defer func() { color.Output = output }()
+		}
+
+		g, err := newGitCommand(cmd, args)
+		if err != nil {
+			return err
+		}
 		diff, err := g.DiffFiles(cmd.Context())
 		if err != nil {
 			return err
@@ -68,64 +125,224 @@ var reviewCmd = &cobra.Command{
 			return err
 		}
 
-		currentModel := viper.GetString("openai.model")
+		_, currentModel := stepModel(stepReview)
 		color.Green("Code review your changes using " + currentModel + " model")
+		steps := newStepClients(cmd.Context(), client)
 
-		out, err := util.GetTemplateByString(
-			prompt.CodeReviewTemplate,
-			util.Data{
-				"file_diffs": diff,
-			},
-		)
-		if err != nil && !promptOnly {
-			return err
+		// Ask for the review in the output language directly, translating the
+		// findings afterwards would lose their structure
+		vars := util.Data{}
+		if lang := prompt.GetLanguage(viper.GetString("output.lang")); lang != prompt.DefaultLanguage {
+			vars["output_language"] = lang
 		}
 
-		// determine if the user wants to use the prompt only
-		if promptOnly {
-			color.Yellow("====================Prompt========================")
-			color.Yellow("\n" + strings.TrimSpace(out) + "\n\n")
-			color.Yellow("==================================================")
-			return nil
-		}
-
-		// Get summarize comment from diff datas
-		color.Cyan("We are trying to review code changes")
-		resp, err := callCompletion(cmd.Context(), client, out, os.Stdout)
+		// Review large changesets file by file
+		var result *core.Review
+		files := git.SplitDiff(diff)
+		mapReduce, err := useMapReduce(diff, files, prompt.CodeReviewTemplate, stepReview, vars)
 		if err != nil {
 			return err
 		}
-		summarizeMessage := resp.Content
-		color.Magenta(resp.Usage.String())
-
-		if prompt.GetLanguage(viper.GetString("output.lang")) != prompt.DefaultLanguage {
-			out, err = util.GetTemplateByString(
-				prompt.TranslationTemplate,
-				util.Data{
-					"output_language": prompt.GetLanguage(viper.GetString("output.lang")),
-					"output_message":  summarizeMessage,
-				},
-			)
+		if mapReduce {
+			prompts, err := renderFilePrompts(files, prompt.CodeReviewTemplate, stepReview, vars)
+			if err != nil {
+				return err
+			}
+
+			// determine if the user wants to use the prompt only
+			if promptOnly {
+				printFilePrompts(files, prompts)
+				return nil
+			}
+
+			ctx, err := systemContext(cmd.Context(), prompt.CodeReviewTemplate)
+			if err != nil {
+				return err
+			}
+			stepClient, err := steps.get(stepReview)
+			if err != nil {
+				return err
+			}
+
+			color.Cyan("We are trying to review code changes of %d files", len(files))
+			results, err := completeFiles(ctx, stepClient.GetReviewFindings, files, prompts)
+			if err != nil {
+				return err
+			}
+			printFileUsage(results)
+			if result, err = mergeReviews(results, strict); err != nil {
+				return err
+			}
+		} else {
+			// Trim the diff so the request fits the model's context window
+			diff, err = fitDiffWith(diff, prompt.CodeReviewTemplate, stepReview, vars)
+			if err != nil {
+				return err
+			}
+
+			data := util.Data{"file_diffs": diff}
+			maps.Copy(data, vars)
+			out, err := util.GetTemplateByString(prompt.CodeReviewTemplate, data)
+			if err != nil && !promptOnly {
+				return err
+			}
+
+			// determine if the user wants to use the prompt only
+			if promptOnly {
+				color.Yellow("====================Prompt========================")
+				color.Yellow("\n" + strings.TrimSpace(out) + "\n\n")
+				color.Yellow("==================================================")
+				return nil
+			}
+
+			ctx, err := systemContext(cmd.Context(), prompt.CodeReviewTemplate)
+			if err != nil {
+				return err
+			}
+			stepClient, err := steps.get(stepReview)
 			if err != nil {
 				return err
 			}
 
-			// translate a git commit message
-			color.Cyan("we are trying to translate code review to " +
-				prompt.GetLanguage(viper.GetString("output.lang")) + " language")
-			resp, err := callCompletion(cmd.Context(), client, out, os.Stdout)
+			// Get the review findings from diff datas
+			color.Cyan("We are trying to review code changes")
+			resp, err := reviewFindings(ctx, stepClient, out)
 			if err != nil {
 				return err
 			}
 			color.Magenta(resp.Usage.String())
-			summarizeMessage = resp.Content
+			if result, err = parseReview(resp.Content, strict); err != nil {
+				return err
+			}
 		}
 
-		// Output core review summary
-		color.Yellow("================Review Summary====================")
-		color.Yellow("\n" + strings.TrimSpace(summarizeMessage) + "\n\n")
-		color.Yellow("==================================================")
+		// Output core review summary and findings
+		result.Sort()
+		if format != review.Table {
+			if err := review.Write(cmd.OutOrStdout(), result, format); err != nil {
+				return err
+			}
+		} else {
+			printReview(result)
+		}
+		color.Cyan(findingCounts(result))
+		if err := postReview(cmd.Context(), post, result); err != nil {
+			return err
+		}
+
+		// Fail the command, e.g. a CI job, when serious findings remain
+		if failOn != "" {
+			if n := result.CountAtLeast(failOn); n > 0 {
+				return fmt.Errorf("found %d findings with severity %s or higher", n, failOn)
+			}
+		}
 
 		return nil
 	},
 }
+
+// reviewFormat returns the output format of the review. It defaults to GitHub
+// annotations when running under the github platform.
+func reviewFormat() (review.Format, error) {
+	format := review.Format(viper.GetString("review.format"))
+	switch {
+	case format == "" && viper.GetString("platform") == GITHUB:
+		return review.GitHub, nil
+	case format == "":
+		return review.Table, nil
+	case !format.IsValid():
+		return "", fmt.Errorf("unsupported review format %q, use table, json, markdown, sarif or github", format)
+	}
+	return format, nil
+}
+
+// reviewFindings asks client for the review findings of content. Streaming
+// shows the raw answer while it is generated, so it uses a plain completion
+// and relies on the prompt asking for JSON.
+func reviewFindings(ctx context.Context, client core.Generative, content string) (*core.Response, error) {
+	if viper.GetBool("openai.stream") {
+		return callCompletion(ctx, client, content, color.Output)
+	}
+	return client.GetReviewFindings(ctx, content)
+}
+
+// parseReview decodes the review findings in content. A model that ignored
+// the requested format still gets its answer shown, as the review summary,
+// unless strict is set, e.g. when the findings gate a CI job.
+func parseReview(content string, strict bool) (*core.Review, error) {
+	review, err := core.ParseReview(content)
+	switch {
+	case err != nil && strict:
+		return nil, fmt.Errorf("unable to read the review findings: %w", err)
+	case err != nil:
+		color.Yellow("Unable to read the review findings, showing the raw review: %v", err)
+		return &core.Review{Summary: content}, nil
+	}
+	return review, nil
+}
+
+// mergeReviews combines the per-file reviews of a map-reduce run into one
+// review, with a summary section per file. With strict, an unreadable file
+// review is an error, as in parseReview.
+func mergeReviews(results []fileResponse, strict bool) (*core.Review, error) {
+	var (
+		review core.Review
+		sb     strings.Builder
+	)
+	for _, r := range results {
+		fileReview, err := parseReview(r.resp.Content, strict)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", r.name, err)
+		}
+		fmt.Fprintf(&sb, "### %s\n\n%s\n\n", r.name, strings.TrimSpace(fileReview.Summary))
+		for _, f := range fileReview.Findings {
+			if f.File == "" {
+				f.File = r.name
+			}
+			review.Findings = append(review.Findings, f)
+		}
+	}
+	review.Summary = sb.String()
+	return &review, nil
+}
+
+// printReview prints the review summary followed by a table of findings for
+// every file.
+func printReview(review *core.Review) {
+	color.Yellow("================Review Summary====================")
+	color.Yellow("\n" + strings.TrimSpace(review.Summary) + "\n\n")
+
+	if len(review.Findings) == 0 {
+		color.Green("No findings")
+	}
+
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+	for i := 0; i < len(review.Findings); {
+		file := review.Findings[i].File
+		color.Cyan("\n%s", file)
+
+		tbl := table.New("Severity", "Lines", "Category", "Message", "Suggestion")
+		tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt).WithWriter(color.Output)
+		for ; i < len(review.Findings) && review.Findings[i].File == file; i++ {
+			f := review.Findings[i]
+			tbl.AddRow(f.Severity, f.Lines(), f.Category, oneLine(f.Message), oneLine(f.Suggestion))
+		}
+		tbl.Print()
+	}
+	color.Yellow("\n==================================================")
+}
+
+// findingCounts returns a line with the number of findings of every severity.
+func findingCounts(r *core.Review) string {
+	counts := make([]string, 0, len(core.Severities))
+	for _, s := range core.Severities {
+		counts = append(counts, fmt.Sprintf("%d %s", r.Count(s), s))
+	}
+	return "Findings: " + strings.Join(counts, ", ")
+}
+
+// oneLine collapses the whitespace of s, including newlines, to fit a table cell.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,469 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/prompt"
+	"github.com/appleboy/CodeGPT/provider/fake"
+	"github.com/appleboy/CodeGPT/review"
+	"github.com/appleboy/CodeGPT/review/github"
+	"github.com/appleboy/CodeGPT/review/gitlab"
+
+	"github.com/spf13/viper"
+)
+
+func TestReviewCommand(t *testing.T) {
+	setupRepo(t)
+	cfg := writeConfig(t, []fake.Response{
+		{Template: prompt.CodeReviewTemplate, Content: "Looks good, hello is unused."},
+	})
+
+	out, err := executeCommand(t, "review", "--config", cfg)
+	if err != nil {
+		t.Fatalf("review failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Review Summary") ||
+		!strings.Contains(out, "Looks good, hello is unused.") {
+		t.Errorf("expected the review in the output:\n%s", out)
+	}
+}
+
+func TestReviewCommandFindings(t *testing.T) {
+	setupRepo(t)
+	cfg := writeConfig(t, []fake.Response{
+		{
+			Template: prompt.CodeReviewTemplate,
+			Content: `{"summary": "One issue found.", "findings": [
+				{"file": "b/hello.go", "start_line": 3, "end_line": 5, "severity": "HIGH",
+				 "category": "bug", "message": "hello is\nnever called", "suggestion": "Remove hello"},
+				{"file": "hello.go", "start_line": 1, "end_line": 1, "severity": "low",
+				 "category": "docs", "message": "Missing package comment"}
+			]}`,
+		},
+	})
+
+	out, err := executeCommand(t, "review", "--config", cfg)
+	if err != nil {
+		t.Fatalf("review failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"One issue found.",
+		"\nhello.go\n",
+		"Severity",
+		"high      3-5    bug       hello is never called    Remove hello",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the output:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "never called") > strings.Index(out, "Missing package comment") {
+		t.Errorf("expected the findings sorted by severity:\n%s", out)
+	}
+}
+
+func TestReviewCommandMapReduce(t *testing.T) {
+	setupRepo(t)
+	if err := os.WriteFile("world.go", []byte("package main\n\nfunc world() {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "add", ".")
+	cfg := writeConfig(t, []fake.Response{
+		{
+			Template: prompt.CodeReviewTemplate,
+			Content:  `{"summary": "Fine.", "findings": [{"severity": "medium", "category": "style", "message": "Rename it"}]}`,
+		},
+	})
+	appendConfig(t, cfg, "map_reduce:\n  enabled: true\n")
+
+	out, err := executeCommand(t, "review", "--config", cfg)
+	if err != nil {
+		t.Fatalf("review failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{"### hello.go", "### world.go", "\nhello.go\n", "\nworld.go\n", "Rename it"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the output:\n%s", want, out)
+		}
+	}
+}
+
+func TestReviewCommandLanguage(t *testing.T) {
+	setupRepo(t)
+	cfg := writeConfig(t, nil)
+	t.Cleanup(func() {
+		commitLang = "en"
+		viper.Set("output.lang", nil)
+	})
+
+	out, err := executeCommand(t, "review", "--config", cfg, "--prompt_only", "--lang", "zh-tw")
+	if err != nil {
+		t.Fatalf("review failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "suggestions in Traditional Chinese") {
+		t.Errorf("expected the output language in the prompt:\n%s", out)
+	}
+}
+
+func TestReviewCommandFormat(t *testing.T) {
+	setupRepo(t)
+	cfg := writeConfig(t, []fake.Response{
+		{
+			Template: prompt.CodeReviewTemplate,
+			Content: `{"summary": "One issue found.", "findings": [{"file": "hello.go", "start_line": 3,
+				"end_line": 3, "severity": "medium", "category": "bug", "message": "hello is never called"}]}`,
+		},
+	})
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"json", `"message": "hello is never called"`},
+		{"markdown", "| medium | 3 | bug | hello is never called |  |"},
+		{"sarif", `"ruleId": "codegpt/bug"`},
+		{"github", "::warning file=hello.go,line=3,title=CodeGPT bug (medium)::hello is never called"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var doc bytes.Buffer
+			rootCmd.SetOut(&doc)
+			t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+			out, err := executeCommand(t, "review", "--config", cfg, "--format", tt.format)
+			if err != nil {
+				t.Fatalf("review failed: %v\n%s", err, out)
+			}
+			if !strings.Contains(doc.String(), tt.want) {
+				t.Errorf("expected %q in the output:\n%s", tt.want, doc.String())
+			}
+			if strings.Contains(doc.String(), "Code review your changes") {
+				t.Errorf("expected the progress messages out of the review:\n%s", doc.String())
+			}
+		})
+	}
+
+	_, err := executeCommand(t, "review", "--config", cfg, "--format", "xml")
+	if err == nil || !strings.Contains(err.Error(), "unsupported review format") {
+		t.Errorf("expected an unsupported format error, got %v", err)
+	}
+}
+
+func TestReviewCommandFailOn(t *testing.T) {
+	setupRepo(t)
+	cfg := writeConfig(t, []fake.Response{
+		{
+			Template: prompt.CodeReviewTemplate,
+			Content: `{"summary": "Two issues found.", "findings": [
+				{"file": "hello.go", "start_line": 3, "severity": "high", "category": "bug", "message": "Broken"},
+				{"file": "hello.go", "start_line": 1, "severity": "low", "category": "docs", "message": "Undocumented"}
+			]}`,
+		},
+	})
+
+	tests := []struct {
+		failOn  string
+		wantErr string
+	}{
+		{"", ""},
+		{"critical", ""},
+		{"high", "found 1 findings with severity high or higher"},
+		{"LOW", "found 2 findings with severity low or higher"},
+		{"blocker", "unsupported severity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.failOn, func(t *testing.T) {
+			out, err := executeCommand(t, "review", "--config", cfg, "--fail_on", tt.failOn)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("review failed: %v\n%s", err, out)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected %q, got %v", tt.wantErr, err)
+			}
+			if tt.failOn != "blocker" &&
+				!strings.Contains(out, "Findings: 0 critical, 1 high, 0 medium, 1 low, 0 info") {
+				t.Errorf("expected the finding counts in the output:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestReviewCommandFailOnUnreadable(t *testing.T) {
+	setupRepo(t)
+	cfg := writeConfig(t, []fake.Response{
+		{Template: prompt.CodeReviewTemplate, Content: "Looks mostly fine, but hello is broken."},
+	})
+
+	out, err := executeCommand(t, "review", "--config", cfg)
+	if err != nil {
+		t.Fatalf("review failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "showing the raw review") || !strings.Contains(out, "hello is broken") {
+		t.Errorf("expected the raw review:\n%s", out)
+	}
+
+	_, err = executeCommand(t, "review", "--config", cfg, "--fail_on", "low")
+	if err == nil || !strings.Contains(err.Error(), "unable to read the review findings") {
+		t.Errorf("expected an unreadable review error, got %v", err)
+	}
+}
+
+func TestReviewCommandPostGitHub(t *testing.T) {
+	setupRepo(t)
+	cfg := writeConfig(t, []fake.Response{
+		{
+			Template: prompt.CodeReviewTemplate,
+			Content: `{"summary": "One issue found.", "findings": [{"file": "hello.go", "start_line": 3,
+				"end_line": 3, "severity": "high", "category": "bug", "message": "hello is never called"}]}`,
+		},
+	})
+
+	var posted github.Review
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/appleboy/CodeGPT/pulls/7/files", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]github.File{
+			{Filename: "hello.go", Patch: "@@ -0,0 +1,3 @@\n+package main\n+\n+func hello() {}"},
+		})
+	})
+	mux.HandleFunc("GET /repos/appleboy/CodeGPT/pulls/7/comments", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	})
+	mux.HandleFunc("POST /repos/appleboy/CodeGPT/pulls/7/reviews", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("unexpected authorization header %q", r.Header.Get("Authorization"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&posted)
+		_, _ = w.Write([]byte(`{"html_url": "https://github.com/appleboy/CodeGPT/pull/7#review-1"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	event := filepath.Join(t.TempDir(), "event.json")
+	payload := `{"pull_request": {"number": 7, "head": {"sha": "abc123"}}, "repository": {"full_name": "appleboy/CodeGPT"}}`
+	if err := os.WriteFile(event, []byte(payload), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_EVENT_PATH", event)
+	t.Setenv("GITHUB_TOKEN", "test-token")
+	t.Setenv("GITHUB_API_URL", server.URL)
+
+	out, err := executeCommand(t, "review", "--config", cfg, "--post", "github")
+	if err != nil {
+		t.Fatalf("review failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Review posted: https://github.com/appleboy/CodeGPT/pull/7#review-1") {
+		t.Errorf("expected the review URL in the output:\n%s", out)
+	}
+	if posted.CommitID != "abc123" || len(posted.Comments) != 1 || posted.Comments[0].Position != 3 {
+		t.Errorf("unexpected posted review: %+v", posted)
+	}
+
+	_, err = executeCommand(t, "review", "--config", cfg, "--post", "bitbucket")
+	if err == nil || !strings.Contains(err.Error(), "unsupported review destination") {
+		t.Errorf("expected an unsupported destination error, got %v", err)
+	}
+}
+
+func TestReviewCommandPostGitLab(t *testing.T) {
+	setupRepo(t)
+	cfg := writeConfig(t, []fake.Response{
+		{
+			Template: prompt.CodeReviewTemplate,
+			Content: `{"summary": "One issue found.", "findings": [{"file": "hello.go", "start_line": 3,
+				"end_line": 3, "severity": "high", "category": "bug", "message": "hello is never called"}]}`,
+		},
+	})
+
+	var discussions []gitlab.Discussion
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v4/projects/42/merge_requests/7", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"web_url": "https://gitlab.example.com/group/project/-/merge_requests/7",
+			"diff_refs": {"base_sha": "base", "head_sha": "head", "start_sha": "start"}}`))
+	})
+	mux.HandleFunc("GET /api/v4/projects/42/merge_requests/7/diffs", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]gitlab.Diff{
+			{OldPath: "hello.go", NewPath: "hello.go", Diff: "@@ -0,0 +1,3 @@\n+package main\n+\n+func hello() {}"},
+		})
+	})
+	mux.HandleFunc("GET /api/v4/projects/42/merge_requests/7/discussions", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	})
+	mux.HandleFunc("POST /api/v4/projects/42/merge_requests/7/discussions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "test-token" {
+			t.Errorf("unexpected token header %q", r.Header.Get("PRIVATE-TOKEN"))
+		}
+		var d gitlab.Discussion
+		_ = json.NewDecoder(r.Body).Decode(&d)
+		discussions = append(discussions, d)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Setenv("CI_PROJECT_ID", "42")
+	t.Setenv("CI_MERGE_REQUEST_IID", "7")
+	t.Setenv("GITLAB_TOKEN", "test-token")
+	appendConfig(t, cfg, "gitlab:\n  base_url: "+server.URL+"/api/v4\n")
+
+	out, err := executeCommand(t, "review", "--config", cfg, "--post", "gitlab")
+	if err != nil {
+		t.Fatalf("review failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Review posted: https://gitlab.example.com/group/project/-/merge_requests/7") {
+		t.Errorf("expected the merge request URL in the output:\n%s", out)
+	}
+	if len(discussions) != 2 || discussions[0].Position == nil || discussions[0].Position.NewLine != 3 ||
+		discussions[1].Position != nil {
+		t.Errorf("expected a line discussion and the summary, got %+v", discussions)
+	}
+}
+
+func TestReviewFormat(t *testing.T) {
+	f := reviewCmd.PersistentFlags().Lookup("format")
+	_ = f.Value.Set(f.DefValue)
+	f.Changed = false
+	t.Cleanup(func() {
+		viper.Set("platform", nil)
+		viper.Set("review.format", nil)
+	})
+
+	viper.Set("platform", GITHUB)
+	if got, _ := reviewFormat(); got != review.GitHub {
+		t.Errorf("expected github annotations on GitHub, got %s", got)
+	}
+	viper.Set("review.format", "sarif")
+	if got, _ := reviewFormat(); got != review.SARIF {
+		t.Errorf("expected the configured format, got %s", got)
+	}
+	viper.Set("platform", nil)
+	viper.Set("review.format", nil)
+	if got, _ := reviewFormat(); got != review.Table {
+		t.Errorf("expected the table by default, got %s", got)
+	}
+}
+
+func TestReviewCommandStream(t *testing.T) {
+	setupRepo(t)
+	cfg := writeConfig(t, []fake.Response{
+		{Template: prompt.CodeReviewTemplate, Content: "Consider documenting hello."},
+	})
+
+	out, err := executeCommand(t, "review", "--config", cfg, "--stream")
+	if err != nil {
+		t.Fatalf("review failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Consider documenting hello.") {
+		t.Errorf("expected the streamed review in the output:\n%s", out)
+	}
+}
+
+func TestReviewCommandMissingResponse(t *testing.T) {
+	setupRepo(t)
+	cfg := writeConfig(t, []fake.Response{
+		{Template: prompt.SummarizeTitleTemplate, Content: "unused"},
+	})
+
+	_, err := executeCommand(t, "review", "--config", cfg)
+	if err == nil || !strings.Contains(err.Error(), "no fake response") {
+		t.Fatalf("expected a missing response error, got %v", err)
+	}
+}
+
+func TestReviewCommandDiffSources(t *testing.T) {
+	setupRepo(t)
+	runGit(t, "commit", "-q", "-m", "add hello")
+	if err := os.MkdirAll("pkg", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"pkg/util.go": "package pkg\n\nfunc Util() {}\n",
+		"world.go":    "package main\n\nfunc world() {}\n",
+	} {
+		if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-q", "-m", "add util and world")
+	if err := os.WriteFile("hello.go", []byte("package main\n\nfunc hello() { world() }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := writeConfig(t, nil)
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "unstaged",
+			args:    []string{"--unstaged"},
+			want:    []string{"b/hello.go"},
+			notWant: []string{"b/world.go"},
+		},
+		{
+			name:    "range with pathspec",
+			args:    []string{"HEAD~1..HEAD", "--", "pkg"},
+			want:    []string{"b/pkg/util.go"},
+			notWant: []string{"b/world.go", "b/hello.go"},
+		},
+		{
+			name: "base",
+			args: []string{"--base", "HEAD~1"},
+			want: []string{"b/pkg/util.go", "b/world.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"review", "--config", cfg, "--prompt_only"}, tt.args...)
+			out, err := executeCommand(t, args...)
+			if err != nil {
+				t.Fatalf("review failed: %v\n%s", err, out)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("expected %s in the prompt:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(out, s) {
+					t.Errorf("unexpected %s in the prompt:\n%s", s, out)
+				}
+			}
+		})
+	}
+
+	_, err := executeCommand(t, "review", "--config", cfg, "--unstaged", "--base", "HEAD~1")
+	if err == nil || !strings.Contains(err.Error(), "use only one of") {
+		t.Errorf("expected a conflicting sources error, got %v", err)
+	}
+}
+
+func TestReviewMaxTokens(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Set("openai.max_tokens", nil)
+		viper.Set("review.max_tokens", nil)
+	})
+
+	viper.Set("openai.max_tokens", 300)
+	if got := reviewMaxTokens(); got != defaultReviewMaxTokens {
+		t.Errorf("reviewMaxTokens() = %d, want %d", got, defaultReviewMaxTokens)
+	}
+	viper.Set("openai.max_tokens", 4096)
+	if got := reviewMaxTokens(); got != 4096 {
+		t.Errorf("reviewMaxTokens() = %d, want the larger openai.max_tokens", got)
+	}
+	viper.Set("review.max_tokens", 1000)
+	if got := reviewMaxTokens(); got != 1000 {
+		t.Errorf("reviewMaxTokens() = %d, want review.max_tokens", got)
+	}
+}
@@ -0,0 +1,444 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/git"
+	"github.com/appleboy/CodeGPT/prompt"
+	"github.com/appleboy/CodeGPT/util"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/erikgeiser/promptkit/confirmation"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// commitSplit splits the staged changes into several commits.
+var commitSplit bool
+
+// hunkPromptLines are the numbers of changed lines of every hunk shown to the model
+// when planning the split, tried in order until the prompt fits the context limit.
+var hunkPromptLines = []int{20, 5, 0}
+
+// commitGroup is a planned commit of the staged hunks.
+type commitGroup struct {
+	summary string
+	hunks   []git.Hunk
+	message string
+}
+
+// splitPlan is the model response planning the split.
+type splitPlan struct {
+	Commits []struct {
+		Summary string   `json:"summary"`
+		Hunks   []string `json:"hunks"`
+	} `json:"commits"`
+}
+
+// renderHunks renders the hunks for the split prompt, shortening the ones longer
+// than maxLines.
+func renderHunks(hunks []git.Hunk, maxLines int) string {
+	var sb strings.Builder
+	for _, h := range hunks {
+		sb.WriteString(h.Title() + "\n")
+
+		lines := strings.Split(strings.TrimRight(h.Body, "\n"), "\n")
+		if len(lines) > 0 && strings.HasPrefix(lines[0], "@@") {
+			lines = lines[1:]
+		}
+		if len(lines) > maxLines {
+			omitted := len(lines) - maxLines
+			lines = append(lines[:maxLines], fmt.Sprintf("... %d more lines", omitted))
+		}
+		if len(lines) > 0 && lines[0] != "" {
+			sb.WriteString(strings.Join(lines, "\n") + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// fitHunks renders the hunks for the split prompt, shortening them until the prompt,
+// plus the tokens reserved for the response, fits the context limit of the split step.
+func fitHunks(hunks []git.Hunk) (string, error) {
+	budget, estimator, err := diffBudget(prompt.SplitCommitsTemplate, stepSplit, util.Data{"hunks": ""})
+	if err != nil {
+		return "", err
+	}
+
+	for i, maxLines := range hunkPromptLines {
+		out := renderHunks(hunks, maxLines)
+		if estimator.Count(out) > budget {
+			continue
+		}
+		if i > 0 {
+			color.Yellow("The hunks exceed the context limit and were shortened to %d lines each", maxLines)
+		}
+		return out, nil
+	}
+	return "", fmt.Errorf(
+		"the %d staged hunks do not fit the context limit, stage fewer changes or increase openai.context_limit",
+		len(hunks),
+	)
+}
+
+// visibleHunks returns the hunks of files, leaving out the files excluded from the
+// prompt by git.exclude_list.
+func visibleHunks(hunks []git.Hunk, files []string) []git.Hunk {
+	return slices.DeleteFunc(slices.Clone(hunks), func(h git.Hunk) bool {
+		return !slices.Contains(files, h.File)
+	})
+}
+
+// excludedHunks returns the hunks missing from visible, the ones of the files
+// excluded from the prompt by git.exclude_list.
+func excludedHunks(hunks, visible []git.Hunk) []git.Hunk {
+	return slices.DeleteFunc(slices.Clone(hunks), func(h git.Hunk) bool {
+		return slices.ContainsFunc(visible, func(v git.Hunk) bool { return v.ID == h.ID })
+	})
+}
+
+// excludedMessage returns the fixed commit message, with the configured trailers,
+// of a group changing only files excluded from the prompt, such as lock files.
+func excludedMessage(ctx context.Context, hunks []git.Hunk, data util.Data) (string, error) {
+	var files []string
+	for _, h := range hunks {
+		if !slices.Contains(files, h.File) {
+			files = append(files, h.File)
+		}
+	}
+	return addTrailers(ctx, "chore: update "+strings.Join(files, ", "), data)
+}
+
+// parseSplitPlan reads the commits planned by the model. Hunks the model did not
+// assign, or assigned twice, go to the first commit mentioning them or to a last
+// commit of remaining changes; unknown IDs are ignored.
+func parseSplitPlan(content string, hunks []git.Hunk) ([]commitGroup, error) {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("invalid split plan, expected JSON: %s", content)
+	}
+	var plan splitPlan
+	if err := json.Unmarshal([]byte(content[start:end+1]), &plan); err != nil {
+		return nil, fmt.Errorf("invalid split plan: %w", err)
+	}
+
+	byID := make(map[string]git.Hunk, len(hunks))
+	for _, h := range hunks {
+		byID[h.ID] = h
+	}
+
+	var groups []commitGroup
+	for _, c := range plan.Commits {
+		g := commitGroup{summary: strings.TrimSpace(c.Summary)}
+		for _, id := range c.Hunks {
+			if h, ok := byID[strings.TrimSpace(id)]; ok {
+				g.hunks = append(g.hunks, h)
+				delete(byID, h.ID)
+			}
+		}
+		if len(g.hunks) > 0 {
+			groups = append(groups, g)
+		}
+	}
+
+	remaining := commitGroup{summary: "remaining changes"}
+	for _, h := range hunks {
+		if _, ok := byID[h.ID]; ok {
+			remaining.hunks = append(remaining.hunks, h)
+		}
+	}
+	if len(remaining.hunks) > 0 {
+		groups = append(groups, remaining)
+	}
+	return groups, nil
+}
+
+// planHelp explains how to edit the split plan.
+const planHelp = `# Every "## <summary>" line starts a commit, followed by the IDs of its hunks.
+# Move hunk lines between commits, add or remove commits, or delete hunk lines
+# to leave the hunks staged without committing them. Lines starting with # are ignored.`
+
+// formatSplitPlan renders groups as editable text.
+func formatSplitPlan(groups []commitGroup) string {
+	var sb strings.Builder
+	sb.WriteString(planHelp + "\n")
+	for _, g := range groups {
+		sb.WriteString("\n## " + g.summary + "\n")
+		for _, h := range g.hunks {
+			sb.WriteString(h.Title() + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// parseSplitPlanText reads a split plan edited by the user. Only the first word of
+// a hunk line, its ID, matters.
+func parseSplitPlanText(text string, hunks []git.Hunk) ([]commitGroup, error) {
+	byID := make(map[string]git.Hunk, len(hunks))
+	for _, h := range hunks {
+		byID[h.ID] = h
+	}
+	used := map[string]bool{}
+
+	var groups []commitGroup
+	for line := range strings.SplitSeq(text, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "## "):
+			groups = append(groups, commitGroup{summary: strings.TrimSpace(line[3:])})
+		case line == "" || strings.HasPrefix(line, "#"):
+		default:
+			id := strings.Fields(line)[0]
+			h, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("unknown hunk %s in the split plan", id)
+			}
+			if used[id] {
+				return nil, fmt.Errorf("hunk %s is listed more than once in the split plan", id)
+			}
+			if len(groups) == 0 {
+				return nil, fmt.Errorf("hunk %s is listed before the first commit", id)
+			}
+			used[id] = true
+			groups[len(groups)-1].hunks = append(groups[len(groups)-1].hunks, h)
+		}
+	}
+
+	groups = slices.DeleteFunc(groups, func(g commitGroup) bool { return len(g.hunks) == 0 })
+	if len(groups) == 0 {
+		return nil, errors.New("the split plan has no commits")
+	}
+	return groups, nil
+}
+
+// runSplit plans how to split the staged changes into several commits with the
+// model, lets the user edit the plan, and commits every group of hunks with its
+// own generated message.
+func runSplit(cmd *cobra.Command) error {
+	ctx := cmd.Context()
+	if commitAmend {
+		return errors.New("--split cannot be combined with --amend")
+	}
+
+	g := git.New(append(
+		commitOptions(),
+		git.WithExcludeList(viper.GetStringSlice("git.exclude_list")),
+	)...)
+	patch, err := g.StagedPatch(ctx)
+	if err != nil {
+		return err
+	}
+	hunks := git.SplitPatch(patch)
+
+	// Excluded files are not shown to the model and are committed on their own
+	files, err := g.ChangedFiles(ctx)
+	if err != nil {
+		return err
+	}
+	visible := visibleHunks(hunks, files)
+	rendered, err := fitHunks(visible)
+	if err != nil {
+		return err
+	}
+
+	out, err := util.GetTemplateByString(
+		prompt.SplitCommitsTemplate,
+		util.Data{
+			"hunks": rendered,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if promptOnly {
+		color.Yellow("====================Prompt========================")
+		color.Yellow("\n" + strings.TrimSpace(out) + "\n\n")
+		color.Yellow("==================================================")
+		return nil
+	}
+
+	// Update the OpenAI client request timeout if the timeout value is greater than the default openai.timeout
+	if timeout > viper.GetDuration("openai.timeout") ||
+		timeout != defaultTimeout {
+		viper.Set("openai.timeout", timeout)
+	}
+
+	provider := core.Platform(viper.GetString("openai.provider"))
+	client, err := GetClient(ctx, provider)
+	if err != nil {
+		return err
+	}
+	steps := newStepClients(ctx, client)
+
+	_, currentModel := stepModel(stepSplit)
+	color.Green("Planning how to split %d staged hunks using %s model", len(hunks), currentModel)
+	splitCtx, err := systemContext(ctx, prompt.SplitCommitsTemplate)
+	if err != nil {
+		return err
+	}
+	stepClient, err := steps.get(stepSplit)
+	if err != nil {
+		return err
+	}
+	resp, err := stepClient.Completion(splitCtx, out)
+	if err != nil {
+		return err
+	}
+	color.Magenta(resp.Usage.String())
+	groups, err := parseSplitPlan(resp.Content, visible)
+	if err != nil {
+		return err
+	}
+	if excluded := excludedHunks(hunks, visible); len(excluded) > 0 {
+		groups = append(groups, commitGroup{summary: "excluded files", hunks: excluded})
+	}
+
+	// Let the user edit the plan
+	if !noConfirm {
+		m := editPrompt("Please review the split plan:", formatSplitPlan(groups))
+		p := tea.NewProgram(m, tea.WithContext(ctx))
+		if _, err := p.Run(); err != nil {
+			return err
+		}
+		p.Wait()
+		if groups, err = parseSplitPlanText(m.textarea.Value(), hunks); err != nil {
+			return err
+		}
+	}
+
+	data, err := commitTemplateData(ctx)
+	if err != nil {
+		return err
+	}
+	history, err := commitHistory(ctx, stepSummarize)
+	if err != nil {
+		return err
+	}
+
+	for i := range groups {
+		color.Cyan("Generating commit message %d/%d: %s", i+1, len(groups), groups[i].summary)
+		// Only the changes of the files shown to the model are summarized, a group
+		// of excluded files alone gets a fixed message
+		shown := visibleHunks(groups[i].hunks, files)
+		if len(shown) == 0 {
+			groups[i].message, err = excludedMessage(ctx, groups[i].hunks, data)
+		} else {
+			groups[i].message, err = generateCommitMessage(
+				ctx, steps, git.BuildPatch(shown), maps.Clone(data), history,
+			)
+		}
+		if err != nil {
+			return err
+		}
+		color.Yellow("================Commit %d/%d=======================", i+1, len(groups))
+		color.Yellow("\n" + groups[i].message + "\n\n")
+	}
+	color.Yellow("==================================================")
+
+	if preview {
+		if noConfirm {
+			return nil
+		}
+		ready, err := confirmation.New(fmt.Sprintf("Create these %d commits?", len(groups)), confirmation.Yes).
+			RunPrompt()
+		if err != nil || !ready {
+			return err
+		}
+	}
+
+	return commitGroups(ctx, g, patch, groups, hunks)
+}
+
+// splitPatchFile is the file in the git directory keeping the staged changes while
+// they are committed group by group.
+const splitPatchFile = "CODEGPT_SPLIT.patch"
+
+// commitGroups unstages everything and commits every group on its own. Hunks left
+// out of the plan are staged again afterwards. When a commit fails, the changes of
+// the remaining groups are staged again before returning the error.
+func commitGroups(
+	ctx context.Context,
+	g *git.Command,
+	patch string,
+	groups []commitGroup,
+	hunks []git.Hunk,
+) error {
+	planned := map[string]bool{}
+	for _, group := range groups {
+		for _, h := range group.hunks {
+			planned[h.ID] = true
+		}
+	}
+	var leftover []git.Hunk
+	for _, h := range hunks {
+		if !planned[h.ID] {
+			leftover = append(leftover, h)
+		}
+	}
+
+	// Keep the staged changes around in case staging them again fails
+	gitDir, err := g.GitDir(ctx)
+	if err != nil {
+		return err
+	}
+	patchFile := filepath.Join(strings.TrimSpace(gitDir), splitPatchFile)
+	if err := os.WriteFile(patchFile, []byte(patch), 0o600); err != nil {
+		return err
+	}
+
+	if err := g.ResetIndex(ctx); err != nil {
+		return err
+	}
+
+	restage := func(rest []commitGroup, err error) error {
+		var remaining []git.Hunk
+		for _, group := range rest {
+			remaining = append(remaining, group.hunks...)
+		}
+		remaining = append(remaining, leftover...)
+		if len(remaining) == 0 {
+			return err
+		}
+		restoreErr := g.ApplyCached(ctx, git.BuildPatch(remaining))
+		switch {
+		case restoreErr == nil:
+			return err
+		case err == nil:
+			return fmt.Errorf("the staged changes are kept in %s: %w", patchFile, restoreErr)
+		default:
+			return fmt.Errorf(
+				"%w, and staging the remaining changes again failed, the staged changes are kept in %s: %w",
+				err, patchFile, restoreErr,
+			)
+		}
+	}
+
+	for i, group := range groups {
+		color.Cyan("Recording commit %d/%d: %s", i+1, len(groups), group.summary)
+		if err := g.ApplyCached(ctx, git.BuildPatch(group.hunks)); err != nil {
+			return restage(groups[i:], err)
+		}
+		output, err := g.Commit(ctx, group.message)
+		if err != nil {
+			// The group is staged already, only restage the groups after it
+			return restage(groups[i+1:], fmt.Errorf("failed to commit %q: %w", group.summary, err))
+		}
+		color.Yellow(output)
+	}
+
+	if err := restage(nil, nil); err != nil {
+		return err
+	}
+	return os.Remove(patchFile)
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/git"
+	"github.com/appleboy/CodeGPT/prompt"
+	"github.com/appleboy/CodeGPT/provider/fake"
+	"github.com/appleboy/CodeGPT/util"
+
+	"github.com/spf13/viper"
+)
+
+// setupSplit stages world.go next to hello.go of setupRepo.
+func setupSplit(t *testing.T) {
+	t.Helper()
+
+	if err := os.WriteFile("world.go", []byte("package main\n\nfunc world() {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "add", "world.go")
+}
+
+func splitResponses(plan string) []fake.Response {
+	return append([]fake.Response{
+		{Template: prompt.SplitCommitsTemplate, Content: plan},
+	}, commitResponses...)
+}
+
+func TestCommitSplit(t *testing.T) {
+	setupRepo(t)
+	setupSplit(t)
+	plan := "```json\n" +
+		`{"commits": [{"summary": "add world", "hunks": ["h2"]}, {"summary": "add hello", "hunks": ["h1"]}]}` +
+		"\n```"
+	cfg := writeConfig(t, splitResponses(plan))
+
+	out, err := executeCommand(t, "commit", "--config", cfg, "--split", "--no_confirm")
+	if err != nil {
+		t.Fatalf("commit --split failed: %v\n%s", err, out)
+	}
+
+	log := runGit(t, "log", "--reverse", "--format=%s", "--name-only")
+	want := "feat: add hello function\n\nworld.go\nfeat: add hello function\n\nhello.go\n"
+	if log != want {
+		t.Errorf("unexpected commits:\n%s\nwant:\n%s", log, want)
+	}
+	if status := runGit(t, "status", "--porcelain"); status != "" {
+		t.Errorf("expected a clean tree, got:\n%s", status)
+	}
+	if _, err := os.Stat(".git/" + splitPatchFile); !os.IsNotExist(err) {
+		t.Errorf("expected the split patch file to be removed, got %v", err)
+	}
+}
+
+func TestCommitSplitRemaining(t *testing.T) {
+	setupRepo(t)
+	setupSplit(t)
+	cfg := writeConfig(t, splitResponses(`{"commits": [{"summary": "add world", "hunks": ["h2", "h9"]}]}`))
+
+	out, err := executeCommand(t, "commit", "--config", cfg, "--split", "--no_confirm")
+	if err != nil {
+		t.Fatalf("commit --split failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Recording commit 2/2: remaining changes") {
+		t.Errorf("expected the unassigned hunk in its own commit:\n%s", out)
+	}
+	if count := strings.TrimSpace(runGit(t, "rev-list", "--count", "HEAD")); count != "2" {
+		t.Errorf("expected 2 commits, got %s", count)
+	}
+}
+
+func TestCommitSplitPromptOnly(t *testing.T) {
+	setupRepo(t)
+	setupSplit(t)
+	cfg := writeConfig(t, nil)
+
+	out, err := executeCommand(t, "commit", "--config", cfg, "--split", "--prompt_only")
+	if err != nil {
+		t.Fatalf("commit --split failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{"h1 hello.go @@ -0,0", "func hello() {}", "h2 world.go @@ -0,0"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the prompt:\n%s", want, out)
+		}
+	}
+}
+
+func TestCommitSplitExcludeList(t *testing.T) {
+	setupRepo(t)
+	setupSplit(t)
+	if err := os.WriteFile("go.sum", []byte("example.com/mod v1.0.0 h1:abc=\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "add", "go.sum")
+	cfg := writeConfig(t, splitResponses(`{"commits": [{"summary": "add code", "hunks": ["h2", "h3"]}]}`))
+
+	out, err := executeCommand(t, "commit", "--config", cfg, "--split", "--prompt_only")
+	if err != nil {
+		t.Fatalf("commit --split failed: %v\n%s", err, out)
+	}
+	if strings.Contains(out, "go.sum") {
+		t.Errorf("expected go.sum to be left out of the prompt:\n%s", out)
+	}
+
+	out, err = executeCommand(t, "commit", "--config", cfg, "--split", "--no_confirm")
+	if err != nil {
+		t.Fatalf("commit --split failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Recording commit 2/2: excluded files") {
+		t.Errorf("expected go.sum in its own commit:\n%s", out)
+	}
+	log := runGit(t, "log", "--reverse", "--format=%s", "--name-only")
+	want := "feat: add hello function\n\nhello.go\nworld.go\nchore: update go.sum\n\ngo.sum\n"
+	if log != want {
+		t.Errorf("expected go.sum committed under a fixed message:\n%s\nwant:\n%s", log, want)
+	}
+}
+
+func TestCommitSplitSameFile(t *testing.T) {
+	setupRepo(t)
+	var lines []string
+	for i := 1; i <= 30; i++ {
+		lines = append(lines, fmt.Sprintf("// line %d", i))
+	}
+	content := "package main\n\n" + strings.Join(lines, "\n") + "\n"
+	if err := os.WriteFile("hello.go", []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "add", "hello.go")
+	runGit(t, "commit", "-q", "-m", "initial")
+
+	// The first hunk adds lines, so the second one applies at an offset afterwards
+	changed := strings.Replace(content, "// line 2\n", "// line 2\n// added a\n// added b\n", 1)
+	changed = strings.Replace(changed, "// line 29\n", "// line 29 changed\n", 1)
+	if err := os.WriteFile("hello.go", []byte(changed), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "add", "hello.go")
+	plan := `{"commits": [{"summary": "add lines", "hunks": ["h1"]}, {"summary": "change line", "hunks": ["h2"]}]}`
+	cfg := writeConfig(t, splitResponses(plan))
+
+	out, err := executeCommand(t, "commit", "--config", cfg, "--split", "--no_confirm")
+	if err != nil {
+		t.Fatalf("commit --split failed: %v\n%s", err, out)
+	}
+	if count := strings.TrimSpace(runGit(t, "rev-list", "--count", "HEAD")); count != "3" {
+		t.Errorf("expected 3 commits, got %s", count)
+	}
+	if diff := runGit(t, "show", "--format=", "HEAD~1"); !strings.Contains(diff, "+// added a") ||
+		strings.Contains(diff, "line 29 changed") {
+		t.Errorf("expected only the added lines in the first commit:\n%s", diff)
+	}
+	if status := runGit(t, "status", "--porcelain"); status != "" {
+		t.Errorf("expected a clean tree, got:\n%s", status)
+	}
+	if got := runGit(t, "show", "HEAD:hello.go"); got != changed {
+		t.Errorf("unexpected hello.go after the split:\n%s", got)
+	}
+}
+
+func TestFitHunks(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("@@ -0,0 +1,100 @@\n")
+	for i := range 100 {
+		fmt.Fprintf(&body, "+// line %d of a long hunk\n", i)
+	}
+	hunks := []git.Hunk{{ID: "h1", File: "long.go", Body: body.String()}}
+
+	const limit = 100000
+	viper.Set("openai.context_limit", limit)
+	t.Cleanup(func() { viper.Set("openai.context_limit", nil) })
+	budget, estimator, err := diffBudget(prompt.SplitCommitsTemplate, stepSplit, util.Data{"hunks": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reserved := limit - budget
+
+	out, err := fitHunks(hunks)
+	if err != nil || out != renderHunks(hunks, 20) {
+		t.Errorf("fitHunks() = %q, %v, want the hunk with 20 lines", out, err)
+	}
+
+	viper.Set("openai.context_limit", reserved+estimator.Count(renderHunks(hunks, 5)))
+	if out, err = fitHunks(hunks); err != nil || out != renderHunks(hunks, 5) {
+		t.Errorf("fitHunks() = %q, %v, want the hunk shortened to 5 lines", out, err)
+	}
+
+	viper.Set("openai.context_limit", reserved+1)
+	if _, err = fitHunks(hunks); err == nil || !strings.Contains(err.Error(), "do not fit the context limit") {
+		t.Errorf("expected a context limit error, got %v", err)
+	}
+}
+
+func TestParseSplitPlanText(t *testing.T) {
+	hunks := []git.Hunk{{ID: "h1", File: "a.go"}, {ID: "h2", File: "b.go"}, {ID: "h3", File: "c.go"}}
+
+	groups, err := parseSplitPlanText("# help\n\n## first\nh3 c.go @@ -1 +1 @@\nh1\n## empty\n\n## second\nh2 b.go\n", hunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 || groups[0].summary != "first" || len(groups[0].hunks) != 2 ||
+		groups[0].hunks[0].ID != "h3" || groups[1].hunks[0].ID != "h2" {
+		t.Errorf("unexpected groups: %+v", groups)
+	}
+
+	for text, want := range map[string]string{
+		"## first\nh4":          "unknown hunk h4",
+		"## first\nh1\nh1":      "listed more than once",
+		"h1\n## first":          "before the first commit",
+		"# nothing\n## first\n": "no commits",
+	} {
+		if _, err := parseSplitPlanText(text, hunks); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parseSplitPlanText(%q) = %v, want error containing %q", text, err, want)
+		}
+	}
+}
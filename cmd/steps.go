@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/appleboy/CodeGPT/core"
+
+	"github.com/spf13/viper"
+)
+
+// Pipeline steps that can use their own provider and model,
+// configured as steps.<step>.provider and steps.<step>.model.
+const (
+	stepSummarize = "summarize"
+	stepTitle     = "title"
+	stepPrefix    = "prefix"
+	stepTranslate = "translate"
+	stepReview    = "review"
+	stepPR        = "pr"
+	stepChangelog = "changelog"
+	stepSplit     = "split"
+)
+
+// stepModel returns the provider and model used by step. An unset provider
+// falls back to openai.provider, an unset model to the model of the provider
+// as in the providers list: openai.model, or the default model of another provider.
+func stepModel(step string) (core.Platform, string) {
+	provider := viper.GetString("steps." + step + ".provider")
+	if provider == "" {
+		provider = viper.GetString("openai.provider")
+	}
+	model := viper.GetString("steps." + step + ".model")
+	if model == "" {
+		model = entryModel(core.Platform(provider))
+	}
+	return core.Platform(provider), model
+}
+
+// hasStepOverride reports whether step is configured with its own provider or model.
+func hasStepOverride(step string) bool {
+	return viper.GetString("steps."+step+".provider") != "" ||
+		viper.GetString("steps."+step+".model") != ""
+}
+
+// stepClients hands out the generative client of each pipeline step.
+// Steps without an override share the default client, steps using the same
+// provider and model share a single client.
+type stepClients struct {
+	ctx     context.Context
+	base    core.Generative
+	clients map[string]core.Generative
+}
+
+// newStepClients returns stepClients falling back to base for steps without an override.
+func newStepClients(ctx context.Context, base core.Generative) *stepClients {
+	return &stepClients{
+		ctx:     ctx,
+		base:    base,
+		clients: make(map[string]core.Generative),
+	}
+}
+
+// get returns the generative client for step.
+func (s *stepClients) get(step string) (core.Generative, error) {
+	if !hasStepOverride(step) {
+		return s.base, nil
+	}
+
+	provider, model := stepModel(step)
+	if !provider.IsValid() {
+		return nil, fmt.Errorf("invalid provider %q for the %s step", provider, step)
+	}
+
+	key := provider.String() + ":" + model
+	if client, ok := s.clients[key]; ok {
+		return client, nil
+	}
+
+	client, err := newClient(s.ctx, provider, model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s client for the %s step: %w", provider, step, err)
+	}
+	client, err = withCache(client, provider.String(), model)
+	if err != nil {
+		return nil, err
+	}
+	client, err = withLedger(s.ctx, client, provider, model)
+	if err != nil {
+		return nil, err
+	}
+
+	s.clients[key] = client
+	return client, nil
+}
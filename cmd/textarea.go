@@ -12,6 +12,7 @@ type errMsg error
 
 type model struct {
 	textarea *textarea.Model
+	title    string
 	err      error
 }
 
@@ -43,10 +44,20 @@ func initialPrompt(value string) model {
 
 	return model{
 		textarea: &ti,
+		title:    "Please review and confirm your commit message:",
 		err:      nil,
 	}
 }
 
+// editPrompt initializes a textarea model like initialPrompt, showing title above
+// it and without a character limit, so value can be extended freely.
+func editPrompt(title, value string) model {
+	m := initialPrompt(value)
+	m.textarea.CharLimit = 0
+	m.title = title
+	return m
+}
+
 func (m model) Init() tea.Cmd {
 	return textarea.Blink
 }
@@ -84,7 +95,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m model) View() string {
 	return fmt.Sprintf(
-		"Please review and confirm your commit message:\n\n%s\n\n%s",
+		"%s\n\n%s\n\n%s",
+		m.title,
 		m.textarea.View(),
 		"(Press Ctrl+C to continue)",
 	) + "\n\n"
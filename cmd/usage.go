@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/core/usage"
+	"github.com/appleboy/CodeGPT/git"
+	"github.com/appleboy/CodeGPT/provider/ledger"
+
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// usageCommand is the name of the running command, recorded with each request.
+var usageCommand string
+
+var (
+	usageBy     string
+	usageSince  string
+	usageOutput string
+)
+
+func init() {
+	usageCmd.Flags().StringVar(&usageBy, "by", string(usage.ByDay),
+		"group usage by day, model or repository")
+	usageCmd.Flags().StringVar(&usageSince, "since", "",
+		"only include requests made on or after this date (YYYY-MM-DD)")
+	usageCmd.Flags().StringVarP(&usageOutput, "output", "o", "table", "Output format: table|json")
+}
+
+// getUsagePath returns the path of the usage ledger.
+// It defaults to $HOME/.config/codegpt/usage.jsonl.
+func getUsagePath() (string, error) {
+	if path := viper.GetString("usage.path"); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "codegpt", "usage.jsonl"), nil
+}
+
+// getPricing returns the built-in model prices with the prices configured
+// under usage.prices applied on top.
+func getPricing() (*usage.Pricing, error) {
+	var prices []usage.Price
+	if err := viper.UnmarshalKey("usage.prices", &prices); err != nil {
+		return nil, fmt.Errorf("invalid usage.prices config: %w", err)
+	}
+	return usage.NewPricing(prices...), nil
+}
+
+// withLedger wraps client for the provider and model with the usage ledger,
+// unless usage.enabled is set to false.
+func withLedger(
+	ctx context.Context,
+	client core.Generative,
+	provider core.Platform,
+	model string,
+) (core.Generative, error) {
+	if viper.IsSet("usage.enabled") && !viper.GetBool("usage.enabled") {
+		return client, nil
+	}
+
+	path, err := getUsagePath()
+	if err != nil {
+		return nil, err
+	}
+
+	// Requests made outside a repository are recorded without one.
+	repository, _ := git.New().TopLevel(ctx)
+
+	return ledger.New(
+		client,
+		ledger.WithLedger(usage.NewLedger(path)),
+		ledger.WithCommand(usageCommand),
+		ledger.WithRepository(repository),
+		ledger.WithProvider(provider.String()),
+		ledger.WithModel(model),
+	)
+}
+
+// usageCmd summarizes the requests recorded in the usage ledger.
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Summarize token usage and estimated cost",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var since time.Time
+		if usageSince != "" {
+			t, err := time.ParseInLocation(time.DateOnly, usageSince, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid --since date %q, use YYYY-MM-DD", usageSince)
+			}
+			since = t
+		}
+
+		pricing, err := getPricing()
+		if err != nil {
+			return err
+		}
+
+		path, err := getUsagePath()
+		if err != nil {
+			return err
+		}
+		records, err := usage.NewLedger(path).Records(since)
+		if err != nil {
+			return err
+		}
+
+		summary, err := usage.Summarize(records, usage.GroupBy(usageBy), pricing)
+		if err != nil {
+			return err
+		}
+
+		if usageOutput == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(summary)
+		}
+		printUsage(summary)
+		return nil
+	},
+}
+
+// printUsage prints the summary as a table followed by the total.
+func printUsage(summary *usage.Summary) {
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	by := string(summary.By)
+	tbl := table.New(
+		strings.ToUpper(by[:1])+by[1:], "Calls", "Prompt", "Completion", "Cached", "Reasoning", "Cost",
+	)
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+	for _, row := range append(summary.Rows, summary.Total) {
+		tbl.AddRow(
+			row.Key,
+			row.Calls,
+			row.PromptTokens,
+			row.CompletionTokens,
+			row.CachedTokens,
+			row.ReasoningTokens,
+			formatCost(row),
+		)
+	}
+	tbl.Print()
+}
+
+// formatCost formats the cost of row in US dollars, marking rows that include
+// calls to models without a price.
+func formatCost(row usage.Row) string {
+	cost := "$" + strconv.FormatFloat(row.Cost, 'f', 4, 64)
+	if row.Unpriced > 0 {
+		cost += fmt.Sprintf(" (%d unpriced)", row.Unpriced)
+	}
+	return cost
+}
@@ -0,0 +1,72 @@
+package capability
+
+// builtin lists the capabilities of well known models.
+var builtin = []Entry{
+	// OpenAI
+	{Pattern: "gpt-3.5-turbo*", Model: chat(16385)},
+	{Pattern: "gpt-4*", Model: chat(8192)},
+	{Pattern: "gpt-4-turbo*", Model: chat(128000)},
+	{Pattern: "gpt-4o*", Model: chat(128000)},
+	{Pattern: "gpt-4.1*", Model: chat(1047576)},
+	{Pattern: "gpt-5*", Model: reasoning(400000, true)},
+	{Pattern: "o1*", Model: reasoning(200000, false)},
+	{Pattern: "o1-mini*", Model: withoutSystemPrompt(reasoning(128000, false))},
+	{Pattern: "o1-preview*", Model: withoutSystemPrompt(reasoning(128000, false))},
+	{Pattern: "o3*", Model: reasoning(200000, false)},
+	{Pattern: "o4*", Model: reasoning(200000, false)},
+
+	// DeepSeek, also when served through OpenRouter or Groq
+	{Pattern: "*deepseek*", Model: withoutTools(chat(64000))},
+	{Pattern: "*deepseek-r1*", Model: withoutTools(reasoning(64000, false))},
+	{Pattern: "*deepseek-reasoner*", Model: withoutTools(reasoning(64000, false))},
+
+	// Anthropic
+	{Pattern: "claude-*", Model: chat(200000)},
+
+	// Gemini
+	{Pattern: "gemini-*", Model: chat(1048576)},
+	{Pattern: "gemini-2.5*", Model: Model{
+		Tools:        true,
+		Streaming:    true,
+		Temperature:  true,
+		SystemPrompt: true,
+		Reasoning:    true,
+		MaxContext:   1048576,
+	}},
+
+	// Ollama
+	{Pattern: "llama3*", Model: chat(8192)},
+	{Pattern: "llama3.1*", Model: chat(131072)},
+	{Pattern: "qwen2.5*", Model: chat(32768)},
+}
+
+// chat returns the capabilities of a regular chat model.
+func chat(maxContext int) Model {
+	m := defaultModel
+	m.MaxContext = maxContext
+	return m
+}
+
+// reasoning returns the capabilities of a reasoning model, which only
+// accepts the default temperature and top_p.
+func reasoning(maxContext int, tools bool) Model {
+	return Model{
+		Tools:        tools,
+		Streaming:    true,
+		SystemPrompt: true,
+		Reasoning:    true,
+		MaxContext:   maxContext,
+	}
+}
+
+// withoutTools returns m without tool calling support.
+func withoutTools(m Model) Model {
+	m.Tools = false
+	return m
+}
+
+// withoutSystemPrompt returns m without system prompt support.
+func withoutSystemPrompt(m Model) Model {
+	m.SystemPrompt = false
+	return m
+}
@@ -0,0 +1,143 @@
+// Package capability describes what each model supports, so providers can shape
+// their requests without special-casing model names.
+package capability
+
+import "strings"
+
+// Model lists the capabilities of a model.
+type Model struct {
+	// Tools is true when the model supports tool (function) calling.
+	Tools bool
+	// Streaming is true when the model can stream its response.
+	Streaming bool
+	// Temperature is true when the model accepts temperature and top_p.
+	Temperature bool
+	// SystemPrompt is true when the model accepts a system prompt.
+	SystemPrompt bool
+	// Reasoning is true for reasoning models.
+	Reasoning bool
+	// MaxContext is the context window in tokens, zero when unknown.
+	MaxContext int
+}
+
+// defaultModel is used for models without a matching entry.
+var defaultModel = Model{
+	Tools:        true,
+	Streaming:    true,
+	Temperature:  true,
+	SystemPrompt: true,
+}
+
+// Entry maps model names matching Pattern to their capabilities.
+// Pattern is a model name where "*" matches any sequence of characters,
+// e.g. "gpt-4o*" or "*deepseek*". Matching is case insensitive.
+type Entry struct {
+	Pattern string
+	Model   Model
+}
+
+// Override changes some capabilities of the models matching Name, which uses
+// the same pattern syntax as Entry. Nil fields keep their current value.
+type Override struct {
+	Name         string `mapstructure:"name"`
+	Tools        *bool  `mapstructure:"tools"`
+	Streaming    *bool  `mapstructure:"streaming"`
+	Temperature  *bool  `mapstructure:"temperature"`
+	SystemPrompt *bool  `mapstructure:"system_prompt"`
+	Reasoning    *bool  `mapstructure:"reasoning"`
+	MaxContext   *int   `mapstructure:"max_context"`
+}
+
+// apply sets the non-nil fields of o on m.
+func (o Override) apply(m *Model) {
+	if o.Tools != nil {
+		m.Tools = *o.Tools
+	}
+	if o.Streaming != nil {
+		m.Streaming = *o.Streaming
+	}
+	if o.Temperature != nil {
+		m.Temperature = *o.Temperature
+	}
+	if o.SystemPrompt != nil {
+		m.SystemPrompt = *o.SystemPrompt
+	}
+	if o.Reasoning != nil {
+		m.Reasoning = *o.Reasoning
+	}
+	if o.MaxContext != nil {
+		m.MaxContext = *o.MaxContext
+	}
+}
+
+// Registry resolves model names to their capabilities.
+type Registry struct {
+	entries   []Entry
+	overrides []Override
+}
+
+// NewRegistry returns a Registry with the built-in entries and the given overrides.
+// Overrides are applied in order, so later overrides win.
+func NewRegistry(overrides ...Override) *Registry {
+	return &Registry{
+		entries:   builtin,
+		overrides: overrides,
+	}
+}
+
+// Lookup returns the capabilities of the named model. The most specific built-in
+// entry is used, then every matching override is applied on top of it. Built-in
+// entries also match the last path segment of routed names such as openai/o1-mini.
+func (r *Registry) Lookup(name string) Model {
+	base := name[strings.LastIndex(name, "/")+1:]
+	m := defaultModel
+	best := -1
+	for _, e := range r.entries {
+		if n := specificity(e.Pattern); n > best && (match(e.Pattern, name) || match(e.Pattern, base)) {
+			m, best = e.Model, n
+		}
+	}
+
+	for _, o := range r.overrides {
+		if match(o.Name, name) {
+			o.apply(&m)
+		}
+	}
+	return m
+}
+
+// defaultRegistry holds the built-in entries only.
+var defaultRegistry = NewRegistry()
+
+// Lookup returns the capabilities of the named model from the built-in entries.
+func Lookup(name string) Model {
+	return defaultRegistry.Lookup(name)
+}
+
+// specificity ranks patterns, longer literal parts are more specific.
+func specificity(pattern string) int {
+	return len(strings.ReplaceAll(pattern, "*", ""))
+}
+
+// match reports whether name matches pattern, where "*" matches any sequence of characters.
+func match(pattern, name string) bool {
+	pattern, name = strings.ToLower(pattern), strings.ToLower(name)
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == name
+	}
+
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(name, part)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(part):]
+	}
+	return strings.HasSuffix(name, last)
+}
@@ -0,0 +1,84 @@
+package capability
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"gpt-4o", "gpt-4o", true},
+		{"gpt-4o", "gpt-4o-mini", false},
+		{"gpt-4o*", "gpt-4o-mini", true},
+		{"GPT-4o*", "gpt-4o", true},
+		{"*deepseek*", "deepseek/deepseek-r1", true},
+		{"*deepseek*", "gpt-4o", false},
+		{"*-mini", "o3-mini", true},
+		{"o*-mini", "o3-mini", true},
+		{"a*a", "a", false},
+	}
+
+	for _, tt := range tests {
+		if got := match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name string
+		want Model
+	}{
+		{"unknown-model", defaultModel},
+		{"gpt-4o-mini", chat(128000)},
+		{"gpt-4", chat(8192)},
+		{"gpt-4-turbo-preview", chat(128000)},
+		{"o3-mini", reasoning(200000, false)},
+		{"o1-mini", withoutSystemPrompt(reasoning(128000, false))},
+		{"gpt-5-mini", reasoning(400000, true)},
+		{"deepseek-chat", withoutTools(chat(64000))},
+		{"deepseek/deepseek-r1", withoutTools(reasoning(64000, false))},
+		{"claude-3-5-haiku-latest", chat(200000)},
+		{"openai/o1-mini", withoutSystemPrompt(reasoning(128000, false))},
+		{"openai/o3-mini", reasoning(200000, false)},
+		{"anthropic/claude-3-5-haiku-latest", chat(200000)},
+		{"vendor/my-o1-mini", defaultModel},
+	}
+
+	for _, tt := range tests {
+		if got := Lookup(tt.name); got != tt.want {
+			t.Errorf("Lookup(%q) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRegistryOverrides(t *testing.T) {
+	off := false
+	ctx := 32000
+	r := NewRegistry(
+		Override{Name: "my-*", Tools: &off},
+		Override{Name: "my-model", MaxContext: &ctx},
+		Override{Name: "gpt-4o", Temperature: &off},
+	)
+
+	got := r.Lookup("my-model")
+	want := defaultModel
+	want.Tools = false
+	want.MaxContext = 32000
+	if got != want {
+		t.Errorf("Lookup(my-model) = %+v, want %+v", got, want)
+	}
+
+	got = r.Lookup("gpt-4o")
+	want = chat(128000)
+	want.Temperature = false
+	if got != want {
+		t.Errorf("Lookup(gpt-4o) = %+v, want %+v", got, want)
+	}
+
+	if got := r.Lookup("gpt-4o-mini"); got != chat(128000) {
+		t.Errorf("override should not apply to gpt-4o-mini, got %+v", got)
+	}
+}
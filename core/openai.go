@@ -12,12 +12,17 @@ import (
 // It includes counts for the prompt tokens, the completion tokens, and the overall total tokens.
 // In addition, it may provide detailed breakdowns for both prompt and completion tokens,
 // allowing for deeper insights into token distribution if the corresponding details are available.
+// Provider and Model are optional and identify the backend that answered the request.
+// FromCache is set when the response was served from the local response cache.
 type Usage struct {
 	PromptTokens            int
 	CompletionTokens        int
 	TotalTokens             int
 	PromptTokensDetails     *openai.PromptTokensDetails
 	CompletionTokensDetails *openai.CompletionTokensDetails
+	Provider                string
+	Model                   string
+	FromCache               bool
 }
 
 func (u Usage) String() string {
@@ -30,6 +35,15 @@ func (u Usage) String() string {
 		s += " (ReasoningTokens: " + strconv.Itoa(u.CompletionTokensDetails.ReasoningTokens) + ")"
 	}
 	s += ", Total tokens: " + strconv.Itoa(u.TotalTokens)
+	if u.Provider != "" {
+		s += ", Provider: " + u.Provider
+		if u.Model != "" {
+			s += " (" + u.Model + ")"
+		}
+	}
+	if u.FromCache {
+		s += ", Served from cache"
+	}
 	return s
 }
 
@@ -51,6 +65,10 @@ type Generative interface {
 	// It takes a context and a string as input and returns a Response pointer and an error.
 	GetSummaryPrefix(ctx context.Context, content string) (resp *Response, err error)
 
+	// GetReviewFindings generates structured code review findings based on the provided content.
+	// The Response content holds a Review as JSON, see ParseReview.
+	GetReviewFindings(ctx context.Context, content string) (resp *Response, err error)
+
 	// CompletionStream generates a completion and streams tokens to the writer as they arrive.
 	// Returns the full accumulated Response on completion.
 	CompletionStream(ctx context.Context, content string, w io.Writer) (resp *Response, err error)
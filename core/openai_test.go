@@ -52,6 +52,27 @@ func TestUsageString(t *testing.T) {
 			},
 			expected: "Prompt tokens: 15 (CachedTokens: 3), Completion tokens: 25 (ReasoningTokens: 9), Total tokens: 40",
 		},
+		{
+			name: "with provider and model",
+			usage: Usage{
+				PromptTokens:     10,
+				CompletionTokens: 20,
+				TotalTokens:      30,
+				Provider:         "anthropic",
+				Model:            "claude-3-5-haiku-latest",
+			},
+			expected: "Prompt tokens: 10, Completion tokens: 20, Total tokens: 30, Provider: anthropic (claude-3-5-haiku-latest)",
+		},
+		{
+			name: "served from cache",
+			usage: Usage{
+				PromptTokens:     10,
+				CompletionTokens: 20,
+				TotalTokens:      30,
+				FromCache:        true,
+			},
+			expected: "Prompt tokens: 10, Completion tokens: 20, Total tokens: 30, Served from cache",
+		},
 	}
 
 	for _, tc := range tests {
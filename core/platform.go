@@ -12,6 +12,10 @@ const (
 	Gemini Platform = "gemini"
 	// Anthropic represents the Anthropic platform.
 	Anthropic Platform = "anthropic"
+	// Ollama represents the Ollama platform.
+	Ollama Platform = "ollama"
+	// Fake represents the offline platform answering with scripted responses.
+	Fake Platform = "fake"
 )
 
 // String returns the string representation of the Platform.
@@ -22,7 +26,7 @@ func (p Platform) String() string {
 // IsValid returns true if the Platform is valid.
 func (p Platform) IsValid() bool {
 	switch p {
-	case OpenAI, Azure, Gemini, Anthropic:
+	case OpenAI, Azure, Gemini, Anthropic, Ollama, Fake:
 		return true
 	}
 	return false
@@ -0,0 +1,216 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+// ReviewFindingsFunc is the name of the tool the providers ask the model to call
+// with its review findings.
+const ReviewFindingsFunc = "report_review_findings"
+
+// Severity is how serious a review finding is.
+type Severity string
+
+// Severities of review findings, from the most to the least serious.
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+	SeverityInfo     Severity = "info"
+)
+
+// Severities lists the severities from the most to the least serious.
+var Severities = []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo}
+
+// Rank returns the position of s in Severities, 0 being the most serious.
+// Unknown severities rank below info.
+func (s Severity) Rank() int {
+	if i := slices.Index(Severities, s); i >= 0 {
+		return i
+	}
+	return len(Severities)
+}
+
+// IsValid reports whether s is a known severity.
+func (s Severity) IsValid() bool {
+	return slices.Contains(Severities, s)
+}
+
+// ReviewCategories lists the categories of review findings.
+var ReviewCategories = []string{
+	"bug", "security", "performance", "maintainability", "style", "test", "docs",
+}
+
+// Finding is a single issue found while reviewing a diff.
+type Finding struct {
+	File       string   `json:"file"`
+	StartLine  int      `json:"start_line"`
+	EndLine    int      `json:"end_line,omitempty"`
+	Severity   Severity `json:"severity"`
+	Category   string   `json:"category"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion,omitempty"`
+}
+
+// Lines returns the line range of the finding, e.g. "12-18", or "" when unknown.
+func (f Finding) Lines() string {
+	switch {
+	case f.StartLine <= 0:
+		return ""
+	case f.EndLine <= f.StartLine:
+		return fmt.Sprint(f.StartLine)
+	default:
+		return fmt.Sprintf("%d-%d", f.StartLine, f.EndLine)
+	}
+}
+
+// Review is the structured result of a code review.
+type Review struct {
+	Summary  string    `json:"summary"`
+	Findings []Finding `json:"findings"`
+}
+
+// Sort orders the findings by file, then by severity and line.
+func (r *Review) Sort() {
+	slices.SortStableFunc(r.Findings, func(a, b Finding) int {
+		if a.File != b.File {
+			return strings.Compare(a.File, b.File)
+		}
+		if a.Severity.Rank() != b.Severity.Rank() {
+			return a.Severity.Rank() - b.Severity.Rank()
+		}
+		return a.StartLine - b.StartLine
+	})
+}
+
+// Count returns the number of findings with severity s.
+func (r *Review) Count(s Severity) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == s {
+			n++
+		}
+	}
+	return n
+}
+
+// CountAtLeast returns the number of findings at least as serious as s.
+func (r *Review) CountAtLeast(s Severity) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity.Rank() <= s.Rank() {
+			n++
+		}
+	}
+	return n
+}
+
+// ReviewSchema is the JSON schema of a Review, used as the tool parameters or
+// structured output format when asking for review findings.
+var ReviewSchema = jsonschema.Definition{
+	Type: jsonschema.Object,
+	Properties: map[string]jsonschema.Definition{
+		"summary": {
+			Type:        jsonschema.String,
+			Description: "A short overall assessment of the changes",
+		},
+		"findings": {
+			Type:        jsonschema.Array,
+			Description: "The issues found in the changes, empty when there are none",
+			Items: &jsonschema.Definition{
+				Type: jsonschema.Object,
+				Properties: map[string]jsonschema.Definition{
+					"file": {
+						Type:        jsonschema.String,
+						Description: "Path of the changed file, as in the diff header",
+					},
+					"start_line": {
+						Type:        jsonschema.Integer,
+						Description: "First line of the issue in the new version of the file",
+					},
+					"end_line": {
+						Type:        jsonschema.Integer,
+						Description: "Last line of the issue in the new version of the file",
+					},
+					"severity": {
+						Type: jsonschema.String,
+						Enum: []string{
+							string(SeverityCritical), string(SeverityHigh), string(SeverityMedium),
+							string(SeverityLow), string(SeverityInfo),
+						},
+					},
+					"category": {
+						Type: jsonschema.String,
+						Enum: ReviewCategories,
+					},
+					"message": {
+						Type:        jsonschema.String,
+						Description: "What is wrong and why it matters",
+					},
+					"suggestion": {
+						Type:        jsonschema.String,
+						Description: "How to fix the issue, optionally with replacement code",
+					},
+				},
+				Required: []string{"file", "start_line", "end_line", "severity", "category", "message"},
+			},
+		},
+	},
+	Required: []string{"summary", "findings"},
+}
+
+// severitySynonyms maps the severities models commonly answer with, outside of the
+// requested ones, to the closest known severity.
+var severitySynonyms = map[Severity]Severity{
+	"blocker":       SeverityCritical,
+	"error":         SeverityHigh,
+	"major":         SeverityHigh,
+	"warning":       SeverityMedium,
+	"warn":          SeverityMedium,
+	"moderate":      SeverityMedium,
+	"minor":         SeverityLow,
+	"note":          SeverityInfo,
+	"informational": SeverityInfo,
+}
+
+// normalizeSeverity returns the known severity of s, ignoring case and mapping
+// synonyms. Other severities become medium, so an unexpected answer is neither
+// dropped below --fail_on thresholds nor reported as critical.
+func normalizeSeverity(s Severity) Severity {
+	s = Severity(strings.ToLower(strings.TrimSpace(string(s))))
+	if s.IsValid() {
+		return s
+	}
+	if known, ok := severitySynonyms[s]; ok {
+		return known
+	}
+	return SeverityMedium
+}
+
+// ParseReview decodes the review findings returned by a provider. Models without
+// tool support answer in plain text, so the JSON object may be wrapped in prose
+// or a code fence.
+func ParseReview(content string) (*Review, error) {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("no JSON object found in the review")
+	}
+
+	var review Review
+	if err := json.Unmarshal([]byte(content[start:end+1]), &review); err != nil {
+		return nil, fmt.Errorf("invalid review findings: %w", err)
+	}
+	for i := range review.Findings {
+		f := &review.Findings[i]
+		f.Severity = normalizeSeverity(f.Severity)
+		f.File = strings.TrimPrefix(strings.TrimPrefix(f.File, "b/"), "./")
+	}
+	return &review, nil
+}
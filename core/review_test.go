@@ -0,0 +1,115 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNormalizeSeverity(t *testing.T) {
+	for in, want := range map[Severity]Severity{
+		"critical": SeverityCritical,
+		" Info ":   SeverityInfo,
+		"blocker":  SeverityCritical,
+		"Error":    SeverityHigh,
+		"major":    SeverityHigh,
+		"WARNING":  SeverityMedium,
+		"minor":    SeverityLow,
+		"note":     SeverityInfo,
+		"urgent":   SeverityMedium,
+		"":         SeverityMedium,
+	} {
+		if got := normalizeSeverity(in); got != want {
+			t.Errorf("normalizeSeverity(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseReview(t *testing.T) {
+	content := "Here is the review:\n```json\n" + `{
+  "summary": "Mostly fine.",
+  "findings": [
+    {"file": "b/main.go", "start_line": 3, "end_line": 3, "severity": "LOW", "category": "style", "message": "name"},
+    {"file": "main.go", "start_line": 10, "end_line": 12, "severity": "urgent", "category": "bug", "message": "nil"}
+  ]
+}` + "\n```"
+
+	review, err := ParseReview(content)
+	if err != nil {
+		t.Fatalf("ParseReview() error = %v", err)
+	}
+	if review.Summary != "Mostly fine." || len(review.Findings) != 2 {
+		t.Fatalf("ParseReview() = %+v", review)
+	}
+	first, second := review.Findings[0], review.Findings[1]
+	if first.File != "main.go" || first.Severity != SeverityLow || first.Lines() != "3" {
+		t.Errorf("unexpected first finding: %+v", first)
+	}
+	if second.Severity != SeverityMedium || second.Lines() != "10-12" {
+		t.Errorf("unexpected second finding: %+v", second)
+	}
+
+	for _, content := range []string{"Looks good to me.", "{not json}"} {
+		if _, err := ParseReview(content); err == nil {
+			t.Errorf("ParseReview(%q) should fail", content)
+		}
+	}
+}
+
+func TestReviewSort(t *testing.T) {
+	review := Review{Findings: []Finding{
+		{File: "b.go", StartLine: 1, Severity: SeverityLow},
+		{File: "a.go", StartLine: 9, Severity: SeverityHigh},
+		{File: "a.go", StartLine: 2, Severity: SeverityHigh},
+		{File: "a.go", StartLine: 1, Severity: SeverityInfo},
+	}}
+	review.Sort()
+
+	var got []string
+	for _, f := range review.Findings {
+		got = append(got, f.File+":"+f.Lines())
+	}
+	want := []string{"a.go:2", "a.go:9", "a.go:1", "b.go:1"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Sort() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestReviewCount(t *testing.T) {
+	review := Review{Findings: []Finding{
+		{Severity: SeverityCritical},
+		{Severity: SeverityMedium},
+		{Severity: SeverityMedium},
+		{Severity: SeverityInfo},
+	}}
+
+	if n := review.Count(SeverityMedium); n != 2 {
+		t.Errorf("Count(medium) = %d, want 2", n)
+	}
+	for s, want := range map[Severity]int{
+		SeverityCritical: 1,
+		SeverityHigh:     1,
+		SeverityMedium:   3,
+		SeverityLow:      3,
+		SeverityInfo:     4,
+	} {
+		if n := review.CountAtLeast(s); n != want {
+			t.Errorf("CountAtLeast(%s) = %d, want %d", s, n, want)
+		}
+	}
+}
+
+func TestReviewSchema(t *testing.T) {
+	data, err := json.Marshal(ReviewSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema["type"] != "object" {
+		t.Errorf("unexpected schema: %s", data)
+	}
+}
@@ -0,0 +1,20 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// WriteCompletion requests a regular completion from g and writes it to w at once.
+// Providers use it to serve CompletionStream for models that cannot stream.
+func WriteCompletion(ctx context.Context, g Generative, content string, w io.Writer) (*Response, error) {
+	resp, err := g.Completion(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(w, resp.Content); err != nil {
+		return nil, fmt.Errorf("writing completion: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+type stubGenerative struct {
+	content string
+	err     error
+}
+
+func (s stubGenerative) Completion(context.Context, string) (*Response, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &Response{Content: s.content}, nil
+}
+
+func (s stubGenerative) GetSummaryPrefix(ctx context.Context, content string) (*Response, error) {
+	return s.Completion(ctx, content)
+}
+
+func (s stubGenerative) GetReviewFindings(ctx context.Context, content string) (*Response, error) {
+	return s.Completion(ctx, content)
+}
+
+func (s stubGenerative) CompletionStream(context.Context, string, io.Writer) (*Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestWriteCompletion(t *testing.T) {
+	var sb strings.Builder
+	resp, err := WriteCompletion(context.Background(), stubGenerative{content: "hello"}, "hi", &sb)
+	if err != nil {
+		t.Fatalf("WriteCompletion() error = %v", err)
+	}
+	if resp.Content != "hello" || sb.String() != "hello" {
+		t.Errorf("WriteCompletion() = %q, wrote %q, want hello", resp.Content, sb.String())
+	}
+
+	wantErr := errors.New("boom")
+	sb.Reset()
+	if _, err := WriteCompletion(context.Background(), stubGenerative{err: wantErr}, "hi", &sb); !errors.Is(err, wantErr) {
+		t.Errorf("WriteCompletion() error = %v, want %v", err, wantErr)
+	}
+	if sb.Len() != 0 {
+		t.Errorf("WriteCompletion() wrote %q on error", sb.String())
+	}
+}
@@ -0,0 +1,20 @@
+package core
+
+import "context"
+
+// systemPromptKey is the context key of the per-request system prompt.
+type systemPromptKey struct{}
+
+// WithSystemPrompt returns a copy of ctx carrying a system prompt. Providers send it
+// in their native system field instead of the system prompt configured on the client.
+func WithSystemPrompt(ctx context.Context, prompt string) context.Context {
+	return context.WithValue(ctx, systemPromptKey{}, prompt)
+}
+
+// SystemPrompt returns the system prompt carried by ctx, or fallback when ctx has none.
+func SystemPrompt(ctx context.Context, fallback string) string {
+	if prompt, ok := ctx.Value(systemPromptKey{}).(string); ok && prompt != "" {
+		return prompt
+	}
+	return fallback
+}
@@ -0,0 +1,20 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSystemPrompt(t *testing.T) {
+	ctx := context.Background()
+
+	if got := SystemPrompt(ctx, "default"); got != "default" {
+		t.Errorf("SystemPrompt() = %q, want fallback", got)
+	}
+	if got := SystemPrompt(WithSystemPrompt(ctx, ""), "default"); got != "default" {
+		t.Errorf("SystemPrompt() = %q, empty override should use fallback", got)
+	}
+	if got := SystemPrompt(WithSystemPrompt(ctx, "house style"), "default"); got != "house style" {
+		t.Errorf("SystemPrompt() = %q, want override", got)
+	}
+}
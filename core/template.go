@@ -0,0 +1,18 @@
+package core
+
+import "context"
+
+// templateNameKey is the context key of the prompt template name.
+type templateNameKey struct{}
+
+// WithTemplateName returns a copy of ctx carrying the name of the prompt template
+// the request content was rendered from.
+func WithTemplateName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, templateNameKey{}, name)
+}
+
+// TemplateName returns the prompt template name carried by ctx, or an empty string.
+func TemplateName(ctx context.Context) string {
+	name, _ := ctx.Value(templateNameKey{}).(string)
+	return name
+}
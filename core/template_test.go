@@ -0,0 +1,18 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTemplateName(t *testing.T) {
+	ctx := context.Background()
+	if got := TemplateName(ctx); got != "" {
+		t.Errorf("TemplateName() = %q, want empty", got)
+	}
+
+	ctx = WithTemplateName(ctx, "code_review_file_diff.tmpl")
+	if got := TemplateName(ctx); got != "code_review_file_diff.tmpl" {
+		t.Errorf("TemplateName() = %q, want code_review_file_diff.tmpl", got)
+	}
+}
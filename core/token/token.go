@@ -0,0 +1,73 @@
+// Package token estimates how many tokens a prompt uses, so requests can be
+// trimmed to fit a model's context window before they are sent.
+package token
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/appleboy/CodeGPT/core"
+)
+
+// DefaultContextLimit is the context window assumed when none is configured.
+const DefaultContextLimit = 128000
+
+// defaultCharsPerToken is used for providers and models without a known ratio.
+const defaultCharsPerToken = 3.5
+
+// Estimator approximates token counts without a provider specific tokenizer.
+// Source code and diffs tokenize worse than prose, so the ratios are
+// deliberately conservative.
+type Estimator struct {
+	charsPerToken float64
+}
+
+// NewEstimator returns an Estimator for the provider and model.
+func NewEstimator(p core.Platform, model string) *Estimator {
+	return &Estimator{charsPerToken: charsPerToken(p, strings.ToLower(model))}
+}
+
+// charsPerToken returns the average number of ASCII characters per token.
+func charsPerToken(p core.Platform, model string) float64 {
+	switch p {
+	case core.OpenAI, core.Azure:
+		// o200k based models (gpt-4o, gpt-4.1, o-series) pack more characters per token
+		// than the older cl100k models.
+		if strings.HasPrefix(model, "gpt-3.5") ||
+			(strings.HasPrefix(model, "gpt-4") && !strings.HasPrefix(model, "gpt-4o") &&
+				!strings.HasPrefix(model, "gpt-4.1")) {
+			return 3.2
+		}
+		return 3.6
+	case core.Anthropic:
+		return 3.2
+	case core.Gemini:
+		return 3.8
+	case core.Ollama:
+		return 3.3
+	}
+	return defaultCharsPerToken
+}
+
+// Count returns the estimated number of tokens in text.
+// ASCII characters are divided by the per model ratio, every other rune is
+// counted as a token of its own, which holds well for CJK text.
+func (e *Estimator) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	ascii, other := 0, 0
+	for i := 0; i < len(text); {
+		if text[i] < utf8.RuneSelf {
+			ascii++
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		other++
+		i += size
+	}
+
+	return int(float64(ascii)/e.charsPerToken+0.999) + other
+}
@@ -0,0 +1,54 @@
+package token
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/core"
+)
+
+func TestCount(t *testing.T) {
+	e := &Estimator{charsPerToken: 4}
+
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{name: "empty", text: "", want: 0},
+		{name: "rounds up", text: "a", want: 1},
+		{name: "ascii", text: strings.Repeat("a", 40), want: 10},
+		{name: "cjk runes", text: "程式碼", want: 3},
+		{name: "mixed", text: "fix 程式", want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := e.Count(tt.text); got != tt.want {
+				t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewEstimator(t *testing.T) {
+	tests := []struct {
+		provider core.Platform
+		model    string
+		want     float64
+	}{
+		{core.OpenAI, "gpt-4o", 3.6},
+		{core.OpenAI, "GPT-4-turbo", 3.2},
+		{core.Azure, "gpt-3.5-turbo", 3.2},
+		{core.Anthropic, "claude-3-5-haiku-latest", 3.2},
+		{core.Gemini, "gemini-2.0-flash", 3.8},
+		{core.Ollama, "llama3", 3.3},
+		{core.Platform("unknown"), "model", defaultCharsPerToken},
+	}
+
+	for _, tt := range tests {
+		if got := NewEstimator(tt.provider, tt.model).charsPerToken; got != tt.want {
+			t.Errorf("NewEstimator(%s, %s) ratio = %v, want %v", tt.provider, tt.model, got, tt.want)
+		}
+	}
+}
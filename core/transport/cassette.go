@@ -0,0 +1,318 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// CassetteEnv names the cassette file provider clients record to or replay from.
+	// Leave it empty to send requests as usual.
+	CassetteEnv = "CODEGPT_CASSETTE"
+	// CassetteModeEnv selects the cassette mode, record or replay (default).
+	CassetteModeEnv = "CODEGPT_CASSETTE_MODE"
+)
+
+// CassetteMode selects whether a CassetteTransport records or replays requests.
+type CassetteMode string
+
+// Supported cassette modes.
+const (
+	// ModeReplay answers requests from the cassette without network access.
+	ModeReplay CassetteMode = "replay"
+	// ModeRecord sends requests and appends each request/response pair to the cassette.
+	// Delete the cassette file first to record it from scratch.
+	ModeRecord CassetteMode = "record"
+)
+
+// redacted replaces secrets in recorded requests and responses.
+const redacted = "REDACTED"
+
+// recordMu serializes writes to cassette files.
+var recordMu sync.Mutex
+
+// scrubbedHeaders are never written to a cassette.
+var scrubbedHeaders = []string{
+	"Authorization",
+	"Api-Key",
+	"X-Api-Key",
+	"X-Goog-Api-Key",
+	"Openai-Organization",
+	"Openai-Project",
+	"Anthropic-Organization-Id",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// scrubbedParams are the query parameters that are never written to a cassette.
+var scrubbedParams = []string{"key", "api_key", "api-key"}
+
+// RecordedRequest is a request stored in a cassette.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response stored in a cassette.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Interaction is a request/response pair stored in a cassette.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// Cassette is a recorded HTTP session, stored as JSON.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// CassetteTransport is an http.RoundTripper that records requests and responses to a
+// cassette file, or replays them from it without network access.
+// API keys and auth headers are scrubbed before anything is written. Replayed
+// requests match a recorded one by method, URL path and query, and body; the host
+// is ignored so a cassette works with any base URL. Each recorded interaction is
+// replayed once, in order.
+type CassetteTransport struct {
+	Origin http.RoundTripper
+	Path   string
+	Mode   CassetteMode
+
+	mu       sync.Mutex
+	loaded   bool
+	cassette Cassette
+	used     []bool
+}
+
+// WithCassette wraps origin with a CassetteTransport when the CODEGPT_CASSETTE
+// environment variable names a cassette file, in the mode set by
+// CODEGPT_CASSETTE_MODE. Otherwise origin is returned as is.
+func WithCassette(origin http.RoundTripper) http.RoundTripper {
+	path := os.Getenv(CassetteEnv)
+	if path == "" {
+		return origin
+	}
+
+	mode := CassetteMode(os.Getenv(CassetteModeEnv))
+	if mode == "" {
+		mode = ModeReplay
+	}
+	return &CassetteTransport{
+		Origin: origin,
+		Path:   path,
+		Mode:   mode,
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *CassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.Mode {
+	case ModeReplay:
+		return t.replay(req, body)
+	case ModeRecord:
+		return t.record(req, body)
+	}
+	return nil, fmt.Errorf("unsupported cassette mode %q, use record or replay", t.Mode)
+}
+
+// replay returns the first unused recorded response matching req.
+func (t *CassetteTransport) replay(req *http.Request, body []byte) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.load(); err != nil {
+		return nil, err
+	}
+
+	target := requestTarget(req.URL)
+	for i, in := range t.cassette.Interactions {
+		if t.used[i] || in.Request.Method != req.Method {
+			continue
+		}
+		u, err := url.Parse(in.Request.URL)
+		if err != nil || requestTarget(u) != target || !sameBody(in.Request.Body, body) {
+			continue
+		}
+
+		t.used[i] = true
+		code := in.Response.StatusCode
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+			StatusCode:    code,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header.Clone(),
+			Body:          io.NopCloser(strings.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf(
+		"cassette %s has no recorded response for %s %s",
+		t.Path, req.Method, target,
+	)
+}
+
+// record sends req and appends the scrubbed request and response to the cassette.
+func (t *CassetteTransport) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := t.Origin.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	// Cassettes are re-read before every append, so several clients in one process,
+	// such as a fallback chain, can record to the same file.
+	recordMu.Lock()
+	defer recordMu.Unlock()
+
+	var c Cassette
+	if data, err := os.ReadFile(t.Path); err == nil {
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("invalid cassette %s: %w", t.Path, err)
+		}
+	}
+	c.Interactions = append(c.Interactions, Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    scrubURL(req.URL),
+			Header: scrubHeader(req.Header),
+			Body:   string(body),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     scrubHeader(resp.Header),
+			Body:       string(respBody),
+		},
+	})
+	if err := saveCassette(t.Path, c); err != nil {
+		return nil, fmt.Errorf("failed to save cassette %s: %w", t.Path, err)
+	}
+	return resp, nil
+}
+
+// load reads the cassette file once.
+func (t *CassetteTransport) load() error {
+	if t.loaded {
+		return nil
+	}
+
+	data, err := os.ReadFile(t.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read cassette: %w", err)
+	}
+	if err := json.Unmarshal(data, &t.cassette); err != nil {
+		return fmt.Errorf("invalid cassette %s: %w", t.Path, err)
+	}
+	t.used = make([]bool, len(t.cassette.Interactions))
+	t.loaded = true
+	return nil
+}
+
+// saveCassette writes c to path.
+func saveCassette(path string, c Cassette) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// readRequestBody reads the body of req and replaces it with an unread copy.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// requestTarget returns the scrubbed path and query of u, which identify a request
+// independently of the host.
+func requestTarget(u *url.URL) string {
+	scrubbed, err := url.Parse(scrubURL(u))
+	if err != nil {
+		return u.RequestURI()
+	}
+	return scrubbed.RequestURI()
+}
+
+// sameBody reports whether a recorded and a sent body are equal, comparing JSON
+// bodies by value so formatting differences do not matter.
+func sameBody(recorded string, sent []byte) bool {
+	if recorded == string(sent) {
+		return true
+	}
+
+	var a, b any
+	if json.Unmarshal([]byte(recorded), &a) != nil || json.Unmarshal(sent, &b) != nil {
+		return false
+	}
+	x, errX := json.Marshal(a)
+	y, errY := json.Marshal(b)
+	return errX == nil && errY == nil && bytes.Equal(x, y)
+}
+
+// scrubHeader returns a copy of h with secrets redacted.
+func scrubHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for _, key := range scrubbedHeaders {
+		if out.Get(key) != "" {
+			out.Set(key, redacted)
+		}
+	}
+	return out
+}
+
+// scrubURL returns u with secret query parameters redacted.
+func scrubURL(u *url.URL) string {
+	q := u.Query()
+	changed := false
+	for _, key := range scrubbedParams {
+		if q.Has(key) {
+			q.Set(key, redacted)
+			changed = true
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+
+	scrubbed := *u
+	scrubbed.RawQuery = q.Encode()
+	return scrubbed.String()
+}
@@ -0,0 +1,137 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func doCassetteRequest(t *testing.T, rt http.RoundTripper, url, body string) (*http.Response, error) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(
+		context.Background(),
+		http.MethodPost,
+		url,
+		strings.NewReader(body),
+	)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer sk-secret")
+	req.Header.Set("X-Goog-Api-Key", "goog-secret")
+	return rt.RoundTrip(req)
+}
+
+func TestCassetteTransport_RecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: " + string(body) + "\n\n"))
+	}))
+
+	path := filepath.Join(t.TempDir(), "cassettes", "session.json")
+	recorder := &CassetteTransport{Origin: http.DefaultTransport, Path: path, Mode: ModeRecord}
+	for _, body := range []string{`{"n":1}`, `{"n":2}`} {
+		resp, err := doCassetteRequest(t, recorder, server.URL+"/v1/chat?key=secret&alt=sse", body)
+		if err != nil {
+			t.Fatalf("record failed: %v", err)
+		}
+		got, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(got) != "data: "+body+"\n\n" {
+			t.Errorf("recorded response body = %q", got)
+		}
+	}
+	server.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read cassette: %v", err)
+	}
+	for _, secret := range []string{"sk-secret", "goog-secret", "session=secret", "key=secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains secret %q", secret)
+		}
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatalf("invalid cassette: %v", err)
+	}
+	if len(c.Interactions) != 2 {
+		t.Fatalf("expected 2 interactions, got %d", len(c.Interactions))
+	}
+
+	// Replay from another host with reformatted bodies and no network access.
+	player := &CassetteTransport{Path: path, Mode: ModeReplay}
+	url := "https://api.example.com/v1/chat?key=other&alt=sse"
+	for _, body := range []string{`{ "n": 2 }`, `{ "n": 1 }`} {
+		resp, err := doCassetteRequest(t, player, url, body)
+		if err != nil {
+			t.Fatalf("replay failed: %v", err)
+		}
+		got, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		want := "data: " + strings.ReplaceAll(body, " ", "") + "\n\n"
+		if resp.StatusCode != http.StatusOK || string(got) != want {
+			t.Errorf("replayed %d %q, want 200 %q", resp.StatusCode, got, want)
+		}
+		if resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Errorf("unexpected Content-Type %q", resp.Header.Get("Content-Type"))
+		}
+	}
+
+	// Each interaction is replayed once.
+	if _, err := doCassetteRequest(t, player, url, `{"n":1}`); err == nil ||
+		!strings.Contains(err.Error(), "no recorded response for POST /v1/chat") {
+		t.Errorf("expected missing response error, got %v", err)
+	}
+}
+
+func TestCassetteTransport_ReplayMissingCassette(t *testing.T) {
+	player := &CassetteTransport{
+		Path: filepath.Join(t.TempDir(), "missing.json"),
+		Mode: ModeReplay,
+	}
+	if _, err := doCassetteRequest(t, player, "https://api.example.com/", "{}"); err == nil {
+		t.Error("expected error for missing cassette")
+	}
+}
+
+func TestCassetteTransport_UnsupportedMode(t *testing.T) {
+	rt := &CassetteTransport{Origin: http.DefaultTransport, Path: "unused.json", Mode: "rewind"}
+	if _, err := doCassetteRequest(t, rt, "https://api.example.com/", "{}"); err == nil ||
+		!strings.Contains(err.Error(), `unsupported cassette mode "rewind"`) {
+		t.Errorf("expected unsupported mode error, got %v", err)
+	}
+}
+
+func TestWithCassette(t *testing.T) {
+	t.Setenv(CassetteEnv, "")
+	if rt := WithCassette(http.DefaultTransport); rt != http.DefaultTransport {
+		t.Errorf("expected origin without %s, got %T", CassetteEnv, rt)
+	}
+
+	t.Setenv(CassetteEnv, "session.json")
+	t.Setenv(CassetteModeEnv, "")
+	rt, ok := WithCassette(http.DefaultTransport).(*CassetteTransport)
+	if !ok {
+		t.Fatal("expected a CassetteTransport")
+	}
+	if rt.Path != "session.json" || rt.Mode != ModeReplay {
+		t.Errorf("unexpected transport path %q mode %q", rt.Path, rt.Mode)
+	}
+
+	t.Setenv(CassetteModeEnv, "record")
+	rt = WithCassette(http.DefaultTransport).(*CassetteTransport)
+	if rt.Mode != ModeRecord {
+		t.Errorf("expected record mode, got %q", rt.Mode)
+	}
+}
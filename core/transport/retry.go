@@ -0,0 +1,170 @@
+package transport
+
+import (
+	"bytes"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries is the default number of retries for a failed request.
+	DefaultMaxRetries = 2
+	// DefaultRetryBaseDelay is the initial backoff delay between retries.
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+	// DefaultRetryMaxDelay is the maximum backoff delay between retries.
+	DefaultRetryMaxDelay = 30 * time.Second
+	// maxRetryAfter is the longest Retry-After delay that is honored;
+	// longer delays return the response to the caller instead of waiting.
+	maxRetryAfter = 2 * time.Minute
+)
+
+// RetryTransport is an http.RoundTripper that retries requests which fail with
+// a rate limit (429) or server error (5xx) status code.
+// It honors the Retry-After and retry-after-ms response headers, otherwise it
+// backs off exponentially with jitter. Waiting stops as soon as the request context is done.
+type RetryTransport struct {
+	Origin     http.RoundTripper
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.MaxRetries <= 0 {
+		return t.Origin.RoundTrip(req)
+	}
+
+	getBody, err := rewindableBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetBody != nil && req.Body != nil {
+		// Every attempt gets its own copy, so the original body is only closed.
+		defer req.Body.Close()
+	}
+
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		// Clone the request so headers added by inner transports are not duplicated on retries.
+		r := req.Clone(ctx)
+		if getBody != nil {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+
+		resp, err := t.Origin.RoundTrip(r)
+		if err != nil || attempt >= t.MaxRetries || !shouldRetry(resp.StatusCode) {
+			return resp, err
+		}
+
+		delay, ok := t.delay(attempt, resp.Header)
+		if !ok {
+			return resp, nil
+		}
+
+		// Drain and close the body so the connection can be reused.
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns how long to wait before the next attempt.
+// It returns false when the server asks to wait longer than maxRetryAfter.
+func (t *RetryTransport) delay(attempt int, header http.Header) (time.Duration, bool) {
+	if d, ok := retryAfter(header, time.Now()); ok {
+		return d, d <= maxRetryAfter
+	}
+
+	base := t.BaseDelay
+	if base <= 0 {
+		base = DefaultRetryBaseDelay
+	}
+	maxDelay := t.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = DefaultRetryMaxDelay
+	}
+
+	backoff := base << attempt
+	if backoff <= 0 || backoff > maxDelay {
+		backoff = maxDelay
+	}
+
+	// Equal jitter: wait between half and the full backoff.
+	half := backoff / 2
+	return half + rand.N(half+1), true //nolint:gosec // jitter does not need a secure random source
+}
+
+// shouldRetry reports whether the status code is a rate limit or a retryable server error.
+func shouldRetry(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	// Non-standard 5xx codes such as 520-529 are used by proxies and for overload errors.
+	return code > http.StatusNetworkAuthenticationRequired && code < 600
+}
+
+// retryAfter parses the retry-after-ms and Retry-After headers.
+// Retry-After may be either a number of seconds or an HTTP date.
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	if v := header.Get("retry-after-ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms >= 0 {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(v); err == nil {
+		d := date.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// rewindableBody returns a function producing a fresh copy of the request body for each attempt.
+// Bodies without GetBody are read into memory once.
+func rewindableBody(req *http.Request) (func() (io.ReadCloser, error), error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil //nolint:nilnil // a nil function means the request has no body
+	}
+	if req.GetBody != nil {
+		return req.GetBody, nil
+	}
+
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}, nil
+}
@@ -0,0 +1,244 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport_RetriesOnServerError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("attempt %d: expected body %q, got %q", calls.Load(), "payload", body)
+		}
+		if got := r.Header.Values("X-Custom"); len(got) != 1 {
+			t.Errorf("expected a single X-Custom header, got %v", got)
+		}
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &RetryTransport{
+			Origin: &DefaultHeaderTransport{
+				Origin: http.DefaultTransport,
+				Header: http.Header{"X-Custom": {"1"}},
+			},
+			MaxRetries: 3,
+			BaseDelay:  time.Millisecond,
+		},
+	}
+
+	req, _ := http.NewRequestWithContext(
+		context.Background(),
+		http.MethodPost,
+		server.URL,
+		strings.NewReader("payload"),
+	)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestRetryTransport_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &RetryTransport{
+			Origin:     http.DefaultTransport,
+			MaxRetries: 2,
+			BaseDelay:  time.Millisecond,
+		},
+	}
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected status 429, got %d", resp.StatusCode)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestRetryTransport_NoRetryOnClientError(t *testing.T) {
+	mock := &mockRoundTripper{
+		resp: &http.Response{StatusCode: http.StatusBadRequest, Body: http.NoBody},
+	}
+	var calls int
+	counting := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return mock.RoundTrip(req)
+	})
+	tr := &RetryTransport{Origin: counting, MaxRetries: 3, BaseDelay: time.Millisecond}
+
+	req, _ := http.NewRequestWithContext(
+		context.Background(),
+		http.MethodGet,
+		"http://example.com",
+		nil,
+	)
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip error: %v", err)
+	}
+	resp.Body.Close()
+
+	if calls != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls)
+	}
+}
+
+func TestRetryTransport_ContextCanceledWhileWaiting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	counting := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		cancel()
+		h := http.Header{}
+		h.Set("Retry-After", "60")
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     h,
+			Body:       http.NoBody,
+		}, nil
+	})
+	tr := &RetryTransport{Origin: counting, MaxRetries: 3}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+	start := time.Now()
+	resp, err := tr.RoundTrip(req)
+	if resp != nil && resp.Body != nil {
+		resp.Body.Close()
+	}
+	if err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("expected RoundTrip to return without waiting for Retry-After")
+	}
+}
+
+func TestRetryTransport_DisabledWithZeroRetries(t *testing.T) {
+	var calls int
+	counting := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: http.StatusBadGateway, Body: http.NoBody}, nil
+	})
+	tr := &RetryTransport{Origin: counting}
+
+	req, _ := http.NewRequestWithContext(
+		context.Background(),
+		http.MethodGet,
+		"http://example.com",
+		nil,
+	)
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip error: %v", err)
+	}
+	resp.Body.Close()
+
+	if calls != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "missing", header: http.Header{}, wantOK: false},
+		{
+			name:   "seconds",
+			header: http.Header{"Retry-After": {"3"}},
+			want:   3 * time.Second,
+			wantOK: true,
+		},
+		{
+			name:   "milliseconds",
+			header: http.Header{"Retry-After-Ms": {"250"}, "Retry-After": {"1"}},
+			want:   250 * time.Millisecond,
+			wantOK: true,
+		},
+		{
+			name:   "http date",
+			header: http.Header{"Retry-After": {now.Add(10 * time.Second).Format(http.TimeFormat)}},
+			want:   10 * time.Second,
+			wantOK: true,
+		},
+		{
+			name:   "date in the past",
+			header: http.Header{"Retry-After": {now.Add(-time.Minute).Format(http.TimeFormat)}},
+			want:   0,
+			wantOK: true,
+		},
+		{name: "invalid", header: http.Header{"Retry-After": {"soon"}}, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retryAfter(tt.header, now)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("retryAfter() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRetryTransport_DelayBounds(t *testing.T) {
+	tr := &RetryTransport{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt := range 10 {
+		d, ok := tr.delay(attempt, http.Header{})
+		if !ok {
+			t.Fatalf("attempt %d: expected delay to be allowed", attempt)
+		}
+		backoff := min(100*time.Millisecond<<attempt, time.Second)
+		if d < backoff/2 || d > backoff {
+			t.Errorf("attempt %d: delay %v out of range [%v, %v]", attempt, d, backoff/2, backoff)
+		}
+	}
+
+	if _, ok := tr.delay(0, http.Header{"Retry-After": {"3600"}}); ok {
+		t.Error("expected a Retry-After longer than the limit to stop retrying")
+	}
+}
+
+// roundTripFunc adapts a function to the http.RoundTripper interface.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,123 @@
+// Package usage records the token usage of every request to a local ledger
+// and summarizes it, including the estimated cost.
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/appleboy/CodeGPT/core"
+)
+
+// Record is a single request stored in the ledger.
+type Record struct {
+	Time             time.Time `json:"time"`
+	Command          string    `json:"command,omitempty"`
+	Repository       string    `json:"repository,omitempty"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CachedTokens     int       `json:"cached_tokens,omitempty"`
+	ReasoningTokens  int       `json:"reasoning_tokens,omitempty"`
+}
+
+// NewRecord returns a Record of u made now. The provider and model reported in u
+// take precedence over the given ones, they name the backend that answered.
+func NewRecord(u core.Usage, provider, model string) Record {
+	if u.Provider != "" {
+		provider = u.Provider
+	}
+	if u.Model != "" {
+		model = u.Model
+	}
+
+	r := Record{
+		Time:             time.Now(),
+		Provider:         provider,
+		Model:            model,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+	}
+	if u.PromptTokensDetails != nil {
+		r.CachedTokens = u.PromptTokensDetails.CachedTokens
+	}
+	if u.CompletionTokensDetails != nil {
+		r.ReasoningTokens = u.CompletionTokensDetails.ReasoningTokens
+	}
+	return r
+}
+
+// Ledger is an append-only JSON Lines file of records.
+type Ledger struct {
+	path string
+}
+
+// NewLedger returns a Ledger stored at path.
+func NewLedger(path string) *Ledger {
+	return &Ledger{path: path}
+}
+
+// Path returns the file path of the ledger.
+func (l *Ledger) Path() string {
+	return l.path
+}
+
+// Append adds r to the end of the ledger, creating the file when needed.
+func (l *Ledger) Append(r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Records returns the records made at or after since, in ledger order.
+// A missing ledger has no records. Lines that cannot be decoded, such as a
+// line cut short by an interrupted write, are skipped.
+func (l *Ledger) Records(since time.Time) ([]Record, error) {
+	f, err := os.Open(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		if r.Time.Before(since) {
+			continue
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading usage ledger: %w", err)
+	}
+	return records, nil
+}
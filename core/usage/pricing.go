@@ -0,0 +1,95 @@
+package usage
+
+import "strings"
+
+// Price is the cost of a model in US dollars per million tokens.
+// Model matches the model name exactly or as a prefix, e.g. "gpt-4o" also
+// prices "gpt-4o-2024-08-06". The longest matching model wins.
+type Price struct {
+	Model       string  `mapstructure:"model" json:"model"`
+	Input       float64 `mapstructure:"input" json:"input"`
+	CachedInput float64 `mapstructure:"cached_input" json:"cached_input"`
+	Output      float64 `mapstructure:"output" json:"output"`
+}
+
+// builtinPrices lists the list prices of well known models.
+var builtinPrices = []Price{
+	{Model: "gpt-3.5-turbo", Input: 0.5, Output: 1.5},
+	{Model: "gpt-4", Input: 30, Output: 60},
+	{Model: "gpt-4-turbo", Input: 10, Output: 30},
+	{Model: "gpt-4o", Input: 2.5, CachedInput: 1.25, Output: 10},
+	{Model: "gpt-4o-mini", Input: 0.15, CachedInput: 0.075, Output: 0.6},
+	{Model: "gpt-4.1", Input: 2, CachedInput: 0.5, Output: 8},
+	{Model: "gpt-4.1-mini", Input: 0.4, CachedInput: 0.1, Output: 1.6},
+	{Model: "gpt-4.1-nano", Input: 0.1, CachedInput: 0.025, Output: 0.4},
+	{Model: "gpt-5", Input: 1.25, CachedInput: 0.125, Output: 10},
+	{Model: "gpt-5-mini", Input: 0.25, CachedInput: 0.025, Output: 2},
+	{Model: "gpt-5-nano", Input: 0.05, CachedInput: 0.005, Output: 0.4},
+	{Model: "o1", Input: 15, CachedInput: 7.5, Output: 60},
+	{Model: "o1-mini", Input: 1.1, CachedInput: 0.55, Output: 4.4},
+	{Model: "o3", Input: 2, CachedInput: 0.5, Output: 8},
+	{Model: "o3-mini", Input: 1.1, CachedInput: 0.55, Output: 4.4},
+	{Model: "o4-mini", Input: 1.1, CachedInput: 0.275, Output: 4.4},
+	{Model: "claude-3-haiku", Input: 0.25, CachedInput: 0.03, Output: 1.25},
+	{Model: "claude-3-5-haiku", Input: 0.8, CachedInput: 0.08, Output: 4},
+	{Model: "claude-3-5-sonnet", Input: 3, CachedInput: 0.3, Output: 15},
+	{Model: "claude-3-7-sonnet", Input: 3, CachedInput: 0.3, Output: 15},
+	{Model: "claude-sonnet-4", Input: 3, CachedInput: 0.3, Output: 15},
+	{Model: "claude-opus-4", Input: 15, CachedInput: 1.5, Output: 75},
+	{Model: "gemini-2.0-flash", Input: 0.1, CachedInput: 0.025, Output: 0.4},
+	{Model: "gemini-2.5-flash", Input: 0.3, CachedInput: 0.075, Output: 2.5},
+	{Model: "gemini-2.5-pro", Input: 1.25, CachedInput: 0.31, Output: 10},
+	{Model: "deepseek-chat", Input: 0.27, CachedInput: 0.07, Output: 1.1},
+	{Model: "deepseek-reasoner", Input: 0.55, CachedInput: 0.14, Output: 2.19},
+}
+
+// Pricing computes the cost of records.
+type Pricing struct {
+	prices []Price
+}
+
+// NewPricing returns a Pricing with the built-in prices and the given overrides.
+// An override replaces the built-in price of the same model.
+func NewPricing(overrides ...Price) *Pricing {
+	prices := make([]Price, 0, len(builtinPrices)+len(overrides))
+	prices = append(prices, overrides...)
+	prices = append(prices, builtinPrices...)
+	return &Pricing{prices: prices}
+}
+
+// Lookup returns the price of model, or false when the model has no price.
+// Local models served by Ollama are free and have no price either.
+func (p *Pricing) Lookup(model string) (Price, bool) {
+	model = strings.ToLower(model)
+	var best Price
+	found := false
+	for _, price := range p.prices {
+		name := strings.ToLower(price.Model)
+		if name == "" || !strings.HasPrefix(model, name) {
+			continue
+		}
+		if !found || len(name) > len(best.Model) {
+			best, found = price, true
+		}
+	}
+	return best, found
+}
+
+// Cost returns the cost of r in US dollars, or false when the model has no price.
+// Cached prompt tokens are billed at the cached input price when one is set.
+func (p *Pricing) Cost(r Record) (float64, bool) {
+	price, ok := p.Lookup(r.Model)
+	if !ok {
+		return 0, false
+	}
+
+	cachedInput := price.CachedInput
+	if cachedInput == 0 {
+		cachedInput = price.Input
+	}
+	cached := min(r.CachedTokens, r.PromptTokens)
+	cost := float64(r.PromptTokens-cached)*price.Input +
+		float64(cached)*cachedInput +
+		float64(r.CompletionTokens)*price.Output
+	return cost / 1e6, true
+}
@@ -0,0 +1,108 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+)
+
+// GroupBy selects how records are grouped in a summary.
+type GroupBy string
+
+// Supported groupings.
+const (
+	ByDay        GroupBy = "day"
+	ByModel      GroupBy = "model"
+	ByRepository GroupBy = "repository"
+)
+
+// IsValid reports whether g is a supported grouping.
+func (g GroupBy) IsValid() bool {
+	switch g {
+	case ByDay, ByModel, ByRepository:
+		return true
+	}
+	return false
+}
+
+// key returns the group of r.
+func (g GroupBy) key(r Record) string {
+	switch g {
+	case ByModel:
+		if r.Provider == "" {
+			return r.Model
+		}
+		return r.Provider + "/" + r.Model
+	case ByRepository:
+		if r.Repository == "" {
+			return "(none)"
+		}
+		return r.Repository
+	default:
+		return r.Time.Local().Format("2006-01-02")
+	}
+}
+
+// Row is the summary of the records of one group.
+type Row struct {
+	Key              string  `json:"key"`
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CachedTokens     int     `json:"cached_tokens"`
+	ReasoningTokens  int     `json:"reasoning_tokens"`
+	Cost             float64 `json:"cost"`
+	// Unpriced counts the calls to models without a price, they add nothing to Cost.
+	Unpriced int `json:"unpriced"`
+}
+
+// add adds r, which costs cost, to the row.
+func (row *Row) add(r Record, cost float64, priced bool) {
+	row.Calls++
+	row.PromptTokens += r.PromptTokens
+	row.CompletionTokens += r.CompletionTokens
+	row.CachedTokens += r.CachedTokens
+	row.ReasoningTokens += r.ReasoningTokens
+	row.Cost += cost
+	if !priced {
+		row.Unpriced++
+	}
+}
+
+// Summary is the usage of a set of records, grouped and in total.
+type Summary struct {
+	By    GroupBy `json:"by"`
+	Rows  []Row   `json:"rows"`
+	Total Row     `json:"total"`
+}
+
+// Summarize groups records by g and computes their cost with pricing.
+// Rows are sorted by key.
+func Summarize(records []Record, g GroupBy, pricing *Pricing) (*Summary, error) {
+	if !g.IsValid() {
+		return nil, fmt.Errorf("unsupported grouping %q, use day, model or repository", g)
+	}
+
+	rows := make(map[string]*Row)
+	s := &Summary{By: g, Total: Row{Key: "total"}}
+	for _, r := range records {
+		key := g.key(r)
+		row, ok := rows[key]
+		if !ok {
+			row = &Row{Key: key}
+			rows[key] = row
+		}
+
+		cost, priced := pricing.Cost(r)
+		row.add(r, cost, priced)
+		s.Total.add(r, cost, priced)
+	}
+
+	s.Rows = make([]Row, 0, len(rows))
+	for _, row := range rows {
+		s.Rows = append(s.Rows, *row)
+	}
+	sort.Slice(s.Rows, func(i, j int) bool {
+		return s.Rows[i].Key < s.Rows[j].Key
+	})
+	return s, nil
+}
@@ -0,0 +1,173 @@
+package usage
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/appleboy/CodeGPT/core"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestNewRecord(t *testing.T) {
+	u := core.Usage{
+		PromptTokens:     100,
+		CompletionTokens: 20,
+		TotalTokens:      120,
+		PromptTokensDetails: &openai.PromptTokensDetails{
+			CachedTokens: 40,
+		},
+		CompletionTokensDetails: &openai.CompletionTokensDetails{
+			ReasoningTokens: 10,
+		},
+	}
+
+	r := NewRecord(u, "openai", "gpt-4o")
+	if r.Provider != "openai" || r.Model != "gpt-4o" {
+		t.Errorf("unexpected provider and model: %+v", r)
+	}
+	if r.PromptTokens != 100 || r.CompletionTokens != 20 ||
+		r.CachedTokens != 40 || r.ReasoningTokens != 10 {
+		t.Errorf("unexpected tokens: %+v", r)
+	}
+
+	u.Provider, u.Model = "anthropic", "claude-3-5-haiku-latest"
+	r = NewRecord(u, "openai", "gpt-4o")
+	if r.Provider != "anthropic" || r.Model != "claude-3-5-haiku-latest" {
+		t.Errorf("usage provider and model should win: %+v", r)
+	}
+}
+
+func TestLedger(t *testing.T) {
+	l := NewLedger(filepath.Join(t.TempDir(), "nested", "usage.jsonl"))
+
+	records, err := l.Records(time.Time{})
+	if err != nil || len(records) != 0 {
+		t.Fatalf("missing ledger should be empty, got %v, %v", records, err)
+	}
+
+	now := time.Now()
+	old := Record{Time: now.Add(-48 * time.Hour), Model: "gpt-4o", PromptTokens: 1}
+	recent := Record{Time: now, Model: "gpt-4o-mini", PromptTokens: 2}
+	for _, r := range []Record{old, recent} {
+		if err := l.Append(r); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	// A truncated line is skipped.
+	f, err := os.OpenFile(l.Path(), os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"time":"2024-`)
+	f.Close()
+
+	records, err = l.Records(time.Time{})
+	if err != nil || len(records) != 2 {
+		t.Fatalf("Records() = %v, %v, want 2 records", records, err)
+	}
+
+	records, err = l.Records(now.Add(-time.Hour))
+	if err != nil || len(records) != 1 || records[0].Model != "gpt-4o-mini" {
+		t.Fatalf("Records(since) = %v, %v, want the recent record", records, err)
+	}
+}
+
+func TestPricing(t *testing.T) {
+	p := NewPricing(Price{Model: "gpt-4o", Input: 5, Output: 20})
+
+	tests := []struct {
+		name   string
+		record Record
+		want   float64
+		priced bool
+	}{
+		{
+			name:   "override",
+			record: Record{Model: "gpt-4o-2024-08-06", PromptTokens: 1e6, CompletionTokens: 1e6},
+			want:   25,
+			priced: true,
+		},
+		{
+			name:   "longest prefix",
+			record: Record{Model: "gpt-4o-mini", PromptTokens: 1e6, CompletionTokens: 1e6},
+			want:   0.75,
+			priced: true,
+		},
+		{
+			name: "cached input",
+			record: Record{
+				Model:        "gpt-4.1",
+				PromptTokens: 1e6,
+				CachedTokens: 5e5,
+			},
+			want:   1.25,
+			priced: true,
+		},
+		{
+			name:   "override without cached price",
+			record: Record{Model: "gpt-4o", PromptTokens: 1e6, CachedTokens: 1e6},
+			want:   5,
+			priced: true,
+		},
+		{
+			name:   "unknown model",
+			record: Record{Model: "llama3", PromptTokens: 1e6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, priced := p.Cost(tt.record)
+			if priced != tt.priced || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Cost() = %v, %v, want %v, %v", got, priced, tt.want, tt.priced)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+	records := []Record{
+		{Time: day, Provider: "openai", Model: "gpt-4o", Repository: "/src/a", PromptTokens: 1e6},
+		{Time: day, Provider: "openai", Model: "gpt-4o", Repository: "/src/b", CompletionTokens: 1e6},
+		{Time: day.AddDate(0, 0, 1), Provider: "ollama", Model: "llama3", PromptTokens: 10},
+	}
+	pricing := NewPricing()
+
+	s, err := Summarize(records, ByDay, pricing)
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if len(s.Rows) != 2 || s.Rows[0].Key != "2024-05-01" || s.Rows[0].Calls != 2 ||
+		s.Rows[1].Key != "2024-05-02" || s.Rows[1].Unpriced != 1 {
+		t.Errorf("unexpected rows by day: %+v", s.Rows)
+	}
+	if s.Total.Calls != 3 || math.Abs(s.Total.Cost-12.5) > 1e-9 {
+		t.Errorf("unexpected total: %+v", s.Total)
+	}
+
+	s, err = Summarize(records, ByModel, pricing)
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if len(s.Rows) != 2 || s.Rows[0].Key != "ollama/llama3" || s.Rows[1].Key != "openai/gpt-4o" {
+		t.Errorf("unexpected rows by model: %+v", s.Rows)
+	}
+
+	s, err = Summarize(records, ByRepository, pricing)
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if len(s.Rows) != 3 || s.Rows[0].Key != "(none)" {
+		t.Errorf("unexpected rows by repository: %+v", s.Rows)
+	}
+
+	if _, err := Summarize(records, "week", pricing); err == nil {
+		t.Error("expected an error for an unsupported grouping")
+	}
+}
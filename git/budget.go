@@ -0,0 +1,177 @@
+package git
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TrimReport describes how a diff was reduced to fit a token budget.
+type TrimReport struct {
+	// ContextDropped is true when unchanged context lines were removed.
+	ContextDropped bool
+	// Collapsed lists the files replaced by a short stat summary.
+	Collapsed []string
+	// Omitted lists the files left out of the diff entirely.
+	Omitted []string
+}
+
+// Trimmed reports whether the diff was changed at all.
+func (r TrimReport) Trimmed() bool {
+	return r.ContextDropped || len(r.Collapsed) > 0 || len(r.Omitted) > 0
+}
+
+// fileDiff is the diff of a single file, split into its header and hunks.
+type fileDiff struct {
+	name      string
+	header    []string
+	body      []string
+	additions int
+	deletions int
+	collapsed bool
+	// tokens is the token count of String, kept up to date by TrimDiff.
+	tokens int
+}
+
+// String renders the file diff, or its stat summary when collapsed.
+func (f *fileDiff) String() string {
+	if f.collapsed {
+		return fmt.Sprintf(
+			"%s %s | %d additions(+), %d deletions(-), diff omitted to fit the context window\n",
+			f.header[0], f.name, f.additions, f.deletions,
+		)
+	}
+	return strings.Join(f.header, "") + strings.Join(f.body, "")
+}
+
+// dropContext removes the unchanged context lines from the hunks.
+func (f *fileDiff) dropContext() {
+	f.body = slices.DeleteFunc(f.body, func(line string) bool {
+		return strings.HasPrefix(line, " ")
+	})
+}
+
+// parseDiff splits the output of git diff into per file diffs.
+// Any text before the first file header is returned as the preamble.
+func parseDiff(diff string) (string, []*fileDiff) {
+	var (
+		preamble strings.Builder
+		files    []*fileDiff
+		current  *fileDiff
+	)
+
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "diff --git ") {
+			current = &fileDiff{name: diffFileName(line)}
+			files = append(files, current)
+		}
+		switch {
+		case current == nil:
+			preamble.WriteString(line)
+		case len(current.body) == 0 && !strings.HasPrefix(line, "@@"):
+			current.header = append(current.header, line)
+		default:
+			current.body = append(current.body, line)
+			switch {
+			case strings.HasPrefix(line, "+"):
+				current.additions++
+			case strings.HasPrefix(line, "-"):
+				current.deletions++
+			}
+		}
+	}
+
+	return preamble.String(), files
+}
+
+// diffFileName returns the file name from a "diff --git a/<name> b/<name>" line.
+func diffFileName(line string) string {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "diff --git "))
+	if _, name, ok := strings.Cut(line, " b/"); ok {
+		return name
+	}
+	return line
+}
+
+// TrimDiff reduces diff until count reports at most maxTokens for it.
+// It first drops the unchanged context lines, then collapses the largest files
+// to a stat summary and finally leaves out the remaining files from the end.
+// The returned report lists what was removed, so it can be shown to the user.
+func TrimDiff(diff string, maxTokens int, count func(string) int) (string, TrimReport) {
+	var report TrimReport
+	if count(diff) <= maxTokens {
+		return diff, report
+	}
+
+	preamble, files := parseDiff(diff)
+	render := func() string {
+		var sb strings.Builder
+		sb.WriteString(preamble)
+		for _, f := range files {
+			sb.WriteString(f.String())
+		}
+		return sb.String()
+	}
+
+	// Step 1: drop the unchanged context lines of every file. Every file is
+	// counted once here, the running total is then updated as files are
+	// collapsed or left out, so huge diffs are not tokenized over and over.
+	total := count(preamble)
+	for _, f := range files {
+		f.dropContext()
+		f.tokens = count(f.String())
+		total += f.tokens
+	}
+	report.ContextDropped = true
+	if total <= maxTokens {
+		return render(), report
+	}
+
+	// Step 2: collapse the largest files to a stat summary.
+	bySize := slices.Clone(files)
+	slices.SortStableFunc(bySize, func(a, b *fileDiff) int {
+		return b.tokens - a.tokens
+	})
+	for _, f := range bySize {
+		f.collapsed = true
+		collapsed := count(f.String())
+		total += collapsed - f.tokens
+		f.tokens = collapsed
+		report.Collapsed = append(report.Collapsed, f.name)
+		if total <= maxTokens {
+			return render(), report
+		}
+	}
+
+	// Step 3: leave out files from the end until the summaries fit.
+	for len(files) > 0 && total > maxTokens {
+		last := files[len(files)-1]
+		files = files[:len(files)-1]
+		total -= last.tokens
+		report.Omitted = append(report.Omitted, last.name)
+		report.Collapsed = slices.DeleteFunc(report.Collapsed, func(name string) bool {
+			return name == last.name
+		})
+	}
+	return render(), report
+}
+
+// FileChange is the diff of a single changed file.
+type FileChange struct {
+	Name string
+	Diff string
+}
+
+// SplitDiff splits the output of git diff into one FileChange per file,
+// in the order git reported them. Text before the first file header is dropped.
+func SplitDiff(diff string) []FileChange {
+	_, files := parseDiff(diff)
+	changes := make([]FileChange, 0, len(files))
+	for _, f := range files {
+		changes = append(changes, FileChange{Name: f.name, Diff: f.String()})
+	}
+	return changes
+}
@@ -0,0 +1,147 @@
+package git
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const budgetDiff = `diff --git a/main.go b/main.go
+index 83db48f..bf269f4 100644
+--- a/main.go
++++ b/main.go
+@@ -1,5 +1,5 @@
+ package main
+ 
+-func old() {}
++func updated() {}
+ // context line
+ // context line
+diff --git a/big.go b/big.go
+index 83db48f..bf269f4 100644
+--- a/big.go
++++ b/big.go
+@@ -1,3 +1,6 @@
+ package big
++var a = 1
++var b = 2
++var c = 3
++var d = 4
++var e = 5
+`
+
+// countBytes counts one token per byte, which keeps the tests predictable.
+func countBytes(s string) int {
+	return len(s)
+}
+
+func TestTrimDiffFits(t *testing.T) {
+	out, report := TrimDiff(budgetDiff, len(budgetDiff), countBytes)
+	if out != budgetDiff {
+		t.Errorf("diff within budget should not change, got:\n%s", out)
+	}
+	if report.Trimmed() {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
+
+func TestTrimDiffDropsContext(t *testing.T) {
+	out, report := TrimDiff(budgetDiff, len(budgetDiff)-10, countBytes)
+	if !report.ContextDropped || len(report.Collapsed) != 0 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if strings.Contains(out, "// context line") {
+		t.Errorf("context lines should be dropped, got:\n%s", out)
+	}
+	if !strings.Contains(out, "+func updated() {}") || !strings.Contains(out, "-func old() {}") {
+		t.Errorf("changed lines should be kept, got:\n%s", out)
+	}
+}
+
+func TestTrimDiffCollapsesLargestFile(t *testing.T) {
+	out, report := TrimDiff(budgetDiff, 260, countBytes)
+	if !slices.Equal(report.Collapsed, []string{"big.go"}) || len(report.Omitted) != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if strings.Contains(out, "+var a = 1") {
+		t.Errorf("big.go should be collapsed, got:\n%s", out)
+	}
+	if !strings.Contains(out, " big.go | 5 additions(+), 0 deletions(-)") {
+		t.Errorf("missing stat summary, got:\n%s", out)
+	}
+	if !strings.Contains(out, "+func updated() {}") {
+		t.Errorf("main.go should be kept, got:\n%s", out)
+	}
+	if len(out) > 260 {
+		t.Errorf("trimmed diff has %d tokens, want at most 260", len(out))
+	}
+}
+
+func TestTrimDiffOmitsFiles(t *testing.T) {
+	out, report := TrimDiff(budgetDiff, 120, countBytes)
+	if !slices.Equal(report.Omitted, []string{"big.go"}) {
+		t.Errorf("unexpected omitted files: %+v", report)
+	}
+	if !slices.Equal(report.Collapsed, []string{"main.go"}) {
+		t.Errorf("unexpected collapsed files: %+v", report)
+	}
+	if strings.Contains(out, "big.go") || len(out) > 120 {
+		t.Errorf("unexpected trimmed diff:\n%s", out)
+	}
+}
+
+func TestTrimDiffCountsFilesOnce(t *testing.T) {
+	var sb strings.Builder
+	const files = 200
+	for i := range files {
+		fmt.Fprintf(&sb, "diff --git a/f%d.go b/f%d.go\n--- a/f%d.go\n+++ b/f%d.go\n@@ -1 +1 @@\n-old\n+new\n",
+			i, i, i, i)
+	}
+
+	calls := 0
+	count := func(s string) int {
+		calls++
+		return len(s)
+	}
+	_, report := TrimDiff(sb.String(), 10, count)
+	if len(report.Omitted) != files {
+		t.Fatalf("expected every file to be left out, got %+v", report)
+	}
+	// The whole diff and the preamble, then each file before and after collapsing
+	if want := 2 + 2*files; calls > want {
+		t.Errorf("count called %d times, want at most %d", calls, want)
+	}
+}
+
+func TestParseDiff(t *testing.T) {
+	_, files := parseDiff(budgetDiff)
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(files))
+	}
+	if files[0].name != "main.go" || files[0].additions != 1 || files[0].deletions != 1 {
+		t.Errorf("unexpected first file: %+v", files[0])
+	}
+	if files[1].name != "big.go" || files[1].additions != 5 {
+		t.Errorf("unexpected second file: %+v", files[1])
+	}
+	if got := files[0].String() + files[1].String(); got != budgetDiff {
+		t.Errorf("rendering the parsed diff should round trip, got:\n%s", got)
+	}
+}
+
+func TestSplitDiff(t *testing.T) {
+	changes := SplitDiff(budgetDiff)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(changes))
+	}
+	if changes[0].Name != "main.go" || !strings.HasPrefix(changes[0].Diff, "diff --git a/main.go") {
+		t.Errorf("unexpected first change: %+v", changes[0])
+	}
+	if changes[1].Name != "big.go" || !strings.HasSuffix(changes[1].Diff, "+var e = 5\n") {
+		t.Errorf("unexpected second change: %+v", changes[1])
+	}
+	if len(SplitDiff("")) != 0 {
+		t.Error("empty diff should have no changes")
+	}
+}
@@ -3,6 +3,7 @@ package git
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path"
@@ -21,11 +22,26 @@ var excludeFromDiff = []string{
 	"go.sum",
 }
 
+// Commit is a commit listed by Commits.
+type Commit struct {
+	Hash    string
+	Subject string
+	Body    string
+}
+
 type Command struct {
 	// Generate diffs with <n> lines of context instead of the usual three
 	diffUnified int
 	excludeList []string
 	isAmend     bool
+	source      DiffSource
+	pathspecs   []string
+	noVerify    bool
+	signoff     bool
+	gpgSign     bool
+	signingKey  string
+	author      string
+	allowEmpty  bool
 }
 
 // excludeFiles returns a list of files to be excluded from git operations.
@@ -38,22 +54,23 @@ func (c *Command) excludeFiles() []string {
 	return excludedFiles
 }
 
+// diffTarget returns the arguments selecting the diff source, followed by the
+// pathspecs and excluded files.
+func (c *Command) diffTarget() []string {
+	args := append(c.source.args(), "--")
+	args = append(args, c.pathspecs...)
+	return append(args, c.excludeFiles()...)
+}
+
 // diffNames generates the git command to list the names of changed files.
-// It includes options to handle amended commits and staged changes.
+// The changes are selected by the diff source and narrowed by the pathspecs.
 func (c *Command) diffNames(ctx context.Context) *exec.Cmd {
 	args := []string{
 		"diff",
 		"--name-only",
 	}
 
-	if c.isAmend {
-		args = append(args, "HEAD^", "HEAD")
-	} else {
-		args = append(args, "--staged")
-	}
-
-	excludedFiles := c.excludeFiles()
-	args = append(args, excludedFiles...)
+	args = append(args, c.diffTarget()...)
 
 	return exec.CommandContext(
 		ctx,
@@ -73,14 +90,46 @@ func (c *Command) diffFiles(ctx context.Context) *exec.Cmd {
 		"--unified=" + strconv.Itoa(c.diffUnified),
 	}
 
-	if c.isAmend {
-		args = append(args, "HEAD^", "HEAD")
-	} else {
-		args = append(args, "--staged")
+	args = append(args, c.diffTarget()...)
+
+	return exec.CommandContext(
+		ctx,
+		"git",
+		args...,
+	)
+}
+
+// log generates the git command to list the messages of the commits behind the
+// diff source, oldest first, as a bullet list with indented bodies.
+func (c *Command) log(ctx context.Context, revisions []string) *exec.Cmd {
+	args := []string{
+		"log",
+		"--no-merges",
+		"--reverse",
+		"--format=- %s%n%w(0,2,2)%b",
 	}
+	args = append(args, revisions...)
+	args = append(args, "--")
+	args = append(args, c.pathspecs...)
+
+	return exec.CommandContext(
+		ctx,
+		"git",
+		args...,
+	)
+}
 
-	excludedFiles := c.excludeFiles()
-	args = append(args, excludedFiles...)
+// commits generates the git command to list the commits behind the diff source,
+// newest first, with fields separated by unit separators and records by record separators.
+func (c *Command) commits(ctx context.Context, revisions []string) *exec.Cmd {
+	args := []string{
+		"log",
+		"--no-merges",
+		"--format=%H%x1f%s%x1f%b%x1e",
+	}
+	args = append(args, revisions...)
+	args = append(args, "--")
+	args = append(args, c.pathspecs...)
 
 	return exec.CommandContext(
 		ctx,
@@ -89,6 +138,44 @@ func (c *Command) diffFiles(ctx context.Context) *exec.Cmd {
 	)
 }
 
+// latestTag generates the git command to find the most recent tag reachable from rev.
+func (c *Command) latestTag(ctx context.Context, rev string) *exec.Cmd {
+	return exec.CommandContext(
+		ctx,
+		"git",
+		"describe",
+		"--tags",
+		"--abbrev=0",
+		rev,
+	)
+}
+
+// tags generates the git command to list the tags reachable from HEAD.
+func (c *Command) tags(ctx context.Context) *exec.Cmd {
+	return exec.CommandContext(
+		ctx,
+		"git",
+		"tag",
+		"--list",
+		"--merged",
+		"HEAD",
+	)
+}
+
+// tag generates the git command to create an annotated tag on HEAD.
+// The message is kept verbatim, so Markdown headings are not mistaken for comments.
+func (c *Command) tag(ctx context.Context, name, message string) *exec.Cmd {
+	return exec.CommandContext(
+		ctx,
+		"git",
+		"tag",
+		"--annotate",
+		"--cleanup=verbatim",
+		"--message="+message,
+		name,
+	)
+}
+
 // hookPath generates the git command to get the path of the hooks directory.
 // This is used to locate where git hooks are stored.
 func (c *Command) hookPath(ctx context.Context) *exec.Cmd {
@@ -120,6 +207,16 @@ func (c *Command) gitDir(ctx context.Context) *exec.Cmd {
 	)
 }
 
+// topLevel generates the git command to get the path of the top-level directory of the working tree.
+func (c *Command) topLevel(ctx context.Context) *exec.Cmd {
+	return exec.CommandContext(
+		ctx,
+		"git",
+		"rev-parse",
+		"--show-toplevel",
+	)
+}
+
 // checkGitRepository generates the git command to check if the current directory is a git repository.
 func (c *Command) checkGitRepository(ctx context.Context) *exec.Cmd {
 	return exec.CommandContext(
@@ -131,15 +228,38 @@ func (c *Command) checkGitRepository(ctx context.Context) *exec.Cmd {
 }
 
 // commit generates the git command to create a commit with the provided message.
-// It includes options to skip pre-commit hooks, sign off the commit, and handle amendments.
+// It includes options to skip pre-commit hooks, sign off or sign the commit, override
+// the author, allow empty commits, and handle amendments.
 func (c *Command) commit(ctx context.Context, val string) *exec.Cmd {
 	args := []string{
 		"commit",
-		"--no-verify",
-		"--signoff",
-		"--message=" + val,
 	}
 
+	if c.noVerify {
+		args = append(args, "--no-verify")
+	}
+
+	if c.signoff {
+		args = append(args, "--signoff")
+	}
+
+	switch {
+	case c.signingKey != "":
+		args = append(args, "--gpg-sign="+c.signingKey)
+	case c.gpgSign:
+		args = append(args, "--gpg-sign")
+	}
+
+	if c.author != "" {
+		args = append(args, "--author="+c.author)
+	}
+
+	if c.allowEmpty {
+		args = append(args, "--allow-empty")
+	}
+
+	args = append(args, "--message="+val)
+
 	if c.isAmend {
 		args = append(args, "--amend")
 	}
@@ -154,9 +274,10 @@ func (c *Command) commit(ctx context.Context, val string) *exec.Cmd {
 // Commit creates a git commit with the provided message and returns the output or an error.
 // It uses the commit method to generate the git command and execute it.
 func (c *Command) Commit(ctx context.Context, val string) (string, error) {
-	output, err := c.commit(ctx, val).Output()
+	output, err := c.commit(ctx, val).CombinedOutput()
 	if err != nil {
-		return "", err
+		// Keep the output of failing hooks or signing
+		return "", fmt.Errorf("failed to commit: %s", strings.TrimSpace(string(output)))
 	}
 
 	return string(output), nil
@@ -172,6 +293,16 @@ func (c *Command) GitDir(ctx context.Context) (string, error) {
 	return string(output), nil
 }
 
+// TopLevel returns the absolute path of the top-level directory of the working tree.
+func (c *Command) TopLevel(ctx context.Context) (string, error) {
+	output, err := c.topLevel(ctx).Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
 // CanExecuteGitDiff checks if git diff can be executed in the current directory.
 // It returns an error if the current directory is not a git repository or if git diff cannot be executed.
 func (c *Command) CanExecuteGitDiff(ctx context.Context) error {
@@ -192,14 +323,17 @@ func (c *Command) CanExecuteGitDiff(ctx context.Context) error {
 // If there are no differences, it returns an empty string and an error.
 // DiffFiles compares the differences between two sets of data and returns the differences as a string and an error.
 // It first lists the names of changed files and then shows the differences between them.
-// If there are no staged changes, it returns an error message.
+// If the diff source has no changes, it returns an error message.
 func (c *Command) DiffFiles(ctx context.Context) (string, error) {
 	output, err := c.diffNames(ctx).Output()
 	if err != nil {
 		return "", err
 	}
 	if string(output) == "" {
-		return "", errors.New("please add your staged changes using git add <files...>")
+		if _, ok := c.source.(stagedSource); ok {
+			return "", errors.New("please add your staged changes using git add <files...>")
+		}
+		return "", fmt.Errorf("no changes found in %s", c.source)
 	}
 
 	output, err = c.diffFiles(ctx).Output()
@@ -210,6 +344,96 @@ func (c *Command) DiffFiles(ctx context.Context) (string, error) {
 	return string(output), nil
 }
 
+// ChangedFiles returns the names of the files changed in the diff source, narrowed by
+// the pathspecs and without the excluded files.
+func (c *Command) ChangedFiles(ctx context.Context) ([]string, error) {
+	output, err := c.diffNames(ctx).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(string(output)), nil
+}
+
+// CommitLog returns the messages of the commits behind the diff source, oldest first.
+// It returns an error for sources that are not committed, such as staged changes, or
+// when there are no commits.
+func (c *Command) CommitLog(ctx context.Context) (string, error) {
+	revisions := c.source.revisions()
+	if revisions == nil {
+		return "", fmt.Errorf("%s have no commit log", c.source)
+	}
+
+	output, err := c.log(ctx, revisions).Output()
+	if err != nil {
+		return "", err
+	}
+	commitLog := strings.TrimSpace(string(output))
+	if commitLog == "" {
+		return "", fmt.Errorf("no commits found in %s", c.source)
+	}
+
+	return commitLog, nil
+}
+
+// Commits returns the commits behind the diff source, newest first.
+// It returns an error for sources that are not committed, such as staged changes.
+func (c *Command) Commits(ctx context.Context) ([]Commit, error) {
+	revisions := c.source.revisions()
+	if revisions == nil {
+		return nil, fmt.Errorf("%s have no commits", c.source)
+	}
+
+	output, err := c.commits(ctx, revisions).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.SplitN(strings.TrimSpace(record), "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Subject: fields[1],
+			Body:    strings.TrimSpace(fields[2]),
+		})
+	}
+	return commits, nil
+}
+
+// LatestTag returns the most recent tag reachable from rev.
+func (c *Command) LatestTag(ctx context.Context, rev string) (string, error) {
+	output, err := c.latestTag(ctx, rev).Output()
+	if err != nil {
+		return "", fmt.Errorf("no tag found before %s", rev)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Tags returns the names of the tags reachable from HEAD.
+func (c *Command) Tags(ctx context.Context) ([]string, error) {
+	output, err := c.tags(ctx).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(string(output)), nil
+}
+
+// Tag creates an annotated tag on HEAD with the given message.
+func (c *Command) Tag(ctx context.Context, name, message string) error {
+	output, err := c.tag(ctx, name, message).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to create tag %s: %s", name, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
 // InstallHook installs the prepare-commit-msg hook if it doesn't already exist.
 // It retrieves the hooks directory path, checks if the hook file exists, and writes the hook file with executable permissions.
 func (c *Command) InstallHook(ctx context.Context) error {
@@ -263,7 +487,10 @@ func (c *Command) UninstallHook(ctx context.Context) error {
 // It applies each option to the config object and initializes the Command object with the configurations.
 func New(opts ...Option) *Command {
 	// Instantiate a new config object with default values
-	cfg := &config{}
+	cfg := &config{
+		noVerify: true,
+		signoff:  true,
+	}
 
 	// Loop through each option passed as argument and apply it to the config object
 	for _, o := range opts {
@@ -276,6 +503,22 @@ func New(opts ...Option) *Command {
 		// Append the user-defined excludeList to the default excludeFromDiff
 		excludeList: append(excludeFromDiff, cfg.excludeList...),
 		isAmend:     cfg.isAmend,
+		source:      cfg.source,
+		pathspecs:   cfg.pathspecs,
+		noVerify:    cfg.noVerify,
+		signoff:     cfg.signoff,
+		gpgSign:     cfg.gpgSign,
+		signingKey:  cfg.signingKey,
+		author:      cfg.author,
+		allowEmpty:  cfg.allowEmpty,
+	}
+
+	// Diff the last commit when amending, and the staged changes otherwise
+	if cmd.source == nil {
+		cmd.source = Staged()
+		if cmd.isAmend {
+			cmd.source = LastCommit()
+		}
 	}
 
 	return cmd
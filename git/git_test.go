@@ -3,7 +3,9 @@ package git
 import (
 	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -65,3 +67,263 @@ func TestCanExecuteGitDiff(t *testing.T) {
 		}
 	})
 }
+
+func TestTopLevel(t *testing.T) {
+	cmd := New()
+	ctx := context.Background()
+
+	dir, err := cmd.TopLevel(ctx)
+	if err != nil {
+		t.Fatalf("TopLevel() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil {
+		t.Errorf("TopLevel() = %q, expected the repository root", dir)
+	}
+
+	t.Chdir(t.TempDir())
+	if _, err := cmd.TopLevel(ctx); err == nil {
+		t.Error("TopLevel() should fail in a non-git directory")
+	}
+}
+
+// setupDiffRepo creates a repository with a feature branch forked from main, one
+// staged and one unstaged change, and changes into it.
+func setupDiffRepo(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "--initial-branch=main")
+	run("config", "user.name", "Test")
+	run("config", "user.email", "test@example.com")
+	write("README.md", "hello\n")
+	run("add", ".")
+	run("commit", "-m", "initial")
+
+	run("checkout", "-b", "feature")
+	write("core/core.go", "package core\n")
+	write("cmd/cmd.go", "package cmd\n")
+	run("add", ".")
+	run("commit", "-m", "add packages")
+
+	write("core/staged.go", "package core\n")
+	run("add", "core/staged.go")
+	write("README.md", "hello world\n")
+}
+
+func TestDiffFilesSources(t *testing.T) {
+	setupDiffRepo(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    []string
+		notWant []string
+		wantErr string
+	}{
+		{
+			name:    "staged by default",
+			want:    []string{"core/staged.go"},
+			notWant: []string{"README.md", "core/core.go"},
+		},
+		{
+			name:    "unstaged",
+			opts:    []Option{WithDiffSource(Unstaged())},
+			want:    []string{"README.md"},
+			notWant: []string{"core/staged.go"},
+		},
+		{
+			name:    "last commit when amending",
+			opts:    []Option{WithEnableAmend(true)},
+			want:    []string{"core/core.go", "cmd/cmd.go"},
+			notWant: []string{"core/staged.go"},
+		},
+		{
+			name:    "base",
+			opts:    []Option{WithDiffSource(Base("main"))},
+			want:    []string{"core/core.go", "cmd/cmd.go"},
+			notWant: []string{"README.md", "core/staged.go"},
+		},
+		{
+			name:    "range with pathspec",
+			opts:    []Option{WithDiffSource(Range("main..feature")), WithPathspecs([]string{"core"})},
+			want:    []string{"core/core.go"},
+			notWant: []string{"cmd/cmd.go"},
+		},
+		{
+			name:    "empty range",
+			opts:    []Option{WithDiffSource(Range("feature..feature"))},
+			wantErr: "no changes found in revision range feature..feature",
+		},
+		{
+			name:    "nothing staged in pathspec",
+			opts:    []Option{WithPathspecs([]string{"cmd"})},
+			wantErr: "please add your staged changes using git add <files...>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := New(tt.opts...).DiffFiles(ctx)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("DiffFiles() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DiffFiles() error = %v", err)
+			}
+			for _, name := range tt.want {
+				if !strings.Contains(diff, "b/"+name) {
+					t.Errorf("diff does not include %s:\n%s", name, diff)
+				}
+			}
+			for _, name := range tt.notWant {
+				if strings.Contains(diff, "b/"+name) {
+					t.Errorf("diff includes %s:\n%s", name, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestCommitLog(t *testing.T) {
+	setupDiffRepo(t)
+	ctx := context.Background()
+
+	log, err := New(WithDiffSource(Base("main"))).CommitLog(ctx)
+	if err != nil {
+		t.Fatalf("CommitLog() error = %v", err)
+	}
+	if log != "- add packages" {
+		t.Errorf("CommitLog() = %q, want %q", log, "- add packages")
+	}
+
+	if _, err := New(WithDiffSource(Range("feature..feature"))).CommitLog(ctx); err == nil ||
+		err.Error() != "no commits found in revision range feature..feature" {
+		t.Errorf("CommitLog() error = %v, want no commits found", err)
+	}
+	if _, err := New().CommitLog(ctx); err == nil {
+		t.Error("CommitLog() should fail for staged changes")
+	}
+}
+
+func TestCommitsAndLatestTag(t *testing.T) {
+	setupDiffRepo(t)
+	ctx := context.Background()
+
+	if _, err := New().LatestTag(ctx, "HEAD"); err == nil {
+		t.Error("LatestTag() should fail without a tag")
+	}
+	if out, err := exec.Command("git", "tag", "v1.0.0", "main").CombinedOutput(); err != nil {
+		t.Fatalf("git tag: %v\n%s", err, out)
+	}
+	tag, err := New().LatestTag(ctx, "HEAD")
+	if err != nil || tag != "v1.0.0" {
+		t.Fatalf("LatestTag() = %q, %v, want v1.0.0", tag, err)
+	}
+
+	commits, err := New(WithDiffSource(Range(tag + "..HEAD"))).Commits(ctx)
+	if err != nil {
+		t.Fatalf("Commits() error = %v", err)
+	}
+	if len(commits) != 1 || commits[0].Subject != "add packages" || len(commits[0].Hash) != 40 {
+		t.Errorf("Commits() = %+v, want the add packages commit", commits)
+	}
+
+	commits, err = New(WithDiffSource(Range("HEAD"))).Commits(ctx)
+	if err != nil || len(commits) != 2 || commits[1].Subject != "initial" {
+		t.Errorf("Commits() = %+v, %v, want all commits newest first", commits, err)
+	}
+}
+
+func TestTag(t *testing.T) {
+	setupDiffRepo(t)
+	ctx := context.Background()
+	cmd := New()
+
+	message := "## v1.0.0\n\n- add packages\n"
+	if err := cmd.Tag(ctx, "v1.0.0", message); err != nil {
+		t.Fatalf("Tag() error = %v", err)
+	}
+	if err := cmd.Tag(ctx, "v1.0.0", message); err == nil {
+		t.Error("Tag() should fail for an existing tag")
+	}
+
+	out, err := exec.Command("git", "tag", "--list", "--format=%(objecttype) %(contents)", "v1.0.0").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "tag "+strings.TrimSpace(message) {
+		t.Errorf("tag = %q, want an annotated tag with the verbatim message", got)
+	}
+
+	tags, err := cmd.Tags(ctx)
+	if err != nil || len(tags) != 1 || tags[0] != "v1.0.0" {
+		t.Errorf("Tags() = %v, %v, want [v1.0.0]", tags, err)
+	}
+}
+
+func TestCommitArgs(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{
+			name: "defaults",
+			want: []string{"git", "commit", "--no-verify", "--signoff", "--message=msg"},
+		},
+		{
+			name: "hooks without sign-off",
+			opts: []Option{WithNoVerify(false), WithSignoff(false)},
+			want: []string{"git", "commit", "--message=msg"},
+		},
+		{
+			name: "signed with author",
+			opts: []Option{WithGPGSign(true), WithAuthor("Jane Doe <jane@example.com>"), WithAllowEmpty(true)},
+			want: []string{
+				"git", "commit", "--no-verify", "--signoff", "--gpg-sign",
+				"--author=Jane Doe <jane@example.com>", "--allow-empty", "--message=msg",
+			},
+		},
+		{
+			name: "signing key and amend",
+			opts: []Option{WithSigningKey("ABCD1234"), WithEnableAmend(true), WithSignoff(false)},
+			want: []string{"git", "commit", "--no-verify", "--gpg-sign=ABCD1234", "--message=msg", "--amend"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(tt.opts...).commit(ctx, "msg").Args
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("commit args = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
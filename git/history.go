@@ -0,0 +1,64 @@
+package git
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// hasCommits generates the git command to check whether HEAD points to a commit.
+func (c *Command) hasCommits(ctx context.Context) *exec.Cmd {
+	return exec.CommandContext(
+		ctx,
+		"git",
+		"rev-parse",
+		"--verify",
+		"--quiet",
+		"HEAD",
+	)
+}
+
+// recentMessages generates the git command to list the messages of the last n
+// non-merge commits touching the pathspecs, separated by record separators.
+func (c *Command) recentMessages(ctx context.Context, n int, author string) *exec.Cmd {
+	args := []string{
+		"log",
+		"--no-merges",
+		"--max-count=" + strconv.Itoa(n),
+		"--format=%B%x1e",
+	}
+	if author != "" {
+		args = append(args, "--author="+author)
+	}
+	args = append(args, "HEAD", "--")
+	args = append(args, c.pathspecs...)
+
+	return exec.CommandContext(
+		ctx,
+		"git",
+		args...,
+	)
+}
+
+// RecentMessages returns the messages of the last n non-merge commits, newest first,
+// limited to commits by author when set and touching the pathspecs. It returns
+// nothing before the first commit.
+func (c *Command) RecentMessages(ctx context.Context, n int, author string) ([]string, error) {
+	if err := c.hasCommits(ctx).Run(); err != nil {
+		return nil, nil //nolint:nilerr // no commits yet
+	}
+
+	output, err := c.recentMessages(ctx, n, author).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []string
+	for record := range strings.SplitSeq(string(output), "\x1e") {
+		if message := strings.TrimSpace(record); message != "" {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
@@ -0,0 +1,47 @@
+package git
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+)
+
+func TestRecentMessages(t *testing.T) {
+	setupDiffRepo(t)
+	ctx := context.Background()
+
+	messages, err := New().RecentMessages(ctx, 5, "")
+	if err != nil {
+		t.Fatalf("RecentMessages() error = %v", err)
+	}
+	if len(messages) != 2 || messages[0] != "add packages" || messages[1] != "initial" {
+		t.Errorf("RecentMessages() = %q, want the commits newest first", messages)
+	}
+
+	messages, err = New().RecentMessages(ctx, 1, "")
+	if err != nil || len(messages) != 1 {
+		t.Errorf("RecentMessages() = %q, %v, want one message", messages, err)
+	}
+
+	messages, err = New(WithPathspecs([]string{"cmd"})).RecentMessages(ctx, 5, "")
+	if err != nil || len(messages) != 1 || messages[0] != "add packages" {
+		t.Errorf("RecentMessages() = %q, %v, want only the commit touching cmd", messages, err)
+	}
+
+	messages, err = New().RecentMessages(ctx, 5, "nobody@example.com")
+	if err != nil || len(messages) != 0 {
+		t.Errorf("RecentMessages() = %q, %v, want no commits by another author", messages, err)
+	}
+}
+
+func TestRecentMessagesWithoutCommits(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if out, err := exec.Command("git", "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+
+	messages, err := New().RecentMessages(context.Background(), 5, "")
+	if err != nil || len(messages) != 0 {
+		t.Errorf("RecentMessages() = %q, %v, want nothing before the first commit", messages, err)
+	}
+}
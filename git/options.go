@@ -42,9 +42,79 @@ func WithEnableAmend(val bool) Option {
 	})
 }
 
+// WithDiffSource returns an Option that sets the changes to diff, overriding the
+// default of staged changes, or the last commit when amending.
+func WithDiffSource(val DiffSource) Option {
+	return optionFunc(func(c *config) {
+		c.source = val
+	})
+}
+
+// WithPathspecs returns an Option that limits the diff to the given pathspecs.
+func WithPathspecs(val []string) Option {
+	return optionFunc(func(c *config) {
+		c.pathspecs = val
+	})
+}
+
+// WithNoVerify returns an Option that sets whether commits skip the pre-commit and
+// commit-msg hooks, which is the default.
+func WithNoVerify(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.noVerify = val
+	})
+}
+
+// WithSignoff returns an Option that sets whether commits get a Signed-off-by
+// trailer, which is the default.
+func WithSignoff(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.signoff = val
+	})
+}
+
+// WithGPGSign returns an Option that sets whether commits are signed with the
+// default GPG or SSH key of the committer.
+func WithGPGSign(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.gpgSign = val
+	})
+}
+
+// WithSigningKey returns an Option that signs commits with the given key ID,
+// which implies WithGPGSign.
+func WithSigningKey(val string) Option {
+	return optionFunc(func(c *config) {
+		c.signingKey = val
+	})
+}
+
+// WithAuthor returns an Option that overrides the commit author, given as
+// "Name <email>".
+func WithAuthor(val string) Option {
+	return optionFunc(func(c *config) {
+		c.author = val
+	})
+}
+
+// WithAllowEmpty returns an Option that sets whether commits without changes are allowed.
+func WithAllowEmpty(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.allowEmpty = val
+	})
+}
+
 // config is a struct that stores configuration options for the instrumentation.
 type config struct {
 	diffUnified int
 	excludeList []string
 	isAmend     bool
+	source      DiffSource
+	pathspecs   []string
+	noVerify    bool
+	signoff     bool
+	gpgSign     bool
+	signingKey  string
+	author      string
+	allowEmpty  bool
 }
@@ -0,0 +1,71 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PatchLine is a line of the hunks of a single file patch.
+type PatchLine struct {
+	// Position counts the lines below the first @@ line of the patch, starting
+	// at 1 and including the following @@ lines, as used by GitHub review comments.
+	Position int
+	// OldLine is the line number in the old version of the file, 0 for added lines.
+	OldLine int
+	// NewLine is the line number in the new version of the file, 0 for removed lines.
+	NewLine int
+}
+
+// PatchLines returns the added, removed and context lines of the hunks of a
+// single file patch, i.e. the part of a diff starting at its first @@ line.
+// Lines before the first @@ line, such as the file header, are skipped.
+func PatchLines(patch string) []PatchLine {
+	var (
+		lines            []PatchLine
+		started          bool
+		position         int
+		oldLine, newLine int
+	)
+	for line := range strings.SplitSeq(patch, "\n") {
+		if strings.HasPrefix(line, "@@") {
+			if started {
+				position++
+			}
+			started = true
+			oldLine, newLine = hunkStart(line)
+			continue
+		}
+		if !started || line == "" {
+			continue
+		}
+
+		position++
+		switch line[0] {
+		case '+':
+			lines = append(lines, PatchLine{Position: position, NewLine: newLine})
+			newLine++
+		case '-':
+			lines = append(lines, PatchLine{Position: position, OldLine: oldLine})
+			oldLine++
+		case ' ':
+			lines = append(lines, PatchLine{Position: position, OldLine: oldLine, NewLine: newLine})
+			oldLine++
+			newLine++
+		}
+		// "\ No newline at end of file" has a position but no line
+	}
+	return lines
+}
+
+// hunkStart returns the first old and new line numbers of a hunk header such
+// as "@@ -1,4 +1,6 @@ func main() {". Single line ranges omit the count, e.g.
+// "@@ -1 +1 @@".
+func hunkStart(header string) (oldLine, newLine int) {
+	ranges, _, _ := strings.Cut(strings.TrimPrefix(header, "@@ "), " @@")
+	oldRange, newRange, _ := strings.Cut(ranges, " ")
+	oldStart, _, _ := strings.Cut(strings.TrimPrefix(oldRange, "-"), ",")
+	newStart, _, _ := strings.Cut(strings.TrimPrefix(newRange, "+"), ",")
+	oldLine, _ = strconv.Atoi(oldStart)
+	newLine, _ = strconv.Atoi(newStart)
+	return oldLine, newLine
+}
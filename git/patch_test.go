@@ -0,0 +1,51 @@
+package git
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPatchLines(t *testing.T) {
+	patch := `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
+-import "fmt"
++import (
++	"fmt"
+@@ -10 +11,2 @@ func main() {
+ 	fmt.Println()
++	os.Exit(0)
+\ No newline at end of file`
+
+	want := []PatchLine{
+		{Position: 1, OldLine: 1, NewLine: 1},
+		{Position: 2, OldLine: 2},
+		{Position: 3, NewLine: 2},
+		{Position: 4, NewLine: 3},
+		{Position: 6, OldLine: 10, NewLine: 11},
+		{Position: 7, NewLine: 12},
+	}
+	if got := PatchLines(patch); !slices.Equal(got, want) {
+		t.Errorf("PatchLines() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestHunkStart(t *testing.T) {
+	tests := []struct {
+		header           string
+		oldLine, newLine int
+	}{
+		{"@@ -1,4 +1,6 @@ func main() {", 1, 1},
+		{"@@ -12 +14 @@", 12, 14},
+		{"@@ -0,0 +1,3 @@", 0, 1},
+		{"@@ -5,2 +0,0 @@", 5, 0},
+	}
+	for _, tt := range tests {
+		oldLine, newLine := hunkStart(tt.header)
+		if oldLine != tt.oldLine || newLine != tt.newLine {
+			t.Errorf("hunkStart(%q) = %d, %d, want %d, %d", tt.header, oldLine, newLine, tt.oldLine, tt.newLine)
+		}
+	}
+}
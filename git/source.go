@@ -0,0 +1,61 @@
+package git
+
+import "fmt"
+
+// DiffSource selects the changes a Command diffs, such as the staged changes or a
+// revision range.
+type DiffSource interface {
+	fmt.Stringer
+	// args returns the git diff arguments that select the changes.
+	args() []string
+	// revisions returns the git log arguments listing the commits behind the
+	// changes, or nil when they are not committed yet.
+	revisions() []string
+}
+
+type stagedSource struct{}
+
+func (stagedSource) args() []string      { return []string{"--staged"} }
+func (stagedSource) revisions() []string { return nil }
+func (stagedSource) String() string      { return "staged changes" }
+
+type unstagedSource struct{}
+
+func (unstagedSource) args() []string      { return nil }
+func (unstagedSource) revisions() []string { return nil }
+func (unstagedSource) String() string      { return "unstaged changes" }
+
+type amendSource struct{}
+
+func (amendSource) args() []string      { return []string{"HEAD^", "HEAD"} }
+func (amendSource) revisions() []string { return []string{"HEAD^..HEAD"} }
+func (amendSource) String() string      { return "the last commit" }
+
+type baseSource struct{ ref string }
+
+func (s baseSource) args() []string      { return []string{s.ref + "...HEAD"} }
+func (s baseSource) revisions() []string { return []string{s.ref + "..HEAD"} }
+func (s baseSource) String() string      { return "changes since " + s.ref }
+
+type rangeSource struct{ rev string }
+
+func (s rangeSource) args() []string      { return []string{s.rev} }
+func (s rangeSource) revisions() []string { return []string{s.rev} }
+func (s rangeSource) String() string      { return "revision range " + s.rev }
+
+// Staged diffs the changes in the index against HEAD. It is the default source.
+func Staged() DiffSource { return stagedSource{} }
+
+// Unstaged diffs the working tree against the index. Untracked files are not included.
+func Unstaged() DiffSource { return unstagedSource{} }
+
+// LastCommit diffs HEAD against its parent, which is what an amended commit replaces.
+func LastCommit() DiffSource { return amendSource{} }
+
+// Base diffs HEAD against its merge base with ref, i.e. everything committed on the
+// current branch since it forked from ref.
+func Base(ref string) DiffSource { return baseSource{ref: ref} }
+
+// Range diffs an arbitrary revision range such as main..feature or v1.0.0...HEAD,
+// passed to git diff as is.
+func Range(rev string) DiffSource { return rangeSource{rev: rev} }
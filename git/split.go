@@ -0,0 +1,183 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// atomicHeaders mark file diffs that cannot be split into hunks, because applying
+// only some of them would create, delete or rename the file more than once.
+var atomicHeaders = []string{
+	"new file mode",
+	"old mode",
+	"deleted file mode",
+	"rename from",
+	"copy from",
+	"GIT binary patch",
+	"Binary files",
+}
+
+// Hunk is an independently applicable part of a patch: a single hunk of a modified
+// file, or the whole diff of a file that cannot be split.
+type Hunk struct {
+	// ID identifies the hunk within its patch, e.g. h1.
+	ID string
+	// File is the name of the changed file.
+	File string
+	// Header is the diff header of the file, shared by all its hunks.
+	Header string
+	// Body is the hunk itself, starting with its @@ line, or empty for header-only diffs.
+	Body string
+}
+
+// Title returns the ID, the file and the @@ line of the hunk, if any.
+func (h Hunk) Title() string {
+	title := h.ID + " " + h.File
+	if line, _, _ := strings.Cut(h.Body, "\n"); strings.HasPrefix(line, "@@") {
+		title += " " + strings.TrimSpace(line)
+	}
+	return title
+}
+
+// SplitPatch splits the output of git diff into hunks, numbered in order.
+func SplitPatch(patch string) []Hunk {
+	_, files := parseDiff(patch)
+
+	var hunks []Hunk
+	add := func(f *fileDiff, body string) {
+		hunks = append(hunks, Hunk{
+			ID:     fmt.Sprintf("h%d", len(hunks)+1),
+			File:   f.name,
+			Header: strings.Join(f.header, ""),
+			Body:   body,
+		})
+	}
+
+	for _, f := range files {
+		if isAtomic(f) {
+			add(f, strings.Join(f.body, ""))
+			continue
+		}
+
+		var body strings.Builder
+		for _, line := range f.body {
+			if strings.HasPrefix(line, "@@") && body.Len() > 0 {
+				add(f, body.String())
+				body.Reset()
+			}
+			body.WriteString(line)
+		}
+		add(f, body.String())
+	}
+	return hunks
+}
+
+// isAtomic reports whether the file diff must be applied as a whole.
+func isAtomic(f *fileDiff) bool {
+	for _, line := range f.header {
+		for _, h := range atomicHeaders {
+			if strings.HasPrefix(line, h) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BuildPatch joins hunks into a patch, writing the header of every file once and
+// its hunks in the order given.
+func BuildPatch(hunks []Hunk) string {
+	var (
+		files  []string
+		byFile = map[string][]Hunk{}
+	)
+	for _, h := range hunks {
+		if _, ok := byFile[h.File]; !ok {
+			files = append(files, h.File)
+		}
+		byFile[h.File] = append(byFile[h.File], h)
+	}
+
+	var sb strings.Builder
+	for _, name := range files {
+		sb.WriteString(byFile[name][0].Header)
+		for _, h := range byFile[name] {
+			sb.WriteString(h.Body)
+		}
+	}
+	return sb.String()
+}
+
+// stagedPatch generates the git command to print the staged changes as a patch that
+// git apply accepts, unlike the output of diffFiles.
+func (c *Command) stagedPatch(ctx context.Context) *exec.Cmd {
+	return exec.CommandContext(
+		ctx,
+		"git",
+		"diff",
+		"--staged",
+		"--binary",
+		"--no-color",
+		"--no-ext-diff",
+	)
+}
+
+// resetIndex generates the git command to unstage all changes, keeping the working tree.
+func (c *Command) resetIndex(ctx context.Context) *exec.Cmd {
+	return exec.CommandContext(
+		ctx,
+		"git",
+		"reset",
+		"--quiet",
+	)
+}
+
+// applyCached generates the git command to apply a patch read from stdin to the index.
+func (c *Command) applyCached(ctx context.Context) *exec.Cmd {
+	return exec.CommandContext(
+		ctx,
+		"git",
+		"apply",
+		"--cached",
+		"--whitespace=nowarn",
+		"-",
+	)
+}
+
+// StagedPatch returns the staged changes as a patch, including binary files.
+func (c *Command) StagedPatch(ctx context.Context) (string, error) {
+	output, err := c.stagedPatch(ctx).Output()
+	if err != nil {
+		return "", err
+	}
+	if len(output) == 0 {
+		return "", errors.New("please add your staged changes using git add <files...>")
+	}
+
+	return string(output), nil
+}
+
+// ResetIndex unstages all changes. The working tree is left untouched.
+func (c *Command) ResetIndex(ctx context.Context) error {
+	output, err := c.resetIndex(ctx).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to unstage changes: %s", strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// ApplyCached applies patch to the index, staging the changes it contains.
+func (c *Command) ApplyCached(ctx context.Context, patch string) error {
+	cmd := c.applyCached(ctx)
+	cmd.Stdin = strings.NewReader(patch)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to stage patch: %s", strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
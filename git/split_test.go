@@ -0,0 +1,84 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestSplitPatch(t *testing.T) {
+	setupDiffRepo(t)
+	ctx := context.Background()
+	g := New()
+
+	git := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+
+	// Commit a long file, then change both of its ends and add a new file
+	var lines []string
+	for i := 1; i <= 40; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	if err := os.WriteFile("long.txt", []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "long.txt")
+	git("commit", "-q", "-m", "add long.txt")
+
+	lines[1], lines[38] = "line 2 changed", "line 39 changed"
+	lines = append(lines[:20], append([]string{"inserted"}, lines[20:]...)...)
+	if err := os.WriteFile("long.txt", []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("new.txt", []byte("new\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "long.txt", "new.txt")
+
+	patch, err := g.StagedPatch(ctx)
+	if err != nil {
+		t.Fatalf("StagedPatch() error = %v", err)
+	}
+	hunks := SplitPatch(patch)
+	if len(hunks) != 4 {
+		t.Fatalf("SplitPatch() returned %d hunks, want 4:\n%+v", len(hunks), hunks)
+	}
+	if BuildPatch(hunks) != patch {
+		t.Errorf("BuildPatch() does not restore the patch:\n%s", BuildPatch(hunks))
+	}
+	for i, file := range []string{"long.txt", "long.txt", "long.txt", "new.txt"} {
+		if hunks[i].ID != fmt.Sprintf("h%d", i+1) || hunks[i].File != file {
+			t.Errorf("hunk %d = %s, want h%d %s", i, hunks[i].Title(), i+1, file)
+		}
+	}
+	if !strings.HasPrefix(hunks[0].Title(), "h1 long.txt @@ -1,5 +1,5 @@") {
+		t.Errorf("unexpected title %q", hunks[0].Title())
+	}
+
+	// Commit the hunks out of order, the later ones apply at an offset
+	if err := g.ResetIndex(ctx); err != nil {
+		t.Fatalf("ResetIndex() error = %v", err)
+	}
+	for _, group := range [][]Hunk{{hunks[2], hunks[3]}, {hunks[0]}, {hunks[1]}} {
+		if err := g.ApplyCached(ctx, BuildPatch(group)); err != nil {
+			t.Fatalf("ApplyCached() error = %v", err)
+		}
+		git("commit", "-q", "-m", group[0].ID)
+	}
+
+	if status := git("status", "--porcelain", "--", "long.txt", "new.txt"); status != "" {
+		t.Errorf("expected all hunks to be committed, got:\n%s", status)
+	}
+	if err := g.ApplyCached(ctx, BuildPatch(hunks[:1])); err == nil {
+		t.Error("ApplyCached() should fail for a patch that was already applied")
+	}
+}
@@ -0,0 +1,100 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// DefaultIssuePattern matches Jira style issue keys such as PROJ-123.
+const DefaultIssuePattern = `[A-Z][A-Z0-9_]+-[0-9]+`
+
+// currentBranch generates the git command to print the name of the current branch,
+// which also works before the first commit.
+func (c *Command) currentBranch(ctx context.Context) *exec.Cmd {
+	return exec.CommandContext(
+		ctx,
+		"git",
+		"symbolic-ref",
+		"--quiet",
+		"--short",
+		"HEAD",
+	)
+}
+
+// interpretTrailers generates the git command to add trailers to the message read
+// from stdin, skipping trailers the message already has.
+func (c *Command) interpretTrailers(ctx context.Context, trailers []string) *exec.Cmd {
+	args := []string{
+		"interpret-trailers",
+		"--if-exists=addIfDifferent",
+	}
+	for _, trailer := range trailers {
+		args = append(args, "--trailer="+trailer)
+	}
+
+	return exec.CommandContext(
+		ctx,
+		"git",
+		args...,
+	)
+}
+
+// CurrentBranch returns the name of the current branch, or an empty string when
+// HEAD is detached.
+func (c *Command) CurrentBranch(ctx context.Context) (string, error) {
+	output, err := c.currentBranch(ctx).Output()
+	if err != nil {
+		// symbolic-ref exits with 1 and prints nothing for a detached HEAD
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// InterpretTrailers appends trailers, each formatted as "Key: value", to message
+// using git interpret-trailers.
+func (c *Command) InterpretTrailers(ctx context.Context, message string, trailers []string) (string, error) {
+	if len(trailers) == 0 {
+		return message, nil
+	}
+
+	cmd := c.interpretTrailers(ctx, trailers)
+	cmd.Stdin = strings.NewReader(message + "\n")
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("failed to add trailers: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// IssueKey returns the first match of pattern in branch, or its first submatch
+// when pattern has a capturing group. It returns an empty string without a match.
+func IssueKey(pattern, branch string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid issue pattern %q: %w", pattern, err)
+	}
+
+	match := re.FindStringSubmatch(branch)
+	switch {
+	case match == nil:
+		return "", nil
+	case len(match) > 1:
+		return match[1], nil
+	default:
+		return match[0], nil
+	}
+}
@@ -0,0 +1,72 @@
+package git
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+)
+
+func TestIssueKey(t *testing.T) {
+	tests := []struct {
+		pattern string
+		branch  string
+		want    string
+	}{
+		{DefaultIssuePattern, "feature/PROJ-123-add-login", "PROJ-123"},
+		{DefaultIssuePattern, "fix/ab-12", ""},
+		{DefaultIssuePattern, "main", ""},
+		{`issue-(\d+)`, "bugfix/issue-42-crash", "42"},
+	}
+
+	for _, tt := range tests {
+		got, err := IssueKey(tt.pattern, tt.branch)
+		if err != nil || got != tt.want {
+			t.Errorf("IssueKey(%q, %q) = %q, %v, want %q", tt.pattern, tt.branch, got, err, tt.want)
+		}
+	}
+
+	if _, err := IssueKey("[", "main"); err == nil {
+		t.Error("IssueKey() should fail for an invalid pattern")
+	}
+}
+
+func TestCurrentBranch(t *testing.T) {
+	setupDiffRepo(t)
+	ctx := context.Background()
+
+	branch, err := New().CurrentBranch(ctx)
+	if err != nil || branch != "feature" {
+		t.Errorf("CurrentBranch() = %q, %v, want feature", branch, err)
+	}
+
+	if out, err := exec.Command("git", "checkout", "-q", "--detach").CombinedOutput(); err != nil {
+		t.Fatalf("git checkout: %v\n%s", err, out)
+	}
+	branch, err = New().CurrentBranch(ctx)
+	if err != nil || branch != "" {
+		t.Errorf("CurrentBranch() = %q, %v, want no branch for a detached HEAD", branch, err)
+	}
+}
+
+func TestInterpretTrailers(t *testing.T) {
+	setupDiffRepo(t)
+	ctx := context.Background()
+
+	message := "feat: add login\n\n- add the login form\n\nRefs: PROJ-1"
+	got, err := New().InterpretTrailers(ctx, message, []string{
+		"Refs: PROJ-1",
+		"Reviewed-by: Jane Doe <jane@example.com>",
+	})
+	if err != nil {
+		t.Fatalf("InterpretTrailers() error = %v", err)
+	}
+	want := "feat: add login\n\n- add the login form\n\nRefs: PROJ-1\nReviewed-by: Jane Doe <jane@example.com>"
+	if got != want {
+		t.Errorf("InterpretTrailers() = %q, want %q", got, want)
+	}
+
+	got, err = New().InterpretTrailers(ctx, "fix: typo", []string{"Refs: PROJ-2"})
+	if err != nil || got != "fix: typo\n\nRefs: PROJ-2" {
+		t.Errorf("InterpretTrailers() = %q, %v, want the trailer after a blank line", got, err)
+	}
+}
@@ -3,6 +3,7 @@ package prompt
 import (
 	"embed"
 	"log"
+	"strings"
 
 	"github.com/appleboy/CodeGPT/util"
 )
@@ -17,9 +18,14 @@ const (
 	SummarizeTitleTemplate     = "summarize_title.tmpl"
 	ConventionalCommitTemplate = "conventional_commit.tmpl"
 	TranslationTemplate        = "translation.tmpl"
+	PullRequestTemplate        = "pull_request.tmpl"
+	ChangelogSectionTemplate   = "changelog_section.tmpl"
+	SplitCommitsTemplate       = "split_commits.tmpl"
 	SummarizePrefixKey         = "summarize_prefix"
 	SummarizeTitleKey          = "summarize_title"
 	SummarizeMessageKey        = "summarize_message"
+	IssueKeyKey                = "issue_key"
+	CommitHistoryKey           = "commit_history"
 )
 
 // Initializes the prompt package by loading the templates from the embedded file system.
@@ -29,6 +35,12 @@ func init() { //nolint:gochecknoinits // required to load embedded templates at
 	}
 }
 
+// SystemTemplateName returns the name of the optional template overriding the
+// system prompt for the given template, e.g. "summarize_title.system.tmpl".
+func SystemTemplateName(name string) string {
+	return strings.TrimSuffix(name, ".tmpl") + ".system.tmpl"
+}
+
 // GetRawData returns the raw data of the template with the given name.
 func GetRawData(name string) ([]byte, error) {
 	key := "templates/" + name
@@ -0,0 +1,20 @@
+package prompt
+
+import "testing"
+
+func TestSystemTemplateName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{SummarizeTitleTemplate, "summarize_title.system.tmpl"},
+		{CodeReviewTemplate, "code_review_file_diff.system.tmpl"},
+		{"custom", "custom.system.tmpl"},
+	}
+
+	for _, tc := range testCases {
+		if result := SystemTemplateName(tc.name); result != tc.expected {
+			t.Errorf("SystemTemplateName(%q) = %q, expected %q", tc.name, result, tc.expected)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/core/capability"
 	"github.com/appleboy/CodeGPT/core/transport"
 	"github.com/appleboy/CodeGPT/proxy"
 	"github.com/appleboy/CodeGPT/version"
@@ -21,24 +22,44 @@ import (
 var _ core.Generative = (*Client)(nil)
 
 type Client struct {
-	client      *anthropic.Client
-	model       anthropic.Model
-	maxTokens   int
-	temperature float32
-	topP        float32
+	client       *anthropic.Client
+	model        anthropic.Model
+	maxTokens    int
+	temperature  float32
+	topP         float32
+	systemPrompt string
+	caps         capability.Model
 }
 
-// Completion is a method on the Client struct that takes a context.Context and a string argument
-func (c *Client) Completion(ctx context.Context, content string) (*core.Response, error) {
-	resp, err := c.client.CreateMessages(ctx, anthropic.MessagesRequest{
-		Model: c.model,
+// newRequest builds a MessagesRequest for content with the client's model parameters.
+// The system prompt carried by ctx overrides the configured one. Models without
+// system prompt support get it prepended to the user message, and sampling
+// parameters are left out for models that reject them.
+func (c *Client) newRequest(ctx context.Context, content string) anthropic.MessagesRequest {
+	system := core.SystemPrompt(ctx, c.systemPrompt)
+	if !c.caps.SystemPrompt && system != "" {
+		content = system + "\n\n" + content
+		system = ""
+	}
+
+	req := anthropic.MessagesRequest{
+		Model:  c.model,
+		System: system,
 		Messages: []anthropic.Message{
 			anthropic.NewUserTextMessage(content),
 		},
-		MaxTokens:   c.maxTokens,
-		Temperature: convert.ToPtr(c.temperature),
-		TopP:        convert.ToPtr(c.topP),
-	})
+		MaxTokens: c.maxTokens,
+	}
+	if c.caps.Temperature {
+		req.Temperature = convert.ToPtr(c.temperature)
+		req.TopP = convert.ToPtr(c.topP)
+	}
+	return req
+}
+
+// Completion is a method on the Client struct that takes a context.Context and a string argument
+func (c *Client) Completion(ctx context.Context, content string) (*core.Response, error) {
+	resp, err := c.client.CreateMessages(ctx, c.newRequest(ctx, content))
 	if err != nil {
 		var e *anthropic.APIError
 		if errors.As(err, &e) {
@@ -71,23 +92,20 @@ func (c *Client) Completion(ctx context.Context, content string) (*core.Response
 }
 
 // CompletionStream streams completion tokens to the writer as they arrive.
+// Models without streaming support write the whole completion at once.
 func (c *Client) CompletionStream(
 	ctx context.Context,
 	content string,
 	w io.Writer,
 ) (*core.Response, error) {
+	if !c.caps.Streaming {
+		return core.WriteCompletion(ctx, c, content, w)
+	}
+
 	var sb strings.Builder
 	var writeErr error
 	resp, err := c.client.CreateMessagesStream(ctx, anthropic.MessagesStreamRequest{
-		MessagesRequest: anthropic.MessagesRequest{
-			Model: c.model,
-			Messages: []anthropic.Message{
-				anthropic.NewUserTextMessage(content),
-			},
-			MaxTokens:   c.maxTokens,
-			Temperature: convert.ToPtr(c.temperature),
-			TopP:        convert.ToPtr(c.topP),
-		},
+		MessagesRequest: c.newRequest(ctx, content),
 		OnContentBlockDelta: func(data anthropic.MessagesEventContentBlockDeltaData) {
 			if data.Delta.Text != nil && writeErr == nil {
 				sb.WriteString(*data.Delta.Text)
@@ -133,16 +151,16 @@ func (c *Client) CompletionStream(
 }
 
 // GetSummaryPrefix is an API call to get a summary prefix using function call.
+// Models without tool support answer with a regular completion instead.
 func (c *Client) GetSummaryPrefix(ctx context.Context, content string) (*core.Response, error) {
-	request := anthropic.MessagesRequest{
-		Model: c.model,
-		Messages: []anthropic.Message{
-			anthropic.NewUserTextMessage(content),
-		},
-		MaxTokens: c.maxTokens,
-		Tools:     tools,
+	if !c.caps.Tools {
+		return c.Completion(ctx, content)
 	}
 
+	request := c.newRequest(ctx, content)
+	request.Temperature, request.TopP = nil, nil
+	request.Tools = tools
+
 	resp, err := c.client.CreateMessages(ctx, request)
 	if err != nil {
 		return nil, err
@@ -183,6 +201,53 @@ func (c *Client) GetSummaryPrefix(ctx context.Context, content string) (*core.Re
 	}, nil
 }
 
+// GetReviewFindings is an API call to get structured review findings using a forced tool call.
+// Models without tool support answer with a regular completion instead.
+func (c *Client) GetReviewFindings(ctx context.Context, content string) (*core.Response, error) {
+	if !c.caps.Tools {
+		return c.Completion(ctx, content)
+	}
+
+	request := c.newRequest(ctx, content)
+	request.Temperature, request.TopP = nil, nil
+	request.Tools = []anthropic.ToolDefinition{reviewTool}
+	request.ToolChoice = &anthropic.ToolChoice{Type: "tool", Name: reviewTool.Name}
+
+	resp, err := c.client.CreateMessages(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var toolUse *anthropic.MessageContentToolUse
+
+	for _, c := range resp.Content {
+		if c.Type == anthropic.MessagesContentTypeToolUse {
+			toolUse = c.MessageContentToolUse
+		}
+	}
+
+	if toolUse == nil {
+		return nil, errors.New("no tool use found in response")
+	}
+
+	usage := core.Usage{
+		PromptTokens:     resp.Usage.InputTokens,
+		CompletionTokens: resp.Usage.OutputTokens,
+		TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+	}
+
+	if resp.Usage.CacheCreationInputTokens > 0 || resp.Usage.CacheReadInputTokens > 0 {
+		usage.PromptTokensDetails = &openai.PromptTokensDetails{
+			CachedTokens: resp.Usage.CacheCreationInputTokens + resp.Usage.CacheReadInputTokens,
+		}
+	}
+
+	return &core.Response{
+		Content: string(toolUse.Input),
+		Usage:   usage,
+	}, nil
+}
+
 // New creates a new Client instance with the provided options.
 func New(opts ...Option) (c *Client, err error) {
 	// Create a new config object with the given options.
@@ -203,12 +268,16 @@ func New(opts ...Option) (c *Client, err error) {
 		return nil, fmt.Errorf("can't create a new HTTP client: %w", err)
 	}
 
-	// Inject x-app-name and x-app-version headers using core/transport.DefaultHeaderTransport
-	httpClient.Transport = &transport.DefaultHeaderTransport{
-		Origin:     httpClient.Transport,
-		Header:     nil,
-		AppName:    version.App,
-		AppVersion: version.Version,
+	// Inject x-app-name and x-app-version headers using core/transport.DefaultHeaderTransport,
+	// retry rate limited or failed requests, and record or replay them when CODEGPT_CASSETTE is set
+	httpClient.Transport = &transport.RetryTransport{
+		Origin: &transport.DefaultHeaderTransport{
+			Origin:     transport.WithCassette(httpClient.Transport),
+			Header:     nil,
+			AppName:    version.App,
+			AppVersion: version.Version,
+		},
+		MaxRetries: cfg.maxRetries,
 	}
 
 	// Create a new client instance with the necessary fields.
@@ -217,10 +286,12 @@ func New(opts ...Option) (c *Client, err error) {
 			cfg.apiKey,
 			anthropic.WithHTTPClient(httpClient),
 		),
-		model:       cfg.model,
-		maxTokens:   cfg.maxTokens,
-		temperature: cfg.temperature,
-		topP:        cfg.topP,
+		model:        cfg.model,
+		maxTokens:    cfg.maxTokens,
+		temperature:  cfg.temperature,
+		topP:         cfg.topP,
+		systemPrompt: cfg.systemPrompt,
+		caps:         cfg.capabilities(),
 	}
 
 	return engine, nil
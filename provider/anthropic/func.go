@@ -1,6 +1,8 @@
 package anthropic
 
 import (
+	"github.com/appleboy/CodeGPT/core"
+
 	"github.com/liushuangls/go-anthropic/v2"
 	"github.com/sashabaranov/go-openai/jsonschema"
 )
@@ -48,3 +50,10 @@ var tools = []anthropic.ToolDefinition{
 		},
 	},
 }
+
+// reviewTool asks the model to report structured code review findings.
+var reviewTool = anthropic.ToolDefinition{
+	Name:        core.ReviewFindingsFunc,
+	Description: "Report the findings of a code review",
+	InputSchema: core.ReviewSchema,
+}
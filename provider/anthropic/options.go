@@ -4,6 +4,8 @@ import (
 	"errors"
 	"time"
 
+	"github.com/appleboy/CodeGPT/core/capability"
+
 	"github.com/liushuangls/go-anthropic/v2"
 )
 
@@ -19,6 +21,9 @@ var (
 	defaultTopP        = float32(1.0)
 )
 
+// DefaultModel is the default Anthropic model to use if one is not provided.
+var DefaultModel = string(defaultModel)
+
 // Option is an interface that specifies instrumentation configuration options.
 type Option interface {
 	apply(*config)
@@ -112,17 +117,44 @@ func WithTimeout(val time.Duration) Option {
 	})
 }
 
+// WithMaxRetries returns a new Option that sets how many times a request is retried
+// after a rate limit (429) or server error (5xx) response. Zero disables retries.
+func WithMaxRetries(val int) Option {
+	return optionFunc(func(c *config) {
+		c.maxRetries = val
+	})
+}
+
+// WithSystemPrompt returns a new Option that sets the system prompt sent with every request.
+// Empty sends no system prompt.
+func WithSystemPrompt(val string) Option {
+	return optionFunc(func(c *config) {
+		c.systemPrompt = val
+	})
+}
+
+// WithCapabilities returns a new Option that sets the capabilities of the model.
+// By default they are looked up from the built-in capability registry.
+func WithCapabilities(val capability.Model) Option {
+	return optionFunc(func(c *config) {
+		c.caps = &val
+	})
+}
+
 // config is a struct that stores configuration options for the instrumentation.
 type config struct {
-	apiKey      string
-	model       anthropic.Model
-	maxTokens   int
-	temperature float32
-	topP        float32
-	proxyURL    string
-	socksURL    string
-	skipVerify  bool
-	timeout     time.Duration
+	apiKey       string
+	model        anthropic.Model
+	maxTokens    int
+	temperature  float32
+	topP         float32
+	proxyURL     string
+	socksURL     string
+	skipVerify   bool
+	timeout      time.Duration
+	maxRetries   int
+	systemPrompt string
+	caps         *capability.Model
 }
 
 // valid checks whether a config object is valid, returning an error if it is not.
@@ -140,6 +172,14 @@ func (cfg *config) valid() error {
 	return nil
 }
 
+// capabilities returns the configured model capabilities, or the built-in ones for the model.
+func (cfg *config) capabilities() capability.Model {
+	if cfg.caps != nil {
+		return *cfg.caps
+	}
+	return capability.Lookup(string(cfg.model))
+}
+
 // newConfig creates a new config object with default values, and applies the given options.
 func newConfig(opts ...Option) *config {
 	// Create a new config object with default values.
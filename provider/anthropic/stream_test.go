@@ -8,6 +8,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/appleboy/CodeGPT/core/capability"
+
 	"github.com/liushuangls/go-anthropic/v2"
 )
 
@@ -46,6 +48,7 @@ data: {"type":"message_stop"}`,
 		),
 		model:     anthropic.ModelClaude3Haiku20240307,
 		maxTokens: 1024,
+		caps:      capability.Lookup(string(anthropic.ModelClaude3Haiku20240307)),
 	}
 
 	var buf bytes.Buffer
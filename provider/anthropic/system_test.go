@@ -0,0 +1,52 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/core/capability"
+
+	"github.com/liushuangls/go-anthropic/v2"
+)
+
+func TestCompletionSystemPrompt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			System string `json:"system"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if req.System != "Be brief." {
+			t.Errorf("expected system prompt %q, got %q", "Be brief.", req.System)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"ok"}],"model":"claude-3-haiku-20240307","usage":{"input_tokens":5,"output_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		client: anthropic.NewClient(
+			"test-token",
+			anthropic.WithBaseURL(server.URL),
+		),
+		model:        anthropic.ModelClaude3Haiku20240307,
+		maxTokens:    1024,
+		systemPrompt: "Follow the house style.",
+		caps:         capability.Lookup(string(anthropic.ModelClaude3Haiku20240307)),
+	}
+
+	ctx := core.WithSystemPrompt(context.Background(), "Be brief.")
+	resp, err := client.Completion(ctx, "test prompt")
+	if err != nil {
+		t.Fatalf("Completion failed: %v", err)
+	}
+	if resp.Content != "ok" {
+		t.Errorf("unexpected content %q", resp.Content)
+	}
+}
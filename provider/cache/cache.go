@@ -0,0 +1,170 @@
+// Package cache provides a core.Generative implementation that stores responses
+// on disk, so identical requests are not sent to the provider twice.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/appleboy/CodeGPT/core"
+)
+
+// DefaultTTL is the default time cached responses stay valid.
+const DefaultTTL = 24 * time.Hour
+
+var _ core.Generative = (*Client)(nil)
+
+// Request kinds, part of the cache key so a completion, a summary prefix and
+// review findings for the same content never share an entry.
+const (
+	kindCompletion = "completion"
+	kindPrefix     = "summary_prefix"
+	kindReview     = "review_findings"
+)
+
+// Client wraps a core.Generative and serves repeated requests from an on-disk store.
+// The cache key is a hash of the prompt, system prompt, provider, model, sampling parameters
+// and the other request parameters given with WithParams.
+type Client struct {
+	client       core.Generative
+	store        *Store
+	params       string
+	systemPrompt string
+}
+
+// key returns the cache key for the request kind, system prompt and content.
+func (c *Client) key(ctx context.Context, kind, content string) string {
+	h := sha256.New()
+	h.Write([]byte(kind))
+	h.Write([]byte{0})
+	h.Write([]byte(c.params))
+	h.Write([]byte{0})
+	h.Write([]byte(core.SystemPrompt(ctx, c.systemPrompt)))
+	h.Write([]byte{0})
+	h.Write([]byte(content))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// lookup returns the cached response for key, marked as served from cache.
+func (c *Client) lookup(key string) (*core.Response, bool) {
+	e, ok := c.store.get(key)
+	if !ok {
+		return nil, false
+	}
+	usage := e.Usage
+	usage.FromCache = true
+	return &core.Response{
+		Content: e.Content,
+		Usage:   usage,
+	}, true
+}
+
+// save stores resp for key. Failing to write the cache never fails the request.
+func (c *Client) save(key string, resp *core.Response) {
+	_ = c.store.put(key, &entry{
+		CreatedAt: time.Now(),
+		Content:   resp.Content,
+		Usage:     resp.Usage,
+	})
+}
+
+// Completion is a method on the Client struct that takes a context.Context and a string argument
+func (c *Client) Completion(ctx context.Context, content string) (*core.Response, error) {
+	key := c.key(ctx, kindCompletion, content)
+	if resp, ok := c.lookup(key); ok {
+		return resp, nil
+	}
+
+	resp, err := c.client.Completion(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	c.save(key, resp)
+	return resp, nil
+}
+
+// GetSummaryPrefix returns the cached summary prefix or asks the wrapped client for it.
+func (c *Client) GetSummaryPrefix(ctx context.Context, content string) (*core.Response, error) {
+	key := c.key(ctx, kindPrefix, content)
+	if resp, ok := c.lookup(key); ok {
+		return resp, nil
+	}
+
+	resp, err := c.client.GetSummaryPrefix(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	c.save(key, resp)
+	return resp, nil
+}
+
+// GetReviewFindings returns the cached review findings or asks the wrapped client for them.
+func (c *Client) GetReviewFindings(ctx context.Context, content string) (*core.Response, error) {
+	key := c.key(ctx, kindReview, content)
+	if resp, ok := c.lookup(key); ok {
+		return resp, nil
+	}
+
+	resp, err := c.client.GetReviewFindings(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	c.save(key, resp)
+	return resp, nil
+}
+
+// CompletionStream streams completion tokens to the writer as they arrive.
+// Cached responses are written to the writer at once. Streamed and regular
+// completions of the same content share a cache entry.
+func (c *Client) CompletionStream(
+	ctx context.Context,
+	content string,
+	w io.Writer,
+) (*core.Response, error) {
+	key := c.key(ctx, kindCompletion, content)
+	if resp, ok := c.lookup(key); ok {
+		if _, err := io.WriteString(w, resp.Content); err != nil {
+			return nil, fmt.Errorf("writing cached completion: %w", err)
+		}
+		return resp, nil
+	}
+
+	resp, err := c.client.CompletionStream(ctx, content, w)
+	if err != nil {
+		return nil, err
+	}
+	c.save(key, resp)
+	return resp, nil
+}
+
+// New wraps client with an on-disk response cache configured by the provided options.
+func New(client core.Generative, opts ...Option) (*Client, error) {
+	// Create a new config object with the given options.
+	cfg := newConfig(opts...)
+
+	// Validate the config object, returning an error if it is invalid.
+	if err := cfg.valid(); err != nil {
+		return nil, err
+	}
+
+	params := strings.Join(append([]string{
+		cfg.provider,
+		cfg.model,
+		strconv.FormatFloat(float64(cfg.temperature), 'f', -1, 32),
+		strconv.FormatFloat(float64(cfg.topP), 'f', -1, 32),
+		strconv.Itoa(cfg.maxTokens),
+	}, cfg.params...), "|")
+
+	return &Client{
+		client:       client,
+		store:        NewStore(cfg.dir, cfg.ttl),
+		params:       params,
+		systemPrompt: cfg.systemPrompt,
+	}, nil
+}
@@ -0,0 +1,267 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/appleboy/CodeGPT/core"
+)
+
+// stubClient is a core.Generative counting the requests it answers.
+type stubClient struct {
+	content string
+	err     error
+	calls   int
+}
+
+func (s *stubClient) Completion(ctx context.Context, content string) (*core.Response, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &core.Response{Content: s.content, Usage: core.Usage{TotalTokens: 42}}, nil
+}
+
+func (s *stubClient) GetSummaryPrefix(ctx context.Context, content string) (*core.Response, error) {
+	return s.Completion(ctx, content)
+}
+
+func (s *stubClient) GetReviewFindings(ctx context.Context, content string) (*core.Response, error) {
+	return s.Completion(ctx, content)
+}
+
+func (s *stubClient) CompletionStream(
+	ctx context.Context,
+	content string,
+	w io.Writer,
+) (*core.Response, error) {
+	resp, err := s.Completion(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = io.WriteString(w, resp.Content)
+	return resp, nil
+}
+
+func TestNewMissingDir(t *testing.T) {
+	if _, err := New(&stubClient{}); !errors.Is(err, errorsMissingDir) {
+		t.Fatalf("expected errorsMissingDir, got %v", err)
+	}
+}
+
+func TestCompletionServedFromCache(t *testing.T) {
+	stub := &stubClient{content: "feat: add cache"}
+	client, err := New(stub, WithDir(t.TempDir()), WithModel("gpt-4o"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := client.Completion(context.Background(), "diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Usage.FromCache {
+		t.Error("first response should not come from cache")
+	}
+
+	second, err := client.Completion(context.Background(), "diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stub.calls != 1 {
+		t.Errorf("expected 1 upstream call, got %d", stub.calls)
+	}
+	if second.Content != first.Content {
+		t.Errorf("expected %q, got %q", first.Content, second.Content)
+	}
+	if !second.Usage.FromCache || second.Usage.TotalTokens != 42 {
+		t.Errorf("unexpected cached usage: %+v", second.Usage)
+	}
+}
+
+func TestCacheKeyIncludesParameters(t *testing.T) {
+	dir := t.TempDir()
+	stub := &stubClient{content: "ok"}
+
+	for _, opts := range [][]Option{
+		{WithModel("gpt-4o")},
+		{WithModel("gpt-4o-mini")},
+		{WithModel("gpt-4o"), WithTemperature(0.2)},
+		{WithModel("gpt-4o"), WithTopP(0.5)},
+		{WithModel("gpt-4o"), WithProvider("anthropic")},
+		{WithModel("gpt-4o"), WithSystemPrompt("Follow the house style.")},
+		{WithModel("gpt-4o"), WithParams("ollama.format=json")},
+		{WithModel("gpt-4o"), WithParams("ollama.format=json", "ollama.num_ctx=8192")},
+	} {
+		client, err := New(stub, append(opts, WithDir(dir))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Completion(context.Background(), "diff"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if stub.calls != 8 {
+		t.Errorf("expected every parameter set to miss the cache, got %d calls", stub.calls)
+	}
+}
+
+func TestCacheKeyIncludesSystemPromptOverride(t *testing.T) {
+	stub := &stubClient{content: "ok"}
+	client, err := New(stub, WithDir(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := core.WithSystemPrompt(context.Background(), "Be brief.")
+	for _, c := range []context.Context{context.Background(), ctx, ctx} {
+		if _, err := client.Completion(c, "diff"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stub.calls != 2 {
+		t.Errorf("expected 2 upstream calls, got %d", stub.calls)
+	}
+}
+
+func TestSummaryPrefixDoesNotShareCompletionEntry(t *testing.T) {
+	stub := &stubClient{content: "ok"}
+	client, err := New(stub, WithDir(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Completion(context.Background(), "diff"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetSummaryPrefix(context.Background(), "diff"); err != nil {
+		t.Fatal(err)
+	}
+	if stub.calls != 2 {
+		t.Errorf("expected 2 upstream calls, got %d", stub.calls)
+	}
+}
+
+func TestCompletionStreamWritesCachedContent(t *testing.T) {
+	stub := &stubClient{content: "streamed"}
+	client, err := New(stub, WithDir(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.CompletionStream(context.Background(), "diff", io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	resp, err := client.CompletionStream(context.Background(), "diff", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stub.calls != 1 {
+		t.Errorf("expected 1 upstream call, got %d", stub.calls)
+	}
+	if buf.String() != "streamed" || !resp.Usage.FromCache {
+		t.Errorf("unexpected cached stream %q, usage %+v", buf.String(), resp.Usage)
+	}
+}
+
+func TestErrorsAreNotCached(t *testing.T) {
+	stub := &stubClient{err: errors.New("boom")}
+	client, err := New(stub, WithDir(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if _, err := client.Completion(context.Background(), "diff"); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if stub.calls != 2 {
+		t.Errorf("expected 2 upstream calls, got %d", stub.calls)
+	}
+}
+
+func TestExpiredEntryIsRefreshed(t *testing.T) {
+	dir := t.TempDir()
+	stub := &stubClient{content: "ok"}
+	client, err := New(stub, WithDir(dir), WithTTL(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := client.key(context.Background(), kindCompletion, "diff")
+	if err := client.store.put(key, &entry{
+		CreatedAt: time.Now().Add(-2 * time.Hour),
+		Content:   "stale",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Completion(context.Background(), "diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "ok" || stub.calls != 1 {
+		t.Errorf("expected a fresh response, got %q after %d calls", resp.Content, stub.calls)
+	}
+}
+
+func TestStoreClearAndStats(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir, time.Hour)
+
+	for _, key := range []string{"fresh", "old"} {
+		if err := store.put(key, &entry{CreatedAt: time.Now(), Content: key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	past := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "old.json"), past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := store.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 2 || stats.Expired != 1 || stats.Size == 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	removed, err := store.Clear(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Errorf("expected 1 expired entry removed, got %d", removed)
+	}
+
+	removed, err = store.Clear(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Errorf("expected 1 entry removed, got %d", removed)
+	}
+}
+
+func TestStoreMissingDir(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "missing"), 0)
+
+	stats, err := store.Stats()
+	if err != nil || stats.Entries != 0 {
+		t.Errorf("unexpected stats %+v, err %v", stats, err)
+	}
+	if removed, err := store.Clear(false); err != nil || removed != 0 {
+		t.Errorf("unexpected clear result %d, err %v", removed, err)
+	}
+}
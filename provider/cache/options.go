@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+var errorsMissingDir = errors.New("missing cache directory")
+
+// Option is an interface that specifies instrumentation configuration options.
+type Option interface {
+	apply(*config)
+}
+
+// optionFunc is a type of function that can be used to implement the Option interface.
+// It takes a pointer to a config struct and modifies it.
+type optionFunc func(*config)
+
+// Ensure that optionFunc satisfies the Option interface.
+var _ Option = (*optionFunc)(nil)
+
+// The apply method of optionFunc type is implemented here to modify the config struct based on the function passed.
+func (o optionFunc) apply(c *config) {
+	o(c)
+}
+
+// WithDir returns an Option that sets the directory where responses are stored.
+func WithDir(val string) Option {
+	return optionFunc(func(c *config) {
+		c.dir = val
+	})
+}
+
+// WithTTL returns an Option that sets how long cached responses stay valid.
+// Zero keeps responses until the cache is cleared.
+func WithTTL(val time.Duration) Option {
+	return optionFunc(func(c *config) {
+		c.ttl = val
+	})
+}
+
+// WithProvider returns an Option that sets the provider name used in the cache key.
+func WithProvider(val string) Option {
+	return optionFunc(func(c *config) {
+		c.provider = val
+	})
+}
+
+// WithModel returns an Option that sets the model name used in the cache key.
+func WithModel(val string) Option {
+	return optionFunc(func(c *config) {
+		c.model = val
+	})
+}
+
+// WithTemperature returns an Option that sets the temperature used in the cache key.
+func WithTemperature(val float32) Option {
+	return optionFunc(func(c *config) {
+		c.temperature = val
+	})
+}
+
+// WithTopP returns an Option that sets the top_p used in the cache key.
+func WithTopP(val float32) Option {
+	return optionFunc(func(c *config) {
+		c.topP = val
+	})
+}
+
+// WithMaxTokens returns an Option that sets the max tokens used in the cache key.
+func WithMaxTokens(val int) Option {
+	return optionFunc(func(c *config) {
+		c.maxTokens = val
+	})
+}
+
+// WithSystemPrompt returns an Option that sets the default system prompt used in the cache key.
+func WithSystemPrompt(val string) Option {
+	return optionFunc(func(c *config) {
+		c.systemPrompt = val
+	})
+}
+
+// WithParams returns an Option that adds provider specific request parameters, such
+// as penalties or the response format, to the cache key.
+func WithParams(val ...string) Option {
+	return optionFunc(func(c *config) {
+		c.params = append(c.params, val...)
+	})
+}
+
+// config is a struct that stores configuration options for the instrumentation.
+type config struct {
+	dir          string
+	ttl          time.Duration
+	provider     string
+	model        string
+	temperature  float32
+	topP         float32
+	maxTokens    int
+	systemPrompt string
+	params       []string
+}
+
+// valid checks whether a config object is valid, returning an error if it is not.
+func (cfg *config) valid() error {
+	if cfg.dir == "" {
+		return errorsMissingDir
+	}
+
+	// If all checks pass, return nil (no error).
+	return nil
+}
+
+// newConfig creates a new config object with default values, and applies the given options.
+func newConfig(opts ...Option) *config {
+	// Create a new config object with default values.
+	c := &config{
+		ttl: DefaultTTL,
+	}
+
+	// Apply each of the given options to the config object.
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+
+	// Return the resulting config object.
+	return c
+}
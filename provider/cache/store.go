@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/appleboy/CodeGPT/core"
+)
+
+// entryExt is the file extension of cached responses.
+const entryExt = ".json"
+
+// entry is a single cached response stored on disk.
+type entry struct {
+	CreatedAt time.Time  `json:"created_at"`
+	Content   string     `json:"content"`
+	Usage     core.Usage `json:"usage"`
+}
+
+// Store is an on-disk store of cached responses, one JSON file per key.
+type Store struct {
+	dir string
+	ttl time.Duration
+}
+
+// Stats describes the content of the cache directory.
+type Stats struct {
+	Dir     string
+	Entries int
+	Expired int
+	Size    int64
+}
+
+// NewStore returns a Store saving entries in dir.
+// Entries older than ttl are treated as missing; a zero ttl never expires entries.
+func NewStore(dir string, ttl time.Duration) *Store {
+	return &Store{dir: dir, ttl: ttl}
+}
+
+// Dir returns the directory of the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// path returns the file path of the entry for key.
+func (s *Store) path(key string) string {
+	return filepath.Join(s.dir, key+entryExt)
+}
+
+// expired reports whether an entry created at t is older than the TTL.
+func (s *Store) expired(t time.Time) bool {
+	return s.ttl > 0 && time.Since(t) > s.ttl
+}
+
+// get returns the entry for key, or false when it is missing, unreadable or expired.
+func (s *Store) get(key string) (*entry, bool) {
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, false
+	}
+	if s.expired(e.CreatedAt) {
+		return nil, false
+	}
+	return &e, true
+}
+
+// put stores the entry for key, replacing any previous entry atomically.
+func (s *Store) put(key string, e *entry) error {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(key))
+}
+
+// walk calls fn for each cached entry file in the store directory.
+func (s *Store) walk(fn func(path string, info fs.FileInfo) error) error {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), entryExt) {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		if err := fn(filepath.Join(s.dir, f.Name()), info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clear removes cached entries and returns how many were removed.
+// When expiredOnly is true, only entries older than the TTL are removed.
+func (s *Store) Clear(expiredOnly bool) (int, error) {
+	removed := 0
+	err := s.walk(func(path string, info fs.FileInfo) error {
+		if expiredOnly && !s.expired(info.ModTime()) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
+
+// Stats returns the number of entries, expired entries and total size of the store.
+func (s *Store) Stats() (Stats, error) {
+	stats := Stats{Dir: s.dir}
+	err := s.walk(func(_ string, info fs.FileInfo) error {
+		stats.Entries++
+		stats.Size += info.Size()
+		if s.expired(info.ModTime()) {
+			stats.Expired++
+		}
+		return nil
+	})
+	return stats, err
+}
@@ -0,0 +1,101 @@
+// Package fake provides an offline core.Generative implementation answering
+// with scripted responses, for deterministic tests and demos without an API key.
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/appleboy/CodeGPT/core"
+)
+
+var _ core.Generative = (*Client)(nil)
+
+// Client answers every request with the matching scripted response.
+type Client struct {
+	responses []Response
+}
+
+// respond returns the response for a request of kind, with a usage counting
+// the words of the prompt and of the response.
+func (c *Client) respond(ctx context.Context, kind, content string) (*core.Response, error) {
+	r, err := match(c.responses, kind, core.TemplateName(ctx), content)
+	if err != nil {
+		return nil, err
+	}
+	if r.Error != "" {
+		return nil, errors.New(r.Error)
+	}
+
+	prompt := len(strings.Fields(content))
+	completion := len(strings.Fields(r.Content))
+	return &core.Response{
+		Content: r.Content,
+		Usage: core.Usage{
+			PromptTokens:     prompt,
+			CompletionTokens: completion,
+			TotalTokens:      prompt + completion,
+		},
+	}, nil
+}
+
+// Completion returns the scripted completion for content.
+func (c *Client) Completion(ctx context.Context, content string) (*core.Response, error) {
+	return c.respond(ctx, KindCompletion, content)
+}
+
+// GetSummaryPrefix returns the scripted summary prefix for content.
+func (c *Client) GetSummaryPrefix(ctx context.Context, content string) (*core.Response, error) {
+	return c.respond(ctx, KindSummaryPrefix, content)
+}
+
+// GetReviewFindings returns the scripted review findings for content.
+func (c *Client) GetReviewFindings(ctx context.Context, content string) (*core.Response, error) {
+	return c.respond(ctx, KindReviewFindings, content)
+}
+
+// CompletionStream writes the scripted completion for content to the writer
+// word by word, like a streaming provider.
+func (c *Client) CompletionStream(
+	ctx context.Context,
+	content string,
+	w io.Writer,
+) (*core.Response, error) {
+	resp, err := c.respond(ctx, KindCompletion, content)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, chunk := range strings.SplitAfter(resp.Content, " ") {
+		if _, err := io.WriteString(w, chunk); err != nil {
+			return nil, fmt.Errorf("writing streamed completion: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// New creates a new fake client with the provided options.
+func New(opts ...Option) (*Client, error) {
+	// Create a new config object with the given options.
+	cfg := newConfig(opts...)
+
+	// Validate the config object, returning an error if it is invalid.
+	if err := cfg.valid(); err != nil {
+		return nil, err
+	}
+
+	var responses []Response
+	if cfg.fixture != "" {
+		f, err := LoadFixture(cfg.fixture)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, f.Responses...)
+	}
+	responses = append(responses, cfg.responses...)
+
+	return &Client{responses: responses}, nil
+}
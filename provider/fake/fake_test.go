@@ -0,0 +1,119 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/core"
+)
+
+func TestNewMissingResponses(t *testing.T) {
+	if _, err := New(); !errors.Is(err, errorsMissingResponses) {
+		t.Fatalf("expected errorsMissingResponses, got %v", err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	client, err := New(WithResponses(
+		Response{Content: "catch all"},
+		Response{Template: "summarize_title.tmpl", Content: "by template"},
+		Response{Hash: Hash("exact prompt"), Content: "by hash"},
+		Response{Kind: KindSummaryPrefix, Content: "feat(cmd)"},
+		Response{Template: "broken.tmpl", Error: "rate limited"},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	title := core.WithTemplateName(ctx, "summarize_title.tmpl")
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		prompt  string
+		prefix  bool
+		want    string
+		wantErr string
+	}{
+		{name: "catch all", ctx: ctx, prompt: "anything", want: "catch all"},
+		{name: "template", ctx: title, prompt: "anything", want: "by template"},
+		{name: "hash wins over template", ctx: title, prompt: "exact prompt", want: "by hash"},
+		{name: "summary prefix", ctx: ctx, prompt: "anything", prefix: true, want: "feat(cmd)"},
+		{
+			name:    "error",
+			ctx:     core.WithTemplateName(ctx, "broken.tmpl"),
+			prompt:  "anything",
+			wantErr: "rate limited",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp *core.Response
+			var err error
+			if tt.prefix {
+				resp, err = client.GetSummaryPrefix(tt.ctx, tt.prompt)
+			} else {
+				resp, err = client.Completion(tt.ctx, tt.prompt)
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Content != tt.want {
+				t.Errorf("got %q, want %q", resp.Content, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoMatch(t *testing.T) {
+	client, err := New(WithResponses(Response{Kind: KindSummaryPrefix, Content: "feat"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Completion(context.Background(), "prompt")
+	if err == nil {
+		t.Fatal("expected an error without a matching response")
+	}
+}
+
+func TestFixtureAndStream(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	fixture := `{"responses": [{"content": "add the fake provider"}]}`
+	if err := os.WriteFile(path, []byte(fixture), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := New(WithFixture(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	resp, err := client.CompletionStream(context.Background(), "two words", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "add the fake provider" || resp.Content != buf.String() {
+		t.Errorf("unexpected stream %q, content %q", buf.String(), resp.Content)
+	}
+	if resp.Usage.PromptTokens != 2 || resp.Usage.CompletionTokens != 4 {
+		t.Errorf("unexpected usage %+v", resp.Usage)
+	}
+
+	if _, err := New(WithFixture(filepath.Join(t.TempDir(), "missing.json"))); err == nil {
+		t.Error("expected an error for a missing fixture")
+	}
+}
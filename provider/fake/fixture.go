@@ -0,0 +1,105 @@
+package fake
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Request kinds a response can be restricted to.
+const (
+	KindCompletion     = "completion"
+	KindSummaryPrefix  = "summary_prefix"
+	KindReviewFindings = "review_findings"
+)
+
+// Response is a scripted response. It answers the requests matching all of its
+// set fields: Hash is the hex SHA-256 of the prompt, see Hash, Template the name
+// of the prompt template the prompt was rendered from, e.g.
+// "summarize_title.tmpl", and Kind the request kind. A response without any of
+// them answers every request.
+type Response struct {
+	Hash     string `json:"hash,omitempty"`
+	Template string `json:"template,omitempty"`
+	Kind     string `json:"kind,omitempty"`
+
+	// Content is the response text. For summary prefix requests it is the
+	// prefix as returned by the other providers, e.g. "feat(cmd)".
+	Content string `json:"content"`
+	// Error makes the request fail with this message instead.
+	Error string `json:"error,omitempty"`
+}
+
+// Fixture is a set of scripted responses, stored as JSON:
+//
+//	{
+//	  "responses": [
+//	    {"template": "summarize_file_diff.tmpl", "content": "- add fake provider"},
+//	    {"kind": "summary_prefix", "content": "feat"},
+//	    {"content": "add fake provider"}
+//	  ]
+//	}
+type Fixture struct {
+	Responses []Response `json:"responses"`
+}
+
+// LoadFixture reads the fixture stored at path.
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// Hash returns the hex SHA-256 of prompt, used to match a response to an exact prompt.
+func Hash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// match returns the response for a request. The most specific matching response
+// wins: a prompt hash beats a template, which beats a kind. Among equally
+// specific responses, the first one in the fixture is used.
+func match(responses []Response, kind, template, prompt string) (*Response, error) {
+	hash := Hash(prompt)
+	var best *Response
+	bestScore := -1
+	for i := range responses {
+		r := &responses[i]
+		if (r.Kind != "" && r.Kind != kind) ||
+			(r.Template != "" && r.Template != template) ||
+			(r.Hash != "" && r.Hash != hash) {
+			continue
+		}
+
+		score := 0
+		if r.Hash != "" {
+			score += 4
+		}
+		if r.Template != "" {
+			score += 2
+		}
+		if r.Kind != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = r, score
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf(
+			"no fake response for %s request, template %q, prompt hash %s",
+			kind, template, hash,
+		)
+	}
+	return best, nil
+}
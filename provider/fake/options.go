@@ -0,0 +1,67 @@
+package fake
+
+import "errors"
+
+var errorsMissingResponses = errors.New("missing fake responses, set fake.fixture")
+
+// Option is an interface that specifies instrumentation configuration options.
+type Option interface {
+	apply(*config)
+}
+
+// optionFunc is a type of function that can be used to implement the Option interface.
+// It takes a pointer to a config struct and modifies it.
+type optionFunc func(*config)
+
+// Ensure that optionFunc satisfies the Option interface.
+var _ Option = (*optionFunc)(nil)
+
+// The apply method of optionFunc type is implemented here to modify the config struct based on the function passed.
+func (o optionFunc) apply(c *config) {
+	o(c)
+}
+
+// WithFixture returns an Option that loads the scripted responses from a fixture file.
+func WithFixture(val string) Option {
+	return optionFunc(func(c *config) {
+		c.fixture = val
+	})
+}
+
+// WithResponses returns an Option that adds scripted responses.
+// They are matched after the responses of the fixture file.
+func WithResponses(val ...Response) Option {
+	return optionFunc(func(c *config) {
+		c.responses = append(c.responses, val...)
+	})
+}
+
+// config is a struct that stores configuration options for the instrumentation.
+type config struct {
+	fixture   string
+	responses []Response
+}
+
+// valid checks whether a config object is valid, returning an error if it is not.
+func (cfg *config) valid() error {
+	if cfg.fixture == "" && len(cfg.responses) == 0 {
+		return errorsMissingResponses
+	}
+
+	// If all checks pass, return nil (no error).
+	return nil
+}
+
+// newConfig creates a new config object with default values, and applies the given options.
+func newConfig(opts ...Option) *config {
+	// Create a new config object with default values.
+	c := &config{}
+
+	// Apply each of the given options to the config object.
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+
+	// Return the resulting config object.
+	return c
+}
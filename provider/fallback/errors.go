@@ -0,0 +1,82 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/appleboy/CodeGPT/provider/ollama"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+	"google.golang.org/genai"
+)
+
+// IsRetryable reports whether err indicates a temporary failure of the backend,
+// so that the same request may succeed on another provider.
+// Transport errors, rate limits (429) and server errors (5xx) are retryable,
+// while client errors such as invalid requests or bad credentials are not.
+// A canceled context is never retryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	if code, ok := statusCode(err); ok {
+		return isRetryableStatus(code)
+	}
+
+	var anthropicErr *anthropic.APIError
+	if errors.As(err, &anthropicErr) {
+		return anthropicErr.IsRateLimitErr() ||
+			anthropicErr.IsApiErr() ||
+			anthropicErr.IsOverloadedErr()
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// isRetryableStatus reports whether the HTTP status code is a rate limit or server error.
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// statusCode extracts the HTTP status code from the error types of the provider SDKs.
+func statusCode(err error) (int, bool) {
+	var openaiAPIErr *openai.APIError
+	if errors.As(err, &openaiAPIErr) && openaiAPIErr.HTTPStatusCode > 0 {
+		return openaiAPIErr.HTTPStatusCode, true
+	}
+
+	var openaiReqErr *openai.RequestError
+	if errors.As(err, &openaiReqErr) && openaiReqErr.HTTPStatusCode > 0 {
+		return openaiReqErr.HTTPStatusCode, true
+	}
+
+	var anthropicReqErr *anthropic.RequestError
+	if errors.As(err, &anthropicReqErr) && anthropicReqErr.StatusCode > 0 {
+		return anthropicReqErr.StatusCode, true
+	}
+
+	var genaiErr genai.APIError
+	if errors.As(err, &genaiErr) && genaiErr.Code > 0 {
+		return genaiErr.Code, true
+	}
+
+	var ollamaErr *ollama.APIError
+	if errors.As(err, &ollamaErr) && ollamaErr.StatusCode > 0 {
+		return ollamaErr.StatusCode, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,87 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/provider/ollama"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+	"google.golang.org/genai"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: true},
+		{
+			name: "openai rate limit",
+			err:  &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests},
+			want: true,
+		},
+		{
+			name: "openai unauthorized",
+			err:  &openai.APIError{HTTPStatusCode: http.StatusUnauthorized},
+			want: false,
+		},
+		{
+			name: "openai request error",
+			err:  &openai.RequestError{HTTPStatusCode: http.StatusBadGateway},
+			want: true,
+		},
+		{
+			name: "anthropic overloaded",
+			err:  fmt.Errorf("messages error: %w", &anthropic.APIError{Type: anthropic.ErrTypeOverloaded}),
+			want: true,
+		},
+		{
+			name: "anthropic invalid request",
+			err:  &anthropic.APIError{Type: anthropic.ErrTypeInvalidRequest},
+			want: false,
+		},
+		{
+			name: "anthropic request error",
+			err:  &anthropic.RequestError{StatusCode: http.StatusInternalServerError},
+			want: true,
+		},
+		{
+			name: "gemini server error",
+			err:  genai.APIError{Code: http.StatusServiceUnavailable},
+			want: true,
+		},
+		{
+			name: "gemini bad request",
+			err:  genai.APIError{Code: http.StatusBadRequest},
+			want: false,
+		},
+		{
+			name: "ollama not found",
+			err:  &ollama.APIError{StatusCode: http.StatusNotFound},
+			want: false,
+		},
+		{
+			name: "transport error",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			want: true,
+		},
+		{name: "generic error", err: errors.New("boom"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Package fallback provides a core.Generative implementation that chains several
+// providers and transparently retries the next one when a provider is unavailable.
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/appleboy/CodeGPT/core"
+)
+
+var _ core.Generative = (*Client)(nil)
+
+// Backend is a single provider/model pair in the fallback chain.
+type Backend struct {
+	Provider core.Platform
+	Model    string
+	Client   core.Generative
+}
+
+// String returns the backend in the form provider or provider/model.
+func (b Backend) String() string {
+	if b.Model == "" {
+		return b.Provider.String()
+	}
+	return b.Provider.String() + "/" + b.Model
+}
+
+// Client is a composite core.Generative that sends each request to the first backend
+// and falls back to the next one on transport errors, rate limits and server errors.
+type Client struct {
+	backends   []Backend
+	retryable  func(error) bool
+	onFallback func(failed, next Backend, err error)
+}
+
+// Backends returns the backends of the chain, in the order they are tried.
+func (c *Client) Backends() []Backend {
+	return slices.Clone(c.backends)
+}
+
+// Completion is a method on the Client struct that takes a context.Context and a string argument
+func (c *Client) Completion(ctx context.Context, content string) (*core.Response, error) {
+	return c.do(ctx, func(b Backend) (*core.Response, error) {
+		return b.Client.Completion(ctx, content)
+	}, nil)
+}
+
+// GetSummaryPrefix is an API call to get a summary prefix using the first available backend.
+func (c *Client) GetSummaryPrefix(ctx context.Context, content string) (*core.Response, error) {
+	return c.do(ctx, func(b Backend) (*core.Response, error) {
+		return b.Client.GetSummaryPrefix(ctx, content)
+	}, nil)
+}
+
+// GetReviewFindings is an API call to get review findings using the first available backend.
+func (c *Client) GetReviewFindings(ctx context.Context, content string) (*core.Response, error) {
+	return c.do(ctx, func(b Backend) (*core.Response, error) {
+		return b.Client.GetReviewFindings(ctx, content)
+	}, nil)
+}
+
+// CompletionStream streams completion tokens to the writer as they arrive.
+// Once a backend has written any output, its errors are returned as is,
+// so the writer never receives a mix of partial answers from different backends.
+func (c *Client) CompletionStream(
+	ctx context.Context,
+	content string,
+	w io.Writer,
+) (*core.Response, error) {
+	cw := &countingWriter{w: w}
+	return c.do(ctx, func(b Backend) (*core.Response, error) {
+		return b.Client.CompletionStream(ctx, content, cw)
+	}, cw)
+}
+
+// do runs call against each backend in order until one succeeds or
+// returns an error that should not be retried on the next backend.
+func (c *Client) do(
+	ctx context.Context,
+	call func(Backend) (*core.Response, error),
+	cw *countingWriter,
+) (*core.Response, error) {
+	var errs []error
+	for i, b := range c.backends {
+		resp, err := call(b)
+		if err == nil {
+			resp.Usage.Provider = b.Provider.String()
+			resp.Usage.Model = b.Model
+			return resp, nil
+		}
+
+		err = fmt.Errorf("%s: %w", b, err)
+		errs = append(errs, err)
+
+		last := i == len(c.backends)-1
+		if last || ctx.Err() != nil || !c.retryable(err) || (cw != nil && cw.n > 0) {
+			break
+		}
+
+		if c.onFallback != nil {
+			c.onFallback(b, c.backends[i+1], err)
+		}
+	}
+
+	if len(errs) == 1 {
+		return nil, errs[0]
+	}
+	return nil, fmt.Errorf("no provider could answer the request: %w", errors.Join(errs...))
+}
+
+// countingWriter records how many bytes were written through it.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += n
+	return n, err
+}
+
+// New creates a new fallback Client with the provided options.
+func New(opts ...Option) (*Client, error) {
+	// Create a new config object with the given options.
+	cfg := newConfig(opts...)
+
+	// Validate the config object, returning an error if it is invalid.
+	if err := cfg.valid(); err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		backends:   cfg.backends,
+		retryable:  cfg.retryable,
+		onFallback: cfg.onFallback,
+	}, nil
+}
@@ -0,0 +1,183 @@
+package fallback
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/provider/ollama"
+)
+
+// stubClient is a core.Generative returning a fixed response or error.
+type stubClient struct {
+	content string
+	partial string
+	err     error
+	calls   int
+}
+
+func (s *stubClient) Completion(ctx context.Context, content string) (*core.Response, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &core.Response{Content: s.content, Usage: core.Usage{TotalTokens: 1}}, nil
+}
+
+func (s *stubClient) GetSummaryPrefix(ctx context.Context, content string) (*core.Response, error) {
+	return s.Completion(ctx, content)
+}
+
+func (s *stubClient) GetReviewFindings(ctx context.Context, content string) (*core.Response, error) {
+	return s.Completion(ctx, content)
+}
+
+func (s *stubClient) CompletionStream(
+	ctx context.Context,
+	content string,
+	w io.Writer,
+) (*core.Response, error) {
+	s.calls++
+	if s.partial != "" {
+		_, _ = io.WriteString(w, s.partial)
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	_, _ = io.WriteString(w, s.content)
+	return &core.Response{Content: s.content}, nil
+}
+
+var errUnavailable = &ollama.APIError{StatusCode: http.StatusServiceUnavailable, Message: "down"}
+
+func TestCompletionFallsBackOnRetryableError(t *testing.T) {
+	primary := &stubClient{err: errUnavailable}
+	secondary := &stubClient{content: "hello"}
+
+	var notified []string
+	client, err := New(
+		WithBackend(core.Anthropic, "claude", primary),
+		WithBackend(core.OpenAI, "gpt-4o", secondary),
+		WithOnFallback(func(failed, next Backend, err error) {
+			notified = append(notified, failed.String()+"->"+next.String())
+		}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	resp, err := client.Completion(context.Background(), "prompt")
+	if err != nil {
+		t.Fatalf("Completion failed: %v", err)
+	}
+
+	if resp.Content != "hello" {
+		t.Errorf("expected content %q, got %q", "hello", resp.Content)
+	}
+	if resp.Usage.Provider != "openai" || resp.Usage.Model != "gpt-4o" {
+		t.Errorf("unexpected backend in usage: %s/%s", resp.Usage.Provider, resp.Usage.Model)
+	}
+	if len(notified) != 1 || notified[0] != "anthropic/claude->openai/gpt-4o" {
+		t.Errorf("unexpected fallback notifications: %v", notified)
+	}
+}
+
+func TestCompletionStopsOnNonRetryableError(t *testing.T) {
+	primary := &stubClient{err: &ollama.APIError{StatusCode: http.StatusBadRequest}}
+	secondary := &stubClient{content: "hello"}
+
+	client, err := New(
+		WithBackend(core.Ollama, "llama3", primary),
+		WithBackend(core.OpenAI, "gpt-4o", secondary),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if _, err := client.GetSummaryPrefix(context.Background(), "prompt"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if secondary.calls != 0 {
+		t.Errorf("expected secondary backend not to be called, got %d calls", secondary.calls)
+	}
+}
+
+func TestCompletionAllBackendsFail(t *testing.T) {
+	client, err := New(
+		WithBackend(core.Ollama, "llama3", &stubClient{err: errUnavailable}),
+		WithBackend(core.Gemini, "gemini-2.0-flash", &stubClient{err: errUnavailable}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	_, err = client.Completion(context.Background(), "prompt")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	for _, name := range []string{"ollama/llama3", "gemini/gemini-2.0-flash"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("expected error to mention %s, got %v", name, err)
+		}
+	}
+	if !errors.Is(err, errUnavailable) {
+		t.Errorf("expected error to wrap the backend error, got %v", err)
+	}
+}
+
+func TestCompletionStreamNoFallbackAfterPartialOutput(t *testing.T) {
+	primary := &stubClient{partial: "Hel", err: errUnavailable}
+	secondary := &stubClient{content: "hello"}
+
+	client, err := New(
+		WithBackend(core.Anthropic, "claude", primary),
+		WithBackend(core.OpenAI, "gpt-4o", secondary),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := client.CompletionStream(context.Background(), "prompt", &buf); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if secondary.calls != 0 {
+		t.Errorf("expected secondary backend not to be called, got %d calls", secondary.calls)
+	}
+	if buf.String() != "Hel" {
+		t.Errorf("unexpected writer output %q", buf.String())
+	}
+}
+
+func TestCompletionStreamFallsBackBeforeOutput(t *testing.T) {
+	primary := &stubClient{err: errUnavailable}
+	secondary := &stubClient{content: "hello"}
+
+	client, err := New(
+		WithBackend(core.Anthropic, "claude", primary),
+		WithBackend(core.OpenAI, "gpt-4o", secondary),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	var buf bytes.Buffer
+	resp, err := client.CompletionStream(context.Background(), "prompt", &buf)
+	if err != nil {
+		t.Fatalf("CompletionStream failed: %v", err)
+	}
+	if resp.Content != "hello" || buf.String() != "hello" {
+		t.Errorf("unexpected output: content %q, writer %q", resp.Content, buf.String())
+	}
+}
+
+func TestNewWithoutBackends(t *testing.T) {
+	if _, err := New(); !errors.Is(err, errorsMissingBackend) {
+		t.Errorf("expected errorsMissingBackend, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package fallback
+
+import (
+	"errors"
+
+	"github.com/appleboy/CodeGPT/core"
+)
+
+var errorsMissingBackend = errors.New("at least one backend is required")
+
+// Option is an interface that specifies instrumentation configuration options.
+type Option interface {
+	apply(*config)
+}
+
+// optionFunc is a type of function that can be used to implement the Option interface.
+// It takes a pointer to a config struct and modifies it.
+type optionFunc func(*config)
+
+// Ensure that optionFunc satisfies the Option interface.
+var _ Option = (*optionFunc)(nil)
+
+// The apply method of optionFunc type is implemented here to modify the config struct based on the function passed.
+func (o optionFunc) apply(c *config) {
+	o(c)
+}
+
+// WithBackend returns an Option that appends a backend to the fallback chain.
+// Backends are tried in the order they are added.
+func WithBackend(provider core.Platform, model string, client core.Generative) Option {
+	return optionFunc(func(c *config) {
+		c.backends = append(c.backends, Backend{
+			Provider: provider,
+			Model:    model,
+			Client:   client,
+		})
+	})
+}
+
+// WithRetryable returns an Option that overrides the function deciding
+// whether an error should move the request on to the next backend.
+func WithRetryable(fn func(error) bool) Option {
+	return optionFunc(func(c *config) {
+		if fn == nil {
+			return
+		}
+		c.retryable = fn
+	})
+}
+
+// WithOnFallback returns an Option that sets a callback invoked every time
+// a backend fails and the request is handed to the next one.
+func WithOnFallback(fn func(failed, next Backend, err error)) Option {
+	return optionFunc(func(c *config) {
+		c.onFallback = fn
+	})
+}
+
+// config is a struct that stores configuration options for the instrumentation.
+type config struct {
+	backends   []Backend
+	retryable  func(error) bool
+	onFallback func(failed, next Backend, err error)
+}
+
+// valid checks whether a config object is valid, returning an error if it is not.
+func (cfg *config) valid() error {
+	if len(cfg.backends) == 0 {
+		return errorsMissingBackend
+	}
+
+	for _, b := range cfg.backends {
+		if b.Client == nil {
+			return errors.New("missing client for backend " + b.String())
+		}
+	}
+
+	// If all checks pass, return nil (no error).
+	return nil
+}
+
+// newConfig creates a new config object with default values, and applies the given options.
+func newConfig(opts ...Option) *config {
+	// Create a new config object with default values.
+	c := &config{
+		retryable: IsRetryable,
+	}
+
+	// Apply each of the given options to the config object.
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+
+	// Return the resulting config object.
+	return c
+}
@@ -0,0 +1,87 @@
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/core/transport"
+)
+
+// newCassetteClient returns a client that replays testdata/cassettes/gemini.json.
+// Delete the cassette and run the tests with CODEGPT_CASSETTE_MODE=record and
+// GEMINI_API_KEY set to record it again against the real API.
+func newCassetteClient(t *testing.T) *Client {
+	t.Helper()
+
+	t.Setenv(transport.CassetteEnv, filepath.Join("testdata", "cassettes", "gemini.json"))
+	token := "test-token"
+	if os.Getenv(transport.CassetteModeEnv) == string(transport.ModeRecord) {
+		token = os.Getenv("GEMINI_API_KEY")
+		if token == "" {
+			t.Skip("GEMINI_API_KEY is required to record cassettes")
+		}
+	}
+
+	client, err := New(
+		context.Background(),
+		WithToken(token),
+		WithModel("gemini-2.0-flash"),
+		WithMaxRetries(0),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client
+}
+
+func TestCassetteCompletion(t *testing.T) {
+	client := newCassetteClient(t)
+
+	resp, err := client.Completion(context.Background(), "Summarize this diff")
+	if err != nil {
+		t.Fatalf("Completion failed: %v", err)
+	}
+	if resp.Content != "Add retry support to the HTTP transport." {
+		t.Errorf("unexpected content %q", resp.Content)
+	}
+	if resp.Usage.PromptTokens != 5 || resp.Usage.CompletionTokens != 9 {
+		t.Errorf("unexpected usage %+v", resp.Usage)
+	}
+}
+
+func TestCassetteCompletionStream(t *testing.T) {
+	client := newCassetteClient(t)
+
+	var buf bytes.Buffer
+	resp, err := client.CompletionStream(context.Background(), "Summarize this diff", &buf)
+	if err != nil {
+		t.Fatalf("CompletionStream failed: %v", err)
+	}
+	if resp.Content != "Add retry support to the HTTP transport." {
+		t.Errorf("unexpected content %q", resp.Content)
+	}
+	if buf.String() != resp.Content {
+		t.Errorf("expected writer output %q, got %q", resp.Content, buf.String())
+	}
+	if resp.Usage.PromptTokens != 5 || resp.Usage.CompletionTokens != 9 {
+		t.Errorf("unexpected usage %+v", resp.Usage)
+	}
+}
+
+func TestCassetteGetSummaryPrefix(t *testing.T) {
+	client := newCassetteClient(t)
+
+	resp, err := client.GetSummaryPrefix(context.Background(), "Summarize this diff")
+	if err != nil {
+		t.Fatalf("GetSummaryPrefix failed: %v", err)
+	}
+	if resp.Content != "feat(transport)" {
+		t.Errorf("unexpected content %q", resp.Content)
+	}
+	if resp.Usage.PromptTokens != 64 {
+		t.Errorf("unexpected usage %+v", resp.Usage)
+	}
+}
@@ -1,6 +1,10 @@
 package gemini
 
-import "google.golang.org/genai"
+import (
+	"github.com/appleboy/CodeGPT/core"
+
+	"google.golang.org/genai"
+)
 
 var summaryPrefixFunc = &genai.Tool{
 	FunctionDeclarations: []*genai.FunctionDeclaration{{
@@ -28,3 +32,13 @@ var summaryPrefixFunc = &genai.Tool{
 		},
 	}},
 }
+
+// reviewFindingsFunc reports structured code review findings, described by the
+// JSON schema shared with the other providers.
+var reviewFindingsFunc = &genai.Tool{
+	FunctionDeclarations: []*genai.FunctionDeclaration{{
+		Name:                 core.ReviewFindingsFunc,
+		Description:          "Report the findings of a code review",
+		ParametersJsonSchema: core.ReviewSchema,
+	}},
+}
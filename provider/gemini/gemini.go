@@ -2,6 +2,7 @@ package gemini
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/core/capability"
 	"github.com/appleboy/CodeGPT/core/transport"
 	"github.com/appleboy/CodeGPT/version"
 
@@ -19,21 +21,54 @@ import (
 )
 
 type Client struct {
-	client      *genai.Client
-	model       string
-	maxTokens   int32
-	temperature float32
-	topP        float32
-	debug       bool
+	client       *genai.Client
+	model        string
+	maxTokens    int32
+	temperature  float32
+	topP         float32
+	debug        bool
+	systemPrompt string
+	caps         capability.Model
 }
 
-// Completion is a method on the Client struct that takes a context.Context and a string argument
-func (c *Client) Completion(ctx context.Context, content string) (*core.Response, error) {
+// systemInstruction returns the system prompt for the request as Gemini content,
+// or nil when there is none.
+func (c *Client) systemInstruction(ctx context.Context) *genai.Content {
+	system := core.SystemPrompt(ctx, c.systemPrompt)
+	if system == "" {
+		return nil
+	}
+	return &genai.Content{
+		Parts: []*genai.Part{
+			{
+				Text: system,
+			},
+		},
+	}
+}
+
+// newRequest builds the contents and config of a request for content with the
+// client's model parameters. Models without system instruction support get the
+// system prompt prepended to the user message, and sampling parameters are left
+// out for models that reject them.
+func (c *Client) newRequest(
+	ctx context.Context,
+	content string,
+) ([]*genai.Content, *genai.GenerateContentConfig) {
 	cfg := &genai.GenerateContentConfig{
-		TopP:            convert.ToPtr(c.topP),
-		Temperature:     convert.ToPtr(c.temperature),
 		MaxOutputTokens: c.maxTokens,
 	}
+	if c.caps.Temperature {
+		cfg.TopP = convert.ToPtr(c.topP)
+		cfg.Temperature = convert.ToPtr(c.temperature)
+	}
+
+	if c.caps.SystemPrompt {
+		cfg.SystemInstruction = c.systemInstruction(ctx)
+	} else if system := core.SystemPrompt(ctx, c.systemPrompt); system != "" {
+		content = system + "\n\n" + content
+	}
+
 	data := []*genai.Content{
 		{
 			Role: "user",
@@ -44,6 +79,12 @@ func (c *Client) Completion(ctx context.Context, content string) (*core.Response
 			},
 		},
 	}
+	return data, cfg
+}
+
+// Completion is a method on the Client struct that takes a context.Context and a string argument
+func (c *Client) Completion(ctx context.Context, content string) (*core.Response, error) {
+	data, cfg := c.newRequest(ctx, content)
 
 	resp, err := c.client.Models.GenerateContent(ctx, c.model, data, cfg)
 	if err != nil {
@@ -69,27 +110,18 @@ func (c *Client) Completion(ctx context.Context, content string) (*core.Response
 }
 
 // CompletionStream streams completion tokens to the writer as they arrive.
+// Models without streaming support write the whole completion at once.
 func (c *Client) CompletionStream(
 	ctx context.Context,
 	content string,
 	w io.Writer,
 ) (*core.Response, error) {
-	cfg := &genai.GenerateContentConfig{
-		TopP:            convert.ToPtr(c.topP),
-		Temperature:     convert.ToPtr(c.temperature),
-		MaxOutputTokens: c.maxTokens,
-	}
-	data := []*genai.Content{
-		{
-			Role: "user",
-			Parts: []*genai.Part{
-				{
-					Text: content,
-				},
-			},
-		},
+	if !c.caps.Streaming {
+		return core.WriteCompletion(ctx, c, content, w)
 	}
 
+	data, cfg := c.newRequest(ctx, content)
+
 	var sb strings.Builder
 	var writeErr error
 	usage := core.Usage{}
@@ -134,28 +166,19 @@ func (c *Client) CompletionStream(
 }
 
 // GetSummaryPrefix is an API call to get a summary prefix using function call.
+// Models without tool support answer with a regular completion instead.
 func (c *Client) GetSummaryPrefix(ctx context.Context, content string) (*core.Response, error) {
-	cfg := &genai.GenerateContentConfig{
-		MaxOutputTokens: c.maxTokens,
-		TopP:            convert.ToPtr(c.topP),
-		Temperature:     convert.ToPtr(c.temperature),
-		Tools:           []*genai.Tool{summaryPrefixFunc},
-		ToolConfig: &genai.ToolConfig{
-			FunctionCallingConfig: &genai.FunctionCallingConfig{
-				Mode: genai.FunctionCallingConfigModeAny,
-				AllowedFunctionNames: []string{
-					"get_summary_prefix",
-				},
-			},
-		},
+	if !c.caps.Tools {
+		return c.Completion(ctx, content)
 	}
-	data := []*genai.Content{
-		{
-			Role: "user",
-			Parts: []*genai.Part{
-				{
-					Text: content,
-				},
+
+	data, cfg := c.newRequest(ctx, content)
+	cfg.Tools = []*genai.Tool{summaryPrefixFunc}
+	cfg.ToolConfig = &genai.ToolConfig{
+		FunctionCallingConfig: &genai.FunctionCallingConfig{
+			Mode: genai.FunctionCallingConfigModeAny,
+			AllowedFunctionNames: []string{
+				"get_summary_prefix",
 			},
 		},
 	}
@@ -210,6 +233,70 @@ func (c *Client) GetSummaryPrefix(ctx context.Context, content string) (*core.Re
 	return r, nil
 }
 
+// GetReviewFindings is an API call to get structured review findings using function call.
+// Models without tool support answer with a regular completion instead.
+func (c *Client) GetReviewFindings(ctx context.Context, content string) (*core.Response, error) {
+	if !c.caps.Tools {
+		return c.Completion(ctx, content)
+	}
+
+	data, cfg := c.newRequest(ctx, content)
+	cfg.Tools = []*genai.Tool{reviewFindingsFunc}
+	cfg.ToolConfig = &genai.ToolConfig{
+		FunctionCallingConfig: &genai.FunctionCallingConfig{
+			Mode: genai.FunctionCallingConfigModeAny,
+			AllowedFunctionNames: []string{
+				core.ReviewFindingsFunc,
+			},
+		},
+	}
+
+	resp, err := c.client.Models.GenerateContent(ctx, c.model, data, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := core.Usage{}
+	if resp.UsageMetadata != nil {
+		usage.PromptTokens = int(resp.UsageMetadata.PromptTokenCount)
+		usage.CompletionTokens = int(resp.UsageMetadata.CandidatesTokenCount)
+		usage.TotalTokens = int(resp.UsageMetadata.TotalTokenCount)
+		if resp.UsageMetadata.CachedContentTokenCount > 0 {
+			usage.PromptTokensDetails = &openai.PromptTokensDetails{
+				CachedTokens: int(resp.UsageMetadata.CachedContentTokenCount),
+			}
+		}
+	}
+
+	if len(resp.Candidates) == 0 {
+		return nil, errors.New("no candidates found")
+	}
+
+	cand := resp.Candidates[0]
+	if cand.Content == nil || len(cand.Content.Parts) == 0 {
+		return nil, errors.New("no content found")
+	}
+
+	part := cand.Content.Parts[0]
+	if part.FunctionCall == nil || part.FunctionCall.Name != core.ReviewFindingsFunc {
+		return nil, errors.New("no function call found")
+	}
+
+	args, err := json.Marshal(part.FunctionCall.Args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode review findings: %w", err)
+	}
+
+	if c.debug {
+		_ = godump.Dump(resp.Candidates)
+	}
+
+	return &core.Response{
+		Content: string(args),
+		Usage:   usage,
+	}, nil
+}
+
 func New(ctx context.Context, opts ...Option) (c *Client, err error) {
 	// Create a new config object with the given options.
 	cfg := newConfig(opts...)
@@ -219,13 +306,17 @@ func New(ctx context.Context, opts ...Option) (c *Client, err error) {
 		return nil, err
 	}
 
-	// Inject x-app-name and x-app-version headers using core/transport.DefaultHeaderTransport
+	// Inject x-app-name and x-app-version headers using core/transport.DefaultHeaderTransport,
+	// retry rate limited or failed requests, and record or replay them when CODEGPT_CASSETTE is set
 	httpClient := &http.Client{
-		Transport: &transport.DefaultHeaderTransport{
-			Origin:     http.DefaultTransport,
-			Header:     nil,
-			AppName:    version.App,
-			AppVersion: version.Version,
+		Transport: &transport.RetryTransport{
+			Origin: &transport.DefaultHeaderTransport{
+				Origin:     transport.WithCassette(http.DefaultTransport),
+				Header:     nil,
+				AppName:    version.App,
+				AppVersion: version.Version,
+			},
+			MaxRetries: cfg.maxRetries,
 		},
 	}
 
@@ -254,11 +345,13 @@ func New(ctx context.Context, opts ...Option) (c *Client, err error) {
 	}
 
 	engine := &Client{
-		client:      client,
-		model:       cfg.model,
-		maxTokens:   cfg.maxTokens,
-		temperature: cfg.temperature,
-		topP:        cfg.topP,
+		client:       client,
+		model:        cfg.model,
+		maxTokens:    cfg.maxTokens,
+		temperature:  cfg.temperature,
+		topP:         cfg.topP,
+		systemPrompt: cfg.systemPrompt,
+		caps:         cfg.capabilities(),
 	}
 
 	return engine, nil
@@ -3,6 +3,8 @@ package gemini
 import (
 	"errors"
 
+	"github.com/appleboy/CodeGPT/core/capability"
+
 	"google.golang.org/genai"
 )
 
@@ -19,6 +21,9 @@ const (
 	defaultTopP        = 1.0
 )
 
+// DefaultModel is the default Gemini model to use if one is not provided.
+var DefaultModel = defaultModel
+
 // Option is an interface that specifies instrumentation configuration options.
 type Option interface {
 	apply(*config)
@@ -82,15 +87,42 @@ func WithTopP(val float32) Option {
 	})
 }
 
+// WithMaxRetries returns a new Option that sets how many times a request is retried
+// after a rate limit (429) or server error (5xx) response. Zero disables retries.
+func WithMaxRetries(val int) Option {
+	return optionFunc(func(c *config) {
+		c.maxRetries = val
+	})
+}
+
+// WithSystemPrompt returns a new Option that sets the system prompt sent with every request.
+// Empty sends no system prompt.
+func WithSystemPrompt(val string) Option {
+	return optionFunc(func(c *config) {
+		c.systemPrompt = val
+	})
+}
+
+// WithCapabilities returns a new Option that sets the capabilities of the model.
+// By default they are looked up from the built-in capability registry.
+func WithCapabilities(val capability.Model) Option {
+	return optionFunc(func(c *config) {
+		c.caps = &val
+	})
+}
+
 type config struct {
-	token       string
-	model       string
-	maxTokens   int32
-	temperature float32
-	topP        float32
-	projectID   string
-	location    string
-	backend     genai.Backend
+	token        string
+	model        string
+	maxTokens    int32
+	temperature  float32
+	topP         float32
+	projectID    string
+	location     string
+	backend      genai.Backend
+	maxRetries   int
+	systemPrompt string
+	caps         *capability.Model
 }
 
 func (cfg *config) valid() error {
@@ -136,6 +168,14 @@ func WithProject(val string) Option {
 	})
 }
 
+// capabilities returns the configured model capabilities, or the built-in ones for the model.
+func (cfg *config) capabilities() capability.Model {
+	if cfg.caps != nil {
+		return *cfg.caps
+	}
+	return capability.Lookup(cfg.model)
+}
+
 // newConfig creates a new config object with default values, and applies the given options.
 func newConfig(opts ...Option) *config {
 	c := &config{
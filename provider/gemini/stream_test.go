@@ -8,6 +8,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/appleboy/CodeGPT/core/capability"
+
 	"google.golang.org/genai"
 )
 
@@ -66,6 +68,7 @@ func TestCompletionStreamWriterOutput(t *testing.T) {
 		maxTokens:   1024,
 		temperature: 0.7,
 		topP:        1.0,
+		caps:        capability.Lookup("gemini-2.0-flash"),
 	}
 
 	var buf bytes.Buffer
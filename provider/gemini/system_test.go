@@ -0,0 +1,27 @@
+package gemini
+
+import (
+	"context"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/core"
+)
+
+func TestSystemInstruction(t *testing.T) {
+	client := &Client{}
+	if got := client.systemInstruction(context.Background()); got != nil {
+		t.Errorf("expected no system instruction, got %+v", got)
+	}
+
+	client.systemPrompt = "Follow the house style."
+	got := client.systemInstruction(context.Background())
+	if got == nil || len(got.Parts) != 1 || got.Parts[0].Text != "Follow the house style." {
+		t.Errorf("unexpected system instruction: %+v", got)
+	}
+
+	ctx := core.WithSystemPrompt(context.Background(), "Be brief.")
+	got = client.systemInstruction(ctx)
+	if got == nil || got.Parts[0].Text != "Be brief." {
+		t.Errorf("unexpected system instruction: %+v", got)
+	}
+}
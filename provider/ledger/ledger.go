@@ -0,0 +1,101 @@
+// Package ledger provides a core.Generative implementation that records the
+// token usage of every request to a usage ledger.
+package ledger
+
+import (
+	"context"
+	"io"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/core/usage"
+)
+
+var _ core.Generative = (*Client)(nil)
+
+// Client wraps a core.Generative and records each successful request.
+// Responses served from the response cache cost nothing and are not recorded.
+type Client struct {
+	client     core.Generative
+	ledger     *usage.Ledger
+	command    string
+	repository string
+	provider   string
+	model      string
+}
+
+// record appends the usage of resp to the ledger. Failing to write the ledger
+// never fails the request.
+func (c *Client) record(resp *core.Response) {
+	if resp.Usage.FromCache {
+		return
+	}
+
+	r := usage.NewRecord(resp.Usage, c.provider, c.model)
+	r.Command = c.command
+	r.Repository = c.repository
+	_ = c.ledger.Append(r)
+}
+
+// Completion is a method on the Client struct that takes a context.Context and a string argument
+func (c *Client) Completion(ctx context.Context, content string) (*core.Response, error) {
+	resp, err := c.client.Completion(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	c.record(resp)
+	return resp, nil
+}
+
+// GetSummaryPrefix asks the wrapped client for a summary prefix and records its usage.
+func (c *Client) GetSummaryPrefix(ctx context.Context, content string) (*core.Response, error) {
+	resp, err := c.client.GetSummaryPrefix(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	c.record(resp)
+	return resp, nil
+}
+
+// GetReviewFindings asks the wrapped client for review findings and records its usage.
+func (c *Client) GetReviewFindings(ctx context.Context, content string) (*core.Response, error) {
+	resp, err := c.client.GetReviewFindings(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	c.record(resp)
+	return resp, nil
+}
+
+// CompletionStream streams completion tokens to the writer as they arrive.
+func (c *Client) CompletionStream(
+	ctx context.Context,
+	content string,
+	w io.Writer,
+) (*core.Response, error) {
+	resp, err := c.client.CompletionStream(ctx, content, w)
+	if err != nil {
+		return nil, err
+	}
+	c.record(resp)
+	return resp, nil
+}
+
+// New wraps client with a usage ledger configured by the provided options.
+func New(client core.Generative, opts ...Option) (*Client, error) {
+	// Create a new config object with the given options.
+	cfg := newConfig(opts...)
+
+	// Validate the config object, returning an error if it is invalid.
+	if err := cfg.valid(); err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		client:     client,
+		ledger:     cfg.ledger,
+		command:    cfg.command,
+		repository: cfg.repository,
+		provider:   cfg.provider,
+		model:      cfg.model,
+	}, nil
+}
@@ -0,0 +1,105 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/core/usage"
+)
+
+// stubClient is a core.Generative answering with a fixed response.
+type stubClient struct {
+	usage core.Usage
+	err   error
+}
+
+func (s *stubClient) Completion(ctx context.Context, content string) (*core.Response, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &core.Response{Content: "ok", Usage: s.usage}, nil
+}
+
+func (s *stubClient) GetSummaryPrefix(ctx context.Context, content string) (*core.Response, error) {
+	return s.Completion(ctx, content)
+}
+
+func (s *stubClient) GetReviewFindings(ctx context.Context, content string) (*core.Response, error) {
+	return s.Completion(ctx, content)
+}
+
+func (s *stubClient) CompletionStream(
+	ctx context.Context,
+	content string,
+	w io.Writer,
+) (*core.Response, error) {
+	resp, err := s.Completion(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = io.WriteString(w, resp.Content)
+	return resp, nil
+}
+
+func TestNewMissingLedger(t *testing.T) {
+	if _, err := New(&stubClient{}); !errors.Is(err, errorsMissingLedger) {
+		t.Fatalf("expected errorsMissingLedger, got %v", err)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	l := usage.NewLedger(filepath.Join(t.TempDir(), "usage.jsonl"))
+	stub := &stubClient{usage: core.Usage{PromptTokens: 10, CompletionTokens: 5}}
+	client, err := New(
+		stub,
+		WithLedger(l),
+		WithCommand("commit"),
+		WithRepository("/src/codegpt"),
+		WithProvider("openai"),
+		WithModel("gpt-4o"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.Completion(ctx, "diff"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetSummaryPrefix(ctx, "diff"); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := client.CompletionStream(ctx, "diff", &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cached responses and failed requests are not recorded.
+	stub.usage.FromCache = true
+	if _, err := client.Completion(ctx, "diff"); err != nil {
+		t.Fatal(err)
+	}
+	stub.err = errors.New("boom")
+	if _, err := client.Completion(ctx, "diff"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	records, err := l.Records(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+	r := records[0]
+	if r.Command != "commit" || r.Repository != "/src/codegpt" || r.Provider != "openai" ||
+		r.Model != "gpt-4o" || r.PromptTokens != 10 || r.CompletionTokens != 5 {
+		t.Errorf("unexpected record: %+v", r)
+	}
+}
@@ -0,0 +1,96 @@
+package ledger
+
+import (
+	"errors"
+
+	"github.com/appleboy/CodeGPT/core/usage"
+)
+
+var errorsMissingLedger = errors.New("missing usage ledger")
+
+// Option is an interface that specifies instrumentation configuration options.
+type Option interface {
+	apply(*config)
+}
+
+// optionFunc is a type of function that can be used to implement the Option interface.
+// It takes a pointer to a config struct and modifies it.
+type optionFunc func(*config)
+
+// Ensure that optionFunc satisfies the Option interface.
+var _ Option = (*optionFunc)(nil)
+
+// The apply method of optionFunc type is implemented here to modify the config struct based on the function passed.
+func (o optionFunc) apply(c *config) {
+	o(c)
+}
+
+// WithLedger returns an Option that sets the ledger requests are recorded to.
+func WithLedger(val *usage.Ledger) Option {
+	return optionFunc(func(c *config) {
+		c.ledger = val
+	})
+}
+
+// WithCommand returns an Option that sets the command recorded with each request.
+func WithCommand(val string) Option {
+	return optionFunc(func(c *config) {
+		c.command = val
+	})
+}
+
+// WithRepository returns an Option that sets the repository recorded with each request.
+func WithRepository(val string) Option {
+	return optionFunc(func(c *config) {
+		c.repository = val
+	})
+}
+
+// WithProvider returns an Option that sets the provider recorded with each request,
+// unless the response names the provider that answered.
+func WithProvider(val string) Option {
+	return optionFunc(func(c *config) {
+		c.provider = val
+	})
+}
+
+// WithModel returns an Option that sets the model recorded with each request,
+// unless the response names the model that answered.
+func WithModel(val string) Option {
+	return optionFunc(func(c *config) {
+		c.model = val
+	})
+}
+
+// config is a struct that stores configuration options for the instrumentation.
+type config struct {
+	ledger     *usage.Ledger
+	command    string
+	repository string
+	provider   string
+	model      string
+}
+
+// valid checks whether a config object is valid, returning an error if it is not.
+func (cfg *config) valid() error {
+	if cfg.ledger == nil {
+		return errorsMissingLedger
+	}
+
+	// If all checks pass, return nil (no error).
+	return nil
+}
+
+// newConfig creates a new config object with default values, and applies the given options.
+func newConfig(opts ...Option) *config {
+	// Create a new config object with default values.
+	c := &config{}
+
+	// Apply each of the given options to the config object.
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+
+	// Return the resulting config object.
+	return c
+}
@@ -0,0 +1,55 @@
+package ollama
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/appleboy/CodeGPT/core"
+)
+
+// summaryPrefixSchema is the JSON schema passed as the structured output format
+// when asking the model for a conventional commit prefix and scope.
+var summaryPrefixSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "prefix": {
+      "type": "string",
+      "enum": ["build", "chore", "ci", "docs", "feat", "fix", "perf", "refactor", "style", "test"],
+      "description": "The prefix to use for the summary"
+    },
+    "scope": {
+      "type": "string",
+      "description": "A short lowercase word identifying the module, package, or component most central to the change"
+    }
+  },
+  "required": ["prefix", "scope"]
+}`)
+
+// summaryPrefix is the structured output returned for summaryPrefixSchema.
+type summaryPrefix struct {
+	Prefix string `json:"prefix"`
+	Scope  string `json:"scope"`
+}
+
+// parseSummaryPrefix decodes the structured output of the model into a summaryPrefix.
+func parseSummaryPrefix(data string) (summaryPrefix, error) {
+	var result summaryPrefix
+	if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &result); err != nil {
+		return result, err
+	}
+	if result.Prefix == "" {
+		return result, errors.New("no prefix found")
+	}
+	return result, nil
+}
+
+// reviewSchema is the JSON schema passed as the structured output format when
+// asking the model for code review findings.
+var reviewSchema = func() json.RawMessage {
+	data, err := json.Marshal(core.ReviewSchema)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}()
@@ -0,0 +1,355 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/core/capability"
+	"github.com/appleboy/CodeGPT/core/transport"
+	"github.com/appleboy/CodeGPT/proxy"
+	"github.com/appleboy/CodeGPT/version"
+)
+
+var _ core.Generative = (*Client)(nil)
+
+// Client is a struct that represents a client for the native Ollama chat API.
+type Client struct {
+	httpClient   *http.Client
+	baseURL      string
+	model        string
+	maxTokens    int
+	temperature  float32
+	topP         float32
+	numCtx       int
+	keepAlive    string
+	format       string
+	systemPrompt string
+	caps         capability.Model
+}
+
+// APIError is returned when the Ollama server responds with a non-2xx status code.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("chat error, status: %d, message: %s", e.StatusCode, e.Message)
+}
+
+// message is a single chat message in the Ollama chat API.
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// options holds the model parameters supported by the Ollama chat API.
+type options struct {
+	NumCtx      int     `json:"num_ctx,omitempty"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+	Temperature float32 `json:"temperature,omitempty"`
+	TopP        float32 `json:"top_p,omitempty"`
+}
+
+// chatRequest is the request body of POST /api/chat.
+type chatRequest struct {
+	Model     string          `json:"model"`
+	Messages  []message       `json:"messages"`
+	Stream    bool            `json:"stream"`
+	Format    json.RawMessage `json:"format,omitempty"`
+	KeepAlive string          `json:"keep_alive,omitempty"`
+	Options   options         `json:"options"`
+}
+
+// chatResponse is a response (or a single NDJSON chunk when streaming) of POST /api/chat.
+type chatResponse struct {
+	Model           string  `json:"model"`
+	Message         message `json:"message"`
+	Done            bool    `json:"done"`
+	PromptEvalCount int     `json:"prompt_eval_count"`
+	EvalCount       int     `json:"eval_count"`
+	Error           string  `json:"error"`
+}
+
+// usage converts the token counters of the final response to a core.Usage.
+func (r *chatResponse) usage() core.Usage {
+	return core.Usage{
+		PromptTokens:     r.PromptEvalCount,
+		CompletionTokens: r.EvalCount,
+		TotalTokens:      r.PromptEvalCount + r.EvalCount,
+	}
+}
+
+// newRequest builds a chatRequest with the client's model parameters.
+// The system prompt carried by ctx overrides the configured one. Models without
+// system prompt support get it prepended to the user message, and sampling
+// parameters are left out for models that reject them.
+func (c *Client) newRequest(ctx context.Context, content string, stream bool) chatRequest {
+	var messages []message
+	if system := core.SystemPrompt(ctx, c.systemPrompt); system != "" {
+		if c.caps.SystemPrompt {
+			messages = append(messages, message{
+				Role:    "system",
+				Content: system,
+			})
+		} else {
+			content = system + "\n\n" + content
+		}
+	}
+	messages = append(messages, message{
+		Role:    "user",
+		Content: content,
+	})
+
+	req := chatRequest{
+		Model:     c.model,
+		Messages:  messages,
+		Stream:    stream,
+		KeepAlive: c.keepAlive,
+		Options: options{
+			NumCtx:     c.numCtx,
+			NumPredict: c.maxTokens,
+		},
+	}
+	if c.caps.Temperature {
+		req.Options.Temperature = c.temperature
+		req.Options.TopP = c.topP
+	}
+	if c.format != "" {
+		req.Format = json.RawMessage(`"` + c.format + `"`)
+	}
+	return req
+}
+
+// post sends the chat request to the Ollama server and returns the raw HTTP response.
+// Non-2xx responses are converted to errors using the error message returned by Ollama.
+func (c *Client) post(ctx context.Context, body chatRequest) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		strings.TrimRight(c.baseURL, "/")+"/api/chat",
+		bytes.NewReader(data),
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("chat error: %w", err)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(resp.Body)
+		apiErr := &APIError{
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(raw)),
+		}
+		var e chatResponse
+		if json.Unmarshal(raw, &e) == nil && e.Error != "" {
+			apiErr.Message = e.Error
+		}
+		return nil, apiErr
+	}
+
+	return resp, nil
+}
+
+// chat sends a non-streaming chat request and decodes the response.
+func (c *Client) chat(ctx context.Context, body chatRequest) (*chatResponse, error) {
+	resp, err := c.post(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var r chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("failed to decode chat response: %w", err)
+	}
+	if r.Error != "" {
+		return nil, fmt.Errorf("chat error: %s", r.Error)
+	}
+
+	return &r, nil
+}
+
+// Completion is a method on the Client struct that takes a context.Context and a string argument
+func (c *Client) Completion(ctx context.Context, content string) (*core.Response, error) {
+	resp, err := c.chat(ctx, c.newRequest(ctx, content, false))
+	if err != nil {
+		return nil, err
+	}
+
+	return &core.Response{
+		Content: resp.Message.Content,
+		Usage:   resp.usage(),
+	}, nil
+}
+
+// CompletionStream streams completion tokens to the writer as they arrive.
+// Ollama streams newline-delimited JSON objects; the last one has done set to true
+// and carries the token counters. Models without streaming support write the
+// whole completion at once.
+func (c *Client) CompletionStream(
+	ctx context.Context,
+	content string,
+	w io.Writer,
+) (*core.Response, error) {
+	if !c.caps.Streaming {
+		return core.WriteCompletion(ctx, c, content, w)
+	}
+
+	resp, err := c.post(ctx, c.newRequest(ctx, content, true))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var sb strings.Builder
+	var usage core.Usage
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var chunk chatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return nil, fmt.Errorf("chat error: %s", chunk.Error)
+		}
+
+		if text := chunk.Message.Content; text != "" {
+			sb.WriteString(text)
+			if _, err := io.WriteString(w, text); err != nil {
+				return nil, fmt.Errorf("writing streamed completion: %w", err)
+			}
+		}
+
+		if chunk.Done {
+			usage = chunk.usage()
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &core.Response{
+		Content: sb.String(),
+		Usage:   usage,
+	}, nil
+}
+
+// GetSummaryPrefix asks the model for a conventional commit prefix using structured outputs.
+// The JSON schema is sent as the request format, so it also works on models without tool support.
+func (c *Client) GetSummaryPrefix(ctx context.Context, content string) (*core.Response, error) {
+	req := c.newRequest(ctx, content, false)
+	req.Format = summaryPrefixSchema
+
+	resp, err := c.chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := parseSummaryPrefix(resp.Message.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse summary prefix: %w", err)
+	}
+
+	return &core.Response{
+		Content: fmt.Sprintf("%s(%s)", result.Prefix, result.Scope),
+		Usage:   resp.usage(),
+	}, nil
+}
+
+// GetReviewFindings asks the model for structured code review findings using structured outputs.
+// The JSON schema is sent as the request format, so it also works on models without tool support.
+func (c *Client) GetReviewFindings(ctx context.Context, content string) (*core.Response, error) {
+	req := c.newRequest(ctx, content, false)
+	req.Format = reviewSchema
+
+	resp, err := c.chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &core.Response{
+		Content: resp.Message.Content,
+		Usage:   resp.usage(),
+	}, nil
+}
+
+// New creates a new Client instance with the provided options.
+func New(opts ...Option) (*Client, error) {
+	// Create a new config object with the given options.
+	cfg := newConfig(opts...)
+
+	// Validate the config object, returning an error if it is invalid.
+	if err := cfg.valid(); err != nil {
+		return nil, err
+	}
+
+	if cfg.format != "" && cfg.format != "json" {
+		return nil, errors.New("unsupported format, only json is allowed")
+	}
+
+	httpClient, err := proxy.New(
+		proxy.WithProxyURL(cfg.proxyURL),
+		proxy.WithSocksURL(cfg.socksURL),
+		proxy.WithSkipVerify(cfg.skipVerify),
+		proxy.WithTimeout(cfg.timeout),
+		proxy.WithHeaders(cfg.headers),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("can't create a new HTTP client: %w", err)
+	}
+
+	// Inject x-app-name and x-app-version headers using core/transport.DefaultHeaderTransport,
+	// retry rate limited or failed requests, and record or replay them when CODEGPT_CASSETTE is set
+	httpClient.Transport = &transport.RetryTransport{
+		Origin: &transport.DefaultHeaderTransport{
+			Origin:     transport.WithCassette(httpClient.Transport),
+			Header:     nil,
+			AppName:    version.App,
+			AppVersion: version.Version,
+		},
+		MaxRetries: cfg.maxRetries,
+	}
+
+	engine := &Client{
+		httpClient:   httpClient,
+		baseURL:      cfg.baseURL,
+		model:        cfg.model,
+		maxTokens:    cfg.maxTokens,
+		temperature:  cfg.temperature,
+		topP:         cfg.topP,
+		numCtx:       cfg.numCtx,
+		keepAlive:    cfg.keepAlive,
+		format:       cfg.format,
+		systemPrompt: cfg.systemPrompt,
+		caps:         cfg.capabilities(),
+	}
+
+	return engine, nil
+}
@@ -0,0 +1,282 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/core/capability"
+)
+
+func TestCompletion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if req.Stream {
+			t.Error("expected stream to be false")
+		}
+		if req.Options.NumCtx != 8192 {
+			t.Errorf("expected num_ctx 8192, got %d", req.Options.NumCtx)
+		}
+		if req.KeepAlive != "10m" {
+			t.Errorf("expected keep_alive 10m, got %q", req.KeepAlive)
+		}
+		if string(req.Format) != `"json"` {
+			t.Errorf("expected format json, got %s", req.Format)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"model":"llama3","message":{"role":"assistant","content":"{\"ok\":true}"},"done":true,"prompt_eval_count":5,"eval_count":3}`))
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithBaseURL(server.URL),
+		WithModel("llama3"),
+		WithNumCtx(8192),
+		WithKeepAlive("10m"),
+		WithFormat("json"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	resp, err := client.Completion(context.Background(), "test prompt")
+	if err != nil {
+		t.Fatalf("Completion failed: %v", err)
+	}
+
+	if resp.Content != `{"ok":true}` {
+		t.Errorf("unexpected content %q", resp.Content)
+	}
+	if resp.Usage.TotalTokens != 8 {
+		t.Errorf("expected total tokens 8, got %d", resp.Usage.TotalTokens)
+	}
+}
+
+func TestGetSummaryPrefix(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+
+		// The JSON schema must be sent as the structured output format
+		var schema map[string]any
+		if err := json.Unmarshal(req.Format, &schema); err != nil {
+			t.Errorf("expected format to be a JSON schema object: %v", err)
+		}
+		if schema["type"] != "object" {
+			t.Errorf("expected schema type object, got %v", schema["type"])
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"model":"llama3","message":{"role":"assistant","content":"{\"prefix\":\"feat\",\"scope\":\"ollama\"}"},"done":true,"prompt_eval_count":20,"eval_count":7}`))
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithBaseURL(server.URL),
+		WithModel("llama3"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	resp, err := client.GetSummaryPrefix(context.Background(), "test prompt")
+	if err != nil {
+		t.Fatalf("GetSummaryPrefix failed: %v", err)
+	}
+
+	if resp.Content != "feat(ollama)" {
+		t.Errorf("expected content %q, got %q", "feat(ollama)", resp.Content)
+	}
+	if resp.Usage.TotalTokens != 27 {
+		t.Errorf("expected total tokens 27, got %d", resp.Usage.TotalTokens)
+	}
+}
+
+func TestGetReviewFindings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+
+		// The review schema must be sent as the structured output format
+		var schema struct {
+			Required []string `json:"required"`
+		}
+		if err := json.Unmarshal(req.Format, &schema); err != nil {
+			t.Errorf("expected format to be a JSON schema object: %v", err)
+		}
+		if len(schema.Required) != 2 || schema.Required[1] != "findings" {
+			t.Errorf("expected the review schema, got %s", req.Format)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"model":"llama3","message":{"role":"assistant","content":"{\"summary\":\"ok\",\"findings\":[]}"},"done":true,"prompt_eval_count":20,"eval_count":7}`))
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithBaseURL(server.URL),
+		WithModel("llama3"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	resp, err := client.GetReviewFindings(context.Background(), "test prompt")
+	if err != nil {
+		t.Fatalf("GetReviewFindings failed: %v", err)
+	}
+
+	if resp.Content != `{"summary":"ok","findings":[]}` {
+		t.Errorf("unexpected content %q", resp.Content)
+	}
+	if resp.Usage.TotalTokens != 27 {
+		t.Errorf("expected total tokens 27, got %d", resp.Usage.TotalTokens)
+	}
+}
+
+func TestParseSummaryPrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    summaryPrefix
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			data: `{"prefix": "fix", "scope": "git"}`,
+			want: summaryPrefix{Prefix: "fix", Scope: "git"},
+		},
+		{
+			name: "surrounding whitespace",
+			data: "\n {\"prefix\": \"docs\", \"scope\": \"readme\"} \n",
+			want: summaryPrefix{Prefix: "docs", Scope: "readme"},
+		},
+		{
+			name:    "missing prefix",
+			data:    `{"scope": "git"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			data:    `feat(git)`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSummaryPrefix(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSummaryPrefix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseSummaryPrefix() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewUnsupportedFormat(t *testing.T) {
+	if _, err := New(WithFormat("yaml")); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
+
+func TestCompletionSystemPrompt(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		expect string
+	}{
+		{name: "configured", ctx: context.Background(), expect: "Follow the house style."},
+		{
+			name:   "per request override",
+			ctx:    core.WithSystemPrompt(context.Background(), "Be brief."),
+			expect: "Be brief.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req chatRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				if len(req.Messages) != 2 || req.Messages[0].Role != "system" ||
+					req.Messages[0].Content != tt.expect || req.Messages[1].Role != "user" {
+					t.Errorf("unexpected messages: %+v", req.Messages)
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"model":"llama3","message":{"role":"assistant","content":"ok"},"done":true}`))
+			}))
+			defer server.Close()
+
+			client, err := New(
+				WithBaseURL(server.URL),
+				WithSystemPrompt("Follow the house style."),
+			)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			if _, err := client.Completion(tt.ctx, "test prompt"); err != nil {
+				t.Fatalf("Completion failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if req.Stream {
+			t.Error("expected a non-streaming request")
+		}
+		if req.Options.Temperature != 0 || req.Options.TopP != 0 {
+			t.Errorf("expected no sampling parameters, got %+v", req.Options)
+		}
+		if len(req.Messages) != 1 || req.Messages[0].Content != "Be brief.\n\ntest prompt" {
+			t.Errorf("unexpected messages: %+v", req.Messages)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"model":"llama3","message":{"role":"assistant","content":"ok"},"done":true}`))
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithBaseURL(server.URL),
+		WithSystemPrompt("Be brief."),
+		WithTemperature(0.7),
+		WithCapabilities(capability.Model{Reasoning: true}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	var buf bytes.Buffer
+	resp, err := client.CompletionStream(context.Background(), "test prompt", &buf)
+	if err != nil {
+		t.Fatalf("CompletionStream failed: %v", err)
+	}
+	if resp.Content != "ok" || buf.String() != "ok" {
+		t.Errorf("unexpected content %q, wrote %q", resp.Content, buf.String())
+	}
+}
@@ -0,0 +1,230 @@
+package ollama
+
+import (
+	"errors"
+	"time"
+
+	"github.com/appleboy/CodeGPT/core/capability"
+)
+
+var errorsMissingModel = errors.New("missing model")
+
+const (
+	defaultBaseURL     = "http://localhost:11434"
+	defaultMaxTokens   = 300
+	defaultModel       = "llama3"
+	defaultTemperature = 1.0
+	defaultTopP        = 1.0
+)
+
+// DefaultModel is the default Ollama model to use if one is not provided.
+var DefaultModel = defaultModel
+
+// Option is an interface that specifies instrumentation configuration options.
+type Option interface {
+	apply(*config)
+}
+
+// optionFunc is a type of function that can be used to implement the Option interface.
+// It takes a pointer to a config struct and modifies it.
+type optionFunc func(*config)
+
+// Ensure that optionFunc satisfies the Option interface.
+var _ Option = (*optionFunc)(nil)
+
+// The apply method of optionFunc type is implemented here to modify the config struct based on the function passed.
+func (o optionFunc) apply(c *config) {
+	o(c)
+}
+
+// WithBaseURL returns a new Option that sets the base URL of the Ollama server.
+// It defaults to http://localhost:11434 when empty.
+func WithBaseURL(val string) Option {
+	return optionFunc(func(c *config) {
+		if val == "" {
+			return
+		}
+		c.baseURL = val
+	})
+}
+
+// WithModel is a function that returns an Option, which sets the model field of the config struct.
+func WithModel(val string) Option {
+	return optionFunc(func(c *config) {
+		c.model = val
+	})
+}
+
+// WithMaxTokens returns a new Option that sets the max tokens for the client configuration.
+// It is sent to Ollama as the num_predict option.
+func WithMaxTokens(val int) Option {
+	if val <= 0 {
+		val = defaultMaxTokens
+	}
+	return optionFunc(func(c *config) {
+		c.maxTokens = val
+	})
+}
+
+// WithTemperature returns a new Option that sets the temperature for the client configuration.
+// What sampling temperature to use, between 0 and 2.
+// Higher values like 0.8 will make the output more random,
+// while lower values like 0.2 will make it more focused and deterministic.
+func WithTemperature(val float32) Option {
+	if val <= 0 {
+		val = defaultTemperature
+	}
+	return optionFunc(func(c *config) {
+		c.temperature = val
+	})
+}
+
+// WithTopP returns a new Option that sets the topP for the client configuration.
+func WithTopP(val float32) Option {
+	return optionFunc(func(c *config) {
+		c.topP = val
+	})
+}
+
+// WithNumCtx returns a new Option that sets the context window size (num_ctx) used by the model.
+// Zero keeps the model's default.
+func WithNumCtx(val int) Option {
+	return optionFunc(func(c *config) {
+		c.numCtx = val
+	})
+}
+
+// WithKeepAlive returns a new Option that controls how long the model stays loaded in memory
+// after the request, e.g. "5m", "1h" or "-1" to keep it loaded indefinitely.
+func WithKeepAlive(val string) Option {
+	return optionFunc(func(c *config) {
+		c.keepAlive = val
+	})
+}
+
+// WithFormat returns a new Option that sets the response format for completions.
+// Use "json" to force the model to reply with valid JSON.
+func WithFormat(val string) Option {
+	return optionFunc(func(c *config) {
+		c.format = val
+	})
+}
+
+// WithProxyURL is a function that returns an Option, which sets the proxyURL field of the config struct.
+func WithProxyURL(val string) Option {
+	return optionFunc(func(c *config) {
+		c.proxyURL = val
+	})
+}
+
+// WithSocksURL is a function that returns an Option, which sets the socksURL field of the config struct.
+func WithSocksURL(val string) Option {
+	return optionFunc(func(c *config) {
+		c.socksURL = val
+	})
+}
+
+// WithSkipVerify returns a new Option that sets the skipVerify for the client configuration.
+func WithSkipVerify(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.skipVerify = val
+	})
+}
+
+// WithTimeout returns a new Option that sets the timeout for the client configuration.
+// It takes a time.Duration value representing the timeout duration.
+// It returns an optionFunc that sets the timeout field of the configuration to the provided value.
+func WithTimeout(val time.Duration) Option {
+	return optionFunc(func(c *config) {
+		c.timeout = val
+	})
+}
+
+// WithHeaders returns a new Option that sets the headers for the http client configuration.
+func WithHeaders(headers []string) Option {
+	return optionFunc(func(c *config) {
+		c.headers = headers
+	})
+}
+
+// WithMaxRetries returns a new Option that sets how many times a request is retried
+// after a rate limit (429) or server error (5xx) response. Zero disables retries.
+func WithMaxRetries(val int) Option {
+	return optionFunc(func(c *config) {
+		c.maxRetries = val
+	})
+}
+
+// WithSystemPrompt returns a new Option that sets the system prompt sent with every request.
+// Empty sends no system prompt.
+func WithSystemPrompt(val string) Option {
+	return optionFunc(func(c *config) {
+		c.systemPrompt = val
+	})
+}
+
+// WithCapabilities returns a new Option that sets the capabilities of the model.
+// By default they are looked up from the built-in capability registry.
+func WithCapabilities(val capability.Model) Option {
+	return optionFunc(func(c *config) {
+		c.caps = &val
+	})
+}
+
+// config is a struct that stores configuration options for the instrumentation.
+type config struct {
+	baseURL      string
+	model        string
+	maxTokens    int
+	temperature  float32
+	topP         float32
+	numCtx       int
+	keepAlive    string
+	format       string
+	proxyURL     string
+	socksURL     string
+	skipVerify   bool
+	timeout      time.Duration
+	headers      []string
+	maxRetries   int
+	systemPrompt string
+	caps         *capability.Model
+}
+
+// valid checks whether a config object is valid, returning an error if it is not.
+func (cfg *config) valid() error {
+	if cfg.model == "" {
+		return errorsMissingModel
+	}
+
+	// If all checks pass, return nil (no error).
+	return nil
+}
+
+// capabilities returns the configured model capabilities, or the built-in ones for the model.
+func (cfg *config) capabilities() capability.Model {
+	if cfg.caps != nil {
+		return *cfg.caps
+	}
+	return capability.Lookup(cfg.model)
+}
+
+// newConfig creates a new config object with default values, and applies the given options.
+func newConfig(opts ...Option) *config {
+	// Create a new config object with default values.
+	c := &config{
+		baseURL:     defaultBaseURL,
+		model:       defaultModel,
+		maxTokens:   defaultMaxTokens,
+		temperature: defaultTemperature,
+		topP:        defaultTopP,
+	}
+
+	// Apply each of the given options to the config object.
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+
+	// Return the resulting config object.
+	return c
+}
@@ -0,0 +1,95 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompletionStream(t *testing.T) {
+	// Create a mock server that returns Ollama NDJSON streaming chunks
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if !req.Stream {
+			t.Error("expected stream to be true")
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		chunks := []string{
+			`{"model":"llama3","message":{"role":"assistant","content":"Hello"},"done":false}`,
+			`{"model":"llama3","message":{"role":"assistant","content":" world"},"done":false}`,
+			`{"model":"llama3","message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":10,"eval_count":2}`,
+		}
+		for _, chunk := range chunks {
+			fmt.Fprintln(w, chunk)
+		}
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithBaseURL(server.URL),
+		WithModel("llama3"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	var buf bytes.Buffer
+	resp, err := client.CompletionStream(context.Background(), "test prompt", &buf)
+	if err != nil {
+		t.Fatalf("CompletionStream failed: %v", err)
+	}
+
+	expectedContent := "Hello world"
+	if resp.Content != expectedContent {
+		t.Errorf("expected content %q, got %q", expectedContent, resp.Content)
+	}
+
+	if buf.String() != expectedContent {
+		t.Errorf("expected writer output %q, got %q", expectedContent, buf.String())
+	}
+
+	if resp.Usage.PromptTokens != 10 {
+		t.Errorf("expected prompt tokens 10, got %d", resp.Usage.PromptTokens)
+	}
+
+	if resp.Usage.TotalTokens != 12 {
+		t.Errorf("expected total tokens 12, got %d", resp.Usage.TotalTokens)
+	}
+}
+
+func TestCompletionStreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"model \"missing\" not found, try pulling it first"}`)
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithBaseURL(server.URL),
+		WithModel("missing"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	var buf bytes.Buffer
+	_, err = client.CompletionStream(context.Background(), "test prompt", &buf)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected empty writer output, got %q", buf.String())
+	}
+}
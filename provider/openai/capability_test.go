@@ -0,0 +1,75 @@
+package openai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/core/capability"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestNewBaseRequestCapabilities(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            []Option
+		wantTemperature float32
+		wantMessages    int
+	}{
+		{
+			name:            "chat model",
+			opts:            []Option{WithModel("gpt-4o")},
+			wantTemperature: 0.5,
+			wantMessages:    2,
+		},
+		{
+			name:            "reasoning model",
+			opts:            []Option{WithModel("o3-mini")},
+			wantTemperature: 0,
+			wantMessages:    2,
+		},
+		{
+			name:            "model without system prompt",
+			opts:            []Option{WithModel("o1-mini")},
+			wantTemperature: 0,
+			wantMessages:    1,
+		},
+		{
+			name: "configured capabilities",
+			opts: []Option{
+				WithModel("o3-mini"),
+				WithCapabilities(capability.Model{Temperature: true, SystemPrompt: true}),
+			},
+			wantTemperature: 0.5,
+			wantMessages:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{
+				WithToken("test-token"),
+				WithTemperature(0.5),
+				WithSystemPrompt("Be brief."),
+			}, tt.opts...)
+			client, err := New(opts...)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			req := client.newBaseRequest(context.Background(), "diff")
+			if req.Temperature != tt.wantTemperature {
+				t.Errorf("expected temperature %v, got %v", tt.wantTemperature, req.Temperature)
+			}
+			if len(req.Messages) != tt.wantMessages {
+				t.Fatalf("expected %d messages, got %d", tt.wantMessages, len(req.Messages))
+			}
+			if tt.wantMessages == 1 {
+				msg := req.Messages[0]
+				if msg.Role != openai.ChatMessageRoleUser || msg.Content != "Be brief.\n\ndiff" {
+					t.Errorf("unexpected user message: %+v", msg)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package openai
 import (
 	"encoding/json"
 
+	"github.com/appleboy/CodeGPT/core"
+
 	"github.com/appleboy/com/bytesconv"
 	openai "github.com/sashabaranov/go-openai"
 	"github.com/sashabaranov/go-openai/jsonschema"
@@ -47,3 +49,10 @@ func GetSummaryPrefixArgs(data string) SummaryPrefixParams {
 	}
 	return prefix
 }
+
+// ReviewFindingsFunc is the function definition reporting structured code review findings.
+var ReviewFindingsFunc = openai.FunctionDefinition{
+	Name:        core.ReviewFindingsFunc,
+	Description: "Report the findings of a code review",
+	Parameters:  core.ReviewSchema,
+}
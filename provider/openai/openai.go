@@ -5,10 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"regexp"
+	"net/http"
 	"strings"
 
 	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/core/capability"
 	"github.com/appleboy/CodeGPT/core/transport"
 	"github.com/appleboy/CodeGPT/proxy"
 	"github.com/appleboy/CodeGPT/version"
@@ -30,6 +31,8 @@ type Client struct {
 	topP             float32
 	frequencyPenalty float32
 	presencePenalty  float32
+	systemPrompt     string
+	caps             capability.Model
 }
 
 type Response struct {
@@ -38,26 +41,39 @@ type Response struct {
 }
 
 // newBaseRequest builds a ChatCompletionRequest with the client's model parameters and
-// standard system/user messages. Callers can further customize the returned request.
-func (c *Client) newBaseRequest(content string) openai.ChatCompletionRequest {
-	return openai.ChatCompletionRequest{
+// standard system/user messages. The system prompt carried by ctx overrides the
+// configured one. Models without system prompt support get it prepended to the
+// user message, and sampling parameters are left out for models that reject them.
+// Callers can further customize the returned request.
+func (c *Client) newBaseRequest(ctx context.Context, content string) openai.ChatCompletionRequest {
+	var messages []openai.ChatCompletionMessage
+	if system := core.SystemPrompt(ctx, c.systemPrompt); system != "" {
+		if c.caps.SystemPrompt {
+			messages = append(messages, openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: system,
+			})
+		} else {
+			content = system + "\n\n" + content
+		}
+	}
+	messages = append(messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: content,
+	})
+
+	req := openai.ChatCompletionRequest{
 		Model:               c.model,
 		MaxCompletionTokens: c.maxTokens,
-		Temperature:         c.temperature,
-		TopP:                c.topP,
-		FrequencyPenalty:    c.frequencyPenalty,
-		PresencePenalty:     c.presencePenalty,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleAssistant,
-				Content: "You are a helpful assistant.",
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: content,
-			},
-		},
+		Messages:            messages,
+	}
+	if c.caps.Temperature {
+		req.Temperature = c.temperature
+		req.TopP = c.topP
+		req.FrequencyPenalty = c.frequencyPenalty
+		req.PresencePenalty = c.presencePenalty
 	}
+	return req
 }
 
 // convertUsage converts an openai.Usage to a core.Usage.
@@ -85,12 +101,17 @@ func (c *Client) Completion(ctx context.Context, content string) (*core.Response
 }
 
 // CompletionStream streams completion tokens to the writer as they arrive.
+// Models without streaming support write the whole completion at once.
 func (c *Client) CompletionStream(
 	ctx context.Context,
 	content string,
 	w io.Writer,
 ) (*core.Response, error) {
-	req := c.newBaseRequest(content)
+	if !c.caps.Streaming {
+		return core.WriteCompletion(ctx, c, content, w)
+	}
+
+	req := c.newBaseRequest(ctx, content)
 	req.Stream = true
 	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
 
@@ -140,9 +161,8 @@ func (c *Client) GetSummaryPrefix(ctx context.Context, content string) (*core.Re
 	var resp openai.ChatCompletionResponse
 	var err error
 
-	// For known models that don't support function calls, use regular completion directly
-	if checkOSeriesModels.MatchString(c.model) ||
-		strings.Contains(strings.ToLower(c.model), "deepseek") {
+	// For models that don't support function calls, use regular completion directly
+	if !c.caps.Tools {
 		resp, err = c.CreateChatCompletion(ctx, content)
 		if err != nil || len(resp.Choices) != 1 {
 			return nil, err
@@ -178,7 +198,67 @@ func (c *Client) GetSummaryPrefix(ctx context.Context, content string) (*core.Re
 	}, nil
 }
 
-var checkOSeriesModels = regexp.MustCompile(`o\d(-(mini|preview))?`)
+// GetReviewFindings is an API call to get structured review findings using function call.
+// Models without function call support answer with a regular completion instead.
+func (c *Client) GetReviewFindings(ctx context.Context, content string) (*core.Response, error) {
+	if !c.caps.Tools {
+		return c.Completion(ctx, content)
+	}
+
+	resp, err := c.CreateFunctionCall(ctx, content, ReviewFindingsFunc)
+	if isToolsUnsupported(err) {
+		// The model does not support function calls after all
+		return c.Completion(ctx, content)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("no choices returned from API")
+	}
+	if resp.Choices[0].FinishReason == openai.FinishReasonLength {
+		return nil, fmt.Errorf(
+			"the review was cut off at %d tokens, increase review.max_tokens",
+			resp.Usage.CompletionTokens,
+		)
+	}
+
+	msg := resp.Choices[0].Message
+	usage := convertUsage(resp.Usage)
+	if len(msg.ToolCalls) == 0 {
+		return &core.Response{
+			Content: msg.Content,
+			Usage:   usage,
+		}, nil
+	}
+
+	return &core.Response{
+		Content: msg.ToolCalls[len(msg.ToolCalls)-1].Function.Arguments,
+		Usage:   usage,
+	}, nil
+}
+
+// isToolsUnsupported reports whether err is the 400 response of a model or server
+// that does not support tools, as opposed to any other failure of the request.
+func isToolsUnsupported(err error) bool {
+	var (
+		apiErr *openai.APIError
+		reqErr *openai.RequestError
+		msg    string
+	)
+	switch {
+	case errors.As(err, &apiErr) && apiErr.HTTPStatusCode == http.StatusBadRequest:
+		msg = apiErr.Message
+	case errors.As(err, &reqErr) && reqErr.HTTPStatusCode == http.StatusBadRequest:
+		msg = string(reqErr.Body)
+	default:
+		return false
+	}
+
+	msg = strings.ToLower(msg)
+	return (strings.Contains(msg, "tool") || strings.Contains(msg, "function")) &&
+		strings.Contains(msg, "support")
+}
 
 // CreateChatCompletion is an API call to create a function call for a chat message.
 func (c *Client) CreateFunctionCall(
@@ -191,7 +271,7 @@ func (c *Client) CreateFunctionCall(
 		Function: &f,
 	}
 
-	req := c.newBaseRequest(content)
+	req := c.newBaseRequest(ctx, content)
 	req.Tools = []openai.Tool{t}
 	req.ToolChoice = openai.ToolChoice{
 		Type: openai.ToolTypeFunction,
@@ -208,7 +288,7 @@ func (c *Client) CreateChatCompletion(
 	ctx context.Context,
 	content string,
 ) (resp openai.ChatCompletionResponse, err error) {
-	return c.client.CreateChatCompletion(ctx, c.newBaseRequest(content))
+	return c.client.CreateChatCompletion(ctx, c.newBaseRequest(ctx, content))
 }
 
 // Completion is a method on the Client struct that takes a context.Context and a string argument
@@ -252,6 +332,8 @@ func New(opts ...Option) (*Client, error) {
 		topP:             cfg.topP,
 		frequencyPenalty: cfg.frequencyPenalty,
 		presencePenalty:  cfg.presencePenalty,
+		systemPrompt:     cfg.systemPrompt,
+		caps:             cfg.capabilities(),
 	}
 
 	// Create a new OpenAI config object with the given API token and other optional fields.
@@ -275,12 +357,16 @@ func New(opts ...Option) (*Client, error) {
 	}
 
 	// Inject x-app-name and x-app-version headers using core/transport.DefaultHeaderTransport
-	// Always wrap the proxy's httpClient.Transport
-	httpClient.Transport = &transport.DefaultHeaderTransport{
-		Origin:     httpClient.Transport,
-		Header:     nil,
-		AppName:    version.App,
-		AppVersion: version.Version,
+	// Always wrap the proxy's httpClient.Transport, retry rate limited or failed requests,
+	// and record or replay them when CODEGPT_CASSETTE is set
+	httpClient.Transport = &transport.RetryTransport{
+		Origin: &transport.DefaultHeaderTransport{
+			Origin:     transport.WithCassette(httpClient.Transport),
+			Header:     nil,
+			AppName:    version.App,
+			AppVersion: version.Version,
+		},
+		MaxRetries: cfg.maxRetries,
 	}
 
 	// Set the OpenAI client to use the default configuration with Azure-specific options, if the provider is Azure.
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/core/capability"
 
 	"github.com/sashabaranov/go-openai"
 )
@@ -19,6 +20,8 @@ const (
 	defaultModel       = openai.GPT3Dot5Turbo
 	defaultTemperature = 1.0
 	defaultTopP        = 1.0
+
+	defaultSystemPrompt = "You are a helpful assistant."
 )
 
 // Option is an interface that specifies instrumentation configuration options.
@@ -165,6 +168,33 @@ func WithFrequencyPenalty(val float32) Option {
 	})
 }
 
+// WithMaxRetries returns a new Option that sets how many times a request is retried
+// after a rate limit (429) or server error (5xx) response. Zero disables retries.
+func WithMaxRetries(val int) Option {
+	return optionFunc(func(c *config) {
+		c.maxRetries = val
+	})
+}
+
+// WithSystemPrompt returns a new Option that sets the system prompt sent with every request.
+// It defaults to "You are a helpful assistant." when empty.
+func WithSystemPrompt(val string) Option {
+	return optionFunc(func(c *config) {
+		if val == "" {
+			return
+		}
+		c.systemPrompt = val
+	})
+}
+
+// WithCapabilities returns a new Option that sets the capabilities of the model.
+// By default they are looked up from the built-in capability registry.
+func WithCapabilities(val capability.Model) Option {
+	return optionFunc(func(c *config) {
+		c.caps = &val
+	})
+}
+
 // config is a struct that stores configuration options for the instrumentation.
 type config struct {
 	baseURL     string
@@ -181,10 +211,13 @@ type config struct {
 	presencePenalty  float32
 	frequencyPenalty float32
 
-	provider   core.Platform
-	skipVerify bool
-	headers    []string
-	apiVersion string
+	provider     core.Platform
+	skipVerify   bool
+	headers      []string
+	apiVersion   string
+	maxRetries   int
+	systemPrompt string
+	caps         *capability.Model
 }
 
 // valid checks whether a config object is valid, returning an error if it is not.
@@ -202,15 +235,24 @@ func (cfg *config) valid() error {
 	return nil
 }
 
+// capabilities returns the configured model capabilities, or the built-in ones for the model.
+func (cfg *config) capabilities() capability.Model {
+	if cfg.caps != nil {
+		return *cfg.caps
+	}
+	return capability.Lookup(cfg.model)
+}
+
 // newConfig creates a new config object with default values, and applies the given options.
 func newConfig(opts ...Option) *config {
 	// Create a new config object with default values.
 	c := &config{
-		model:       defaultModel,
-		maxTokens:   defaultMaxTokens,
-		temperature: defaultTemperature,
-		provider:    core.OpenAI,
-		topP:        defaultTopP,
+		model:        defaultModel,
+		maxTokens:    defaultMaxTokens,
+		temperature:  defaultTemperature,
+		provider:     core.OpenAI,
+		topP:         defaultTopP,
+		systemPrompt: defaultSystemPrompt,
 	}
 
 	// Apply each of the given options to the config object.
@@ -0,0 +1,142 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/core"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestGetReviewFindings(t *testing.T) {
+	args := `{"summary":"ok","findings":[{"file":"main.go","start_line":3,"end_line":3,"severity":"low","category":"style","message":"rename"}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if len(req.Tools) != 1 || req.Tools[0].Function.Name != core.ReviewFindingsFunc {
+			t.Errorf("expected the review findings tool, got %+v", req.Tools)
+		}
+
+		resp := openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{
+				Message: openai.ChatCompletionMessage{
+					Role: openai.ChatMessageRoleAssistant,
+					ToolCalls: []openai.ToolCall{{
+						Type:     openai.ToolTypeFunction,
+						Function: openai.FunctionCall{Name: core.ReviewFindingsFunc, Arguments: args},
+					}},
+				},
+			}},
+			Usage: openai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithToken("test-token"),
+		WithModel("gpt-4o"),
+		WithBaseURL(server.URL+"/v1"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	resp, err := client.GetReviewFindings(context.Background(), "test prompt")
+	if err != nil {
+		t.Fatalf("GetReviewFindings failed: %v", err)
+	}
+	review, err := core.ParseReview(resp.Content)
+	if err != nil {
+		t.Fatalf("ParseReview failed: %v", err)
+	}
+	if len(review.Findings) != 1 || review.Findings[0].Message != "rename" {
+		t.Errorf("unexpected review: %+v", review)
+	}
+	if resp.Usage.TotalTokens != 15 {
+		t.Errorf("expected total tokens 15, got %d", resp.Usage.TotalTokens)
+	}
+}
+
+func TestGetReviewFindingsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		message  string
+		finish   openai.FinishReason
+		fallback bool
+		wantErr  string
+	}{
+		{"tools not supported", http.StatusBadRequest, "tools is not supported in this model", "", true, ""},
+		{"bad request", http.StatusBadRequest, "context length exceeded", "", false, "context length exceeded"},
+		{"unauthorized", http.StatusUnauthorized, "invalid api key", "", false, "invalid api key"},
+		{"truncated", http.StatusOK, "", openai.FinishReasonLength, false, "cut off at 5 tokens"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				var req openai.ChatCompletionRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				w.Header().Set("Content-Type", "application/json")
+
+				if len(req.Tools) > 0 && tt.status != http.StatusOK {
+					w.WriteHeader(tt.status)
+					_ = json.NewEncoder(w).Encode(openai.ErrorResponse{
+						Error: &openai.APIError{Message: tt.message, Type: "invalid_request_error"},
+					})
+					return
+				}
+				_ = json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+					Choices: []openai.ChatCompletionChoice{{
+						Message: openai.ChatCompletionMessage{
+							Role:    openai.ChatMessageRoleAssistant,
+							Content: `{"summary":"ok"}`,
+						},
+						FinishReason: tt.finish,
+					}},
+					Usage: openai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+				})
+			}))
+			defer server.Close()
+
+			client, err := New(
+				WithToken("test-token"),
+				WithModel("gpt-4o"),
+				WithBaseURL(server.URL+"/v1"),
+			)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			resp, err := client.GetReviewFindings(context.Background(), "test prompt")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+			} else if err != nil || resp.Content != `{"summary":"ok"}` {
+				t.Errorf("GetReviewFindings() = %+v, %v", resp, err)
+			}
+
+			want := 1
+			if tt.fallback {
+				want = 2
+			}
+			if requests != want {
+				t.Errorf("expected %d requests, got %d", want, requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package openai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/core"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestNewBaseRequestSystemPrompt(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		ctx    context.Context
+		expect string
+	}{
+		{
+			name:   "default system prompt",
+			ctx:    context.Background(),
+			expect: defaultSystemPrompt,
+		},
+		{
+			name:   "configured system prompt",
+			opts:   []Option{WithSystemPrompt("Follow the house style.")},
+			ctx:    context.Background(),
+			expect: "Follow the house style.",
+		},
+		{
+			name:   "per request override",
+			opts:   []Option{WithSystemPrompt("Follow the house style.")},
+			ctx:    core.WithSystemPrompt(context.Background(), "Review like a security expert."),
+			expect: "Review like a security expert.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(append([]Option{WithToken("test-token")}, tt.opts...)...)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			req := client.newBaseRequest(tt.ctx, "diff")
+			if len(req.Messages) != 2 {
+				t.Fatalf("expected 2 messages, got %d", len(req.Messages))
+			}
+			if req.Messages[0].Role != openai.ChatMessageRoleSystem ||
+				req.Messages[0].Content != tt.expect {
+				t.Errorf("unexpected system message: %+v", req.Messages[0])
+			}
+			if req.Messages[1].Role != openai.ChatMessageRoleUser ||
+				req.Messages[1].Content != "diff" {
+				t.Errorf("unexpected user message: %+v", req.Messages[1])
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/appleboy/CodeGPT/core"
+)
+
+// annotationLevel returns the workflow command of a finding with severity s.
+func annotationLevel(s core.Severity) string {
+	switch s {
+	case core.SeverityCritical, core.SeverityHigh:
+		return "error"
+	case core.SeverityMedium:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+// annotations renders every finding as a GitHub Actions workflow command, see
+// https://docs.github.com/actions/reference/workflow-commands-for-github-actions.
+func annotations(r *core.Review) string {
+	var sb strings.Builder
+	for _, f := range r.Findings {
+		var props []string
+		if f.File != "" {
+			props = append(props, "file="+escapeProperty(f.File))
+			if f.StartLine > 0 {
+				props = append(props, fmt.Sprintf("line=%d", f.StartLine))
+				if f.EndLine > f.StartLine {
+					props = append(props, fmt.Sprintf("endLine=%d", f.EndLine))
+				}
+			}
+		}
+		props = append(props, "title="+escapeProperty(fmt.Sprintf("CodeGPT %s (%s)", f.Category, f.Severity)))
+
+		message := strings.TrimSpace(f.Message)
+		if suggestion := strings.TrimSpace(f.Suggestion); suggestion != "" {
+			message += "\n\nSuggestion: " + suggestion
+		}
+		fmt.Fprintf(&sb, "::%s %s::%s\n",
+			annotationLevel(f.Severity), strings.Join(props, ","), escapeData(message))
+	}
+	return sb.String()
+}
+
+// escapeData escapes the message of a workflow command.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command.
+func escapeProperty(s string) string {
+	return strings.NewReplacer(
+		"%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C",
+	).Replace(s)
+}
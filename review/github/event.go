@@ -0,0 +1,65 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// PullRequest identifies the pull request to review.
+type PullRequest struct {
+	// Repository is the owner and name of the repository, e.g. appleboy/CodeGPT.
+	Repository string
+	Number     int
+	// HeadSHA is the commit the review comments refer to.
+	HeadSHA string
+}
+
+// event is the part of the GitHub Actions event payload describing a pull request.
+type event struct {
+	Number      int `json:"number"`
+	PullRequest *struct {
+		Number int `json:"number"`
+		Head   struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// ReadEvent reads the pull request from the event payload of a GitHub Actions
+// workflow, found at $GITHUB_EVENT_PATH. The workflow must be triggered by a
+// pull_request or pull_request_target event.
+func ReadEvent(path string) (*PullRequest, error) {
+	if path == "" {
+		return nil, errors.New("missing GitHub event payload, GITHUB_EVENT_PATH is not set")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the GitHub event payload: %w", err)
+	}
+
+	var e event
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("invalid GitHub event payload: %w", err)
+	}
+	if e.PullRequest == nil {
+		return nil, errors.New("the GitHub event is not a pull request event")
+	}
+
+	pr := &PullRequest{
+		Repository: e.Repository.FullName,
+		Number:     e.PullRequest.Number,
+		HeadSHA:    e.PullRequest.Head.SHA,
+	}
+	if pr.Number == 0 {
+		pr.Number = e.Number
+	}
+	if pr.Repository == "" || pr.Number == 0 {
+		return nil, errors.New("the GitHub event payload has no repository or pull request number")
+	}
+	return pr, nil
+}
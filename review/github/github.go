@@ -0,0 +1,267 @@
+// Package github posts code review findings to GitHub pull requests as a
+// review with inline comments, using the REST API.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/git"
+	"github.com/appleboy/CodeGPT/review"
+	"github.com/appleboy/CodeGPT/version"
+)
+
+// apiVersion is the version of the REST API the requests are written for.
+const apiVersion = "2022-11-28"
+
+// filesPerPage is the page size used to list the files of a pull request.
+const filesPerPage = 100
+
+// commentsPerPage is the page size used to list the review comments of a pull request.
+const commentsPerPage = 100
+
+// Client is a struct that represents a client for the GitHub REST API.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// APIError is returned when GitHub responds with a non-2xx status code.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("github error, status: %d, message: %s", e.StatusCode, e.Message)
+}
+
+// File is a file changed by a pull request, with its patch as shown by GitHub.
+// The patch is empty for binary and very large files.
+type File struct {
+	Filename string `json:"filename"`
+	Patch    string `json:"patch"`
+}
+
+// Comment is an inline comment of a pull request review.
+type Comment struct {
+	Path     string `json:"path"`
+	Position int    `json:"position"`
+	Body     string `json:"body"`
+}
+
+// PostedComment is a review comment already on the pull request. Line is the line
+// of the new version of the file, or 0 when the comment is outdated.
+type PostedComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Body string `json:"body"`
+}
+
+// Review is a pull request review with its inline comments.
+type Review struct {
+	CommitID string    `json:"commit_id,omitempty"`
+	Body     string    `json:"body"`
+	Event    string    `json:"event"`
+	Comments []Comment `json:"comments"`
+}
+
+// New creates a new Client instance with the provided options.
+func New(opts ...Option) (*Client, error) {
+	// Create a new config object with the given options.
+	cfg := newConfig(opts...)
+
+	// Validate the config object, returning an error if it is invalid.
+	if err := cfg.valid(); err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		httpClient: cfg.httpClient,
+		baseURL:    cfg.baseURL,
+		token:      cfg.token,
+	}, nil
+}
+
+// do sends a request with body encoded as JSON and decodes the response into out.
+// Non-2xx responses are converted to errors using the message returned by GitHub.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.baseURL, "/")+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("X-GitHub-Api-Version", apiVersion)
+	req.Header.Set("User-Agent", version.App)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("github error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		raw, _ := io.ReadAll(resp.Body)
+		apiErr := &APIError{
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(raw)),
+		}
+		var e struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(raw, &e) == nil && e.Message != "" {
+			apiErr.Message = e.Message
+		}
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode github response: %w", err)
+	}
+	return nil
+}
+
+// Files lists the files changed by the pull request.
+func (c *Client) Files(ctx context.Context, pr *PullRequest) ([]File, error) {
+	var files []File
+	for page := 1; ; page++ {
+		var batch []File
+		path := fmt.Sprintf("/repos/%s/pulls/%d/files?per_page=%d&page=%d",
+			pr.Repository, pr.Number, filesPerPage, page)
+		if err := c.do(ctx, http.MethodGet, path, nil, &batch); err != nil {
+			return nil, err
+		}
+		files = append(files, batch...)
+		if len(batch) < filesPerPage {
+			return files, nil
+		}
+	}
+}
+
+// Comments lists the review comments of the pull request.
+func (c *Client) Comments(ctx context.Context, pr *PullRequest) ([]PostedComment, error) {
+	var comments []PostedComment
+	for page := 1; ; page++ {
+		var batch []PostedComment
+		path := fmt.Sprintf("/repos/%s/pulls/%d/comments?per_page=%d&page=%d",
+			pr.Repository, pr.Number, commentsPerPage, page)
+		if err := c.do(ctx, http.MethodGet, path, nil, &batch); err != nil {
+			return nil, err
+		}
+		comments = append(comments, batch...)
+		if len(batch) < commentsPerPage {
+			return comments, nil
+		}
+	}
+}
+
+// CreateReview creates a review on the pull request and returns its URL.
+func (c *Client) CreateReview(ctx context.Context, pr *PullRequest, r *Review) (string, error) {
+	var resp struct {
+		HTMLURL string `json:"html_url"`
+	}
+	path := fmt.Sprintf("/repos/%s/pulls/%d/reviews", pr.Repository, pr.Number)
+	if err := c.do(ctx, http.MethodPost, path, r, &resp); err != nil {
+		return "", err
+	}
+	return resp.HTMLURL, nil
+}
+
+// PostReview posts r to the pull request as a review comment. Findings on lines
+// of the pull request diff become inline comments, unless an earlier run already
+// commented on the same line, the others are listed in the review body after
+// the summary. It returns the URL of the review.
+func (c *Client) PostReview(ctx context.Context, pr *PullRequest, r *core.Review) (string, error) {
+	files, err := c.Files(ctx, pr)
+	if err != nil {
+		return "", fmt.Errorf("failed to list the pull request files: %w", err)
+	}
+	comments, err := c.Comments(ctx, pr)
+	if err != nil {
+		return "", fmt.Errorf("failed to list the pull request comments: %w", err)
+	}
+	return c.CreateReview(ctx, pr, NewReview(r, files, comments, pr.HeadSHA))
+}
+
+// NewReview maps the findings of r to positions in the patches of files. Findings
+// on a line with a comment posted by an earlier review are left out.
+func NewReview(r *core.Review, files []File, posted []PostedComment, commitID string) *Review {
+	positions := make(map[string]map[int]int, len(files))
+	for _, f := range files {
+		positions[f.Filename] = Positions(f.Patch)
+	}
+	commented := map[string]bool{}
+	for _, c := range posted {
+		if c.Line > 0 && review.IsPosted(c.Body) {
+			commented[fmt.Sprintf("%s:%d", c.Path, c.Line)] = true
+		}
+	}
+
+	out := &Review{CommitID: commitID, Event: "COMMENT", Comments: []Comment{}}
+	var outside []core.Finding
+	for _, f := range r.Findings {
+		if line, position := findPosition(positions[f.File], f); position > 0 {
+			if commented[fmt.Sprintf("%s:%d", f.File, line)] {
+				continue
+			}
+			out.Comments = append(out.Comments, Comment{
+				Path:     f.File,
+				Position: position,
+				Body:     review.Comment(f),
+			})
+			continue
+		}
+		outside = append(outside, f)
+	}
+	out.Body = review.Body(r.Summary, outside)
+	return out
+}
+
+// findPosition returns the first line of f found in the patch and its position,
+// or a position of 0 when none of its lines were changed by the pull request.
+func findPosition(positions map[int]int, f core.Finding) (int, int) {
+	if f.StartLine <= 0 {
+		return 0, 0
+	}
+	for line := f.StartLine; line <= max(f.StartLine, f.EndLine); line++ {
+		if position, ok := positions[line]; ok {
+			return line, position
+		}
+	}
+	return 0, 0
+}
+
+// Positions maps the line numbers of the new version of a file to their
+// position in its patch, see git.PatchLine.
+func Positions(patch string) map[int]int {
+	positions := map[int]int{}
+	for _, l := range git.PatchLines(patch) {
+		if l.NewLine > 0 {
+			positions[l.NewLine] = l.Position
+		}
+	}
+	return positions
+}
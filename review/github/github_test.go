@@ -0,0 +1,198 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/review"
+)
+
+const testPatch = `@@ -1,2 +1,4 @@
+ package main
++
++func hello() {}
+ 
+@@ -10,2 +11,2 @@ func main() {
+-	world()
++	hello()`
+
+var testReview = &core.Review{
+	Summary: "Two issues found.",
+	Findings: []core.Finding{
+		{
+			File: "main.go", StartLine: 3, EndLine: 3, Severity: core.SeverityHigh,
+			Category: "bug", Message: "hello is never called", Suggestion: "Call it",
+		},
+		{
+			File: "main.go", StartLine: 5, EndLine: 12, Severity: core.SeverityLow,
+			Category: "style", Message: "Rename hello",
+		},
+		{
+			File: "main.go", StartLine: 30, Severity: core.SeverityMedium,
+			Category: "docs", Message: "Outside the diff",
+		},
+		{
+			File: "other.go", StartLine: 1, Severity: core.SeverityInfo,
+			Category: "docs", Message: "Not in the pull request",
+		},
+	},
+}
+
+func TestPositions(t *testing.T) {
+	got := Positions(testPatch)
+	want := map[int]int{1: 1, 2: 2, 3: 3, 4: 4, 11: 7}
+	if len(got) != len(want) {
+		t.Fatalf("Positions() = %v, want %v", got, want)
+	}
+	for line, position := range want {
+		if got[line] != position {
+			t.Errorf("Positions()[%d] = %d, want %d", line, got[line], position)
+		}
+	}
+}
+
+func TestPostReview(t *testing.T) {
+	var posted Review
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/appleboy/CodeGPT/pulls/42/files", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("unexpected authorization header %q", r.Header.Get("Authorization"))
+		}
+		_ = json.NewEncoder(w).Encode([]File{{Filename: "main.go", Patch: testPatch}})
+	})
+	mux.HandleFunc("GET /api/v3/repos/appleboy/CodeGPT/pulls/42/comments", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	})
+	mux.HandleFunc("POST /api/v3/repos/appleboy/CodeGPT/pulls/42/reviews", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&posted); err != nil {
+			t.Errorf("failed to decode the review: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"html_url": "https://github.example.com/appleboy/CodeGPT/pull/42#review-1"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := New(WithBaseURL(server.URL+"/api/v3/"), WithToken("test-token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr := &PullRequest{Repository: "appleboy/CodeGPT", Number: 42, HeadSHA: "abc123"}
+	url, err := client.PostReview(context.Background(), pr, testReview)
+	if err != nil {
+		t.Fatalf("PostReview failed: %v", err)
+	}
+	if url != "https://github.example.com/appleboy/CodeGPT/pull/42#review-1" {
+		t.Errorf("unexpected review URL %q", url)
+	}
+
+	if posted.CommitID != "abc123" || posted.Event != "COMMENT" {
+		t.Errorf("unexpected review: %+v", posted)
+	}
+	want := []Comment{
+		{
+			Path: "main.go", Position: 3,
+			Body: "**high** · bug\n\nhello is never called\n\n**Suggestion:** Call it\n\n" + review.Marker,
+		},
+		{Path: "main.go", Position: 7, Body: "**low** · style\n\nRename hello\n\n" + review.Marker},
+	}
+	if len(posted.Comments) != len(want) {
+		t.Fatalf("expected %d comments, got %+v", len(want), posted.Comments)
+	}
+	for i := range want {
+		if posted.Comments[i] != want[i] {
+			t.Errorf("comment %d = %+v, want %+v", i, posted.Comments[i], want[i])
+		}
+	}
+	for _, s := range []string{"Two issues found.", "`main.go:30` **medium**", "`other.go:1` **info**", review.Marker} {
+		if !strings.Contains(posted.Body, s) {
+			t.Errorf("expected %q in the review body:\n%s", s, posted.Body)
+		}
+	}
+}
+
+func TestNewReviewSkipsPosted(t *testing.T) {
+	files := []File{{Filename: "main.go", Patch: testPatch}}
+	posted := []PostedComment{
+		// Posted by an earlier run
+		{Path: "main.go", Line: 3, Body: "**high** · bug\n\nold wording\n\n" + review.Marker},
+		// Written by a reviewer
+		{Path: "main.go", Line: 11, Body: "Please rename this"},
+		// Outdated
+		{Path: "main.go", Line: 0, Body: review.Marker},
+	}
+
+	got := NewReview(testReview, files, posted, "abc123")
+	if len(got.Comments) != 1 || got.Comments[0].Position != 7 {
+		t.Errorf("expected only the comment on line 11, got %+v", got.Comments)
+	}
+	if strings.Contains(got.Body, "hello is never called") {
+		t.Errorf("expected the posted finding to be left out of the body:\n%s", got.Body)
+	}
+}
+
+func TestPostReviewError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+	}))
+	defer server.Close()
+
+	client, err := New(WithBaseURL(server.URL), WithToken("test-token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.PostReview(context.Background(), &PullRequest{Repository: "a/b", Number: 1}, testReview)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "Not Found" {
+		t.Errorf("expected a not found API error, got %v", err)
+	}
+}
+
+func TestNewMissingToken(t *testing.T) {
+	if _, err := New(); !errors.Is(err, errorsMissingToken) {
+		t.Errorf("expected a missing token error, got %v", err)
+	}
+}
+
+func TestReadEvent(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	pr, err := ReadEvent(write("pull_request.json", `{
+		"number": 42,
+		"pull_request": {"number": 42, "head": {"sha": "abc123"}},
+		"repository": {"full_name": "appleboy/CodeGPT"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *pr != (PullRequest{Repository: "appleboy/CodeGPT", Number: 42, HeadSHA: "abc123"}) {
+		t.Errorf("unexpected pull request: %+v", pr)
+	}
+
+	for name, content := range map[string]string{
+		"push.json":    `{"ref": "refs/heads/main", "repository": {"full_name": "appleboy/CodeGPT"}}`,
+		"invalid.json": `{`,
+	} {
+		if _, err := ReadEvent(write(name, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := ReadEvent(""); err == nil {
+		t.Error("expected an error without an event path")
+	}
+}
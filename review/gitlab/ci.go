@@ -0,0 +1,36 @@
+package gitlab
+
+import (
+	"errors"
+	"strconv"
+)
+
+// MergeRequest identifies the merge request to review.
+type MergeRequest struct {
+	// Project is the ID or the full path of the project, e.g. 42 or group/project.
+	Project string
+	// IID is the number of the merge request within its project.
+	IID int
+}
+
+// ReadCI reads the merge request from the predefined variables of a GitLab CI
+// merge request pipeline, looked up with getenv.
+func ReadCI(getenv func(string) string) (*MergeRequest, error) {
+	// The merge request belongs to the target project, which differs from
+	// CI_PROJECT_ID in pipelines of merge requests from forks
+	project, iid := getenv("CI_MERGE_REQUEST_PROJECT_ID"), getenv("CI_MERGE_REQUEST_IID")
+	if project == "" {
+		project = getenv("CI_PROJECT_ID")
+	}
+	if project == "" || iid == "" {
+		return nil, errors.New(
+			"missing CI_PROJECT_ID or CI_MERGE_REQUEST_IID, run the job in a merge request pipeline",
+		)
+	}
+
+	n, err := strconv.Atoi(iid)
+	if err != nil || n <= 0 {
+		return nil, errors.New("invalid CI_MERGE_REQUEST_IID " + strconv.Quote(iid))
+	}
+	return &MergeRequest{Project: project, IID: n}, nil
+}
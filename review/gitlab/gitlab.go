@@ -0,0 +1,337 @@
+// Package gitlab posts code review findings to GitLab merge requests as
+// discussions on the changed lines, using the REST API.
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/git"
+	"github.com/appleboy/CodeGPT/review"
+	"github.com/appleboy/CodeGPT/version"
+)
+
+// diffsPerPage is the page size used to list the diffs of a merge request.
+const diffsPerPage = 100
+
+// discussionsPerPage is the page size used to list the discussions of a merge request.
+const discussionsPerPage = 100
+
+// Client is a struct that represents a client for the GitLab REST API.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// APIError is returned when GitLab responds with a non-2xx status code.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("gitlab error, status: %d, message: %s", e.StatusCode, e.Message)
+}
+
+// DiffRefs are the commits a merge request diff is computed from.
+type DiffRefs struct {
+	BaseSHA  string `json:"base_sha"`
+	HeadSHA  string `json:"head_sha"`
+	StartSHA string `json:"start_sha"`
+}
+
+// Diff is a file changed by a merge request, with its patch as shown by GitLab.
+type Diff struct {
+	OldPath string `json:"old_path"`
+	NewPath string `json:"new_path"`
+	Diff    string `json:"diff"`
+}
+
+// Position locates a discussion on a line of the merge request diff. Added
+// lines only have a new line, unchanged lines have both.
+type Position struct {
+	PositionType string `json:"position_type"`
+	BaseSHA      string `json:"base_sha"`
+	HeadSHA      string `json:"head_sha"`
+	StartSHA     string `json:"start_sha"`
+	OldPath      string `json:"old_path"`
+	NewPath      string `json:"new_path"`
+	OldLine      int    `json:"old_line,omitempty"`
+	NewLine      int    `json:"new_line,omitempty"`
+}
+
+// Discussion is a thread started on a line of the merge request diff.
+type Discussion struct {
+	Body     string    `json:"body"`
+	Position *Position `json:"position,omitempty"`
+}
+
+// Note is a comment of a discussion already on the merge request.
+type Note struct {
+	Body     string    `json:"body"`
+	Position *Position `json:"position"`
+}
+
+// Thread is a discussion already on the merge request, with its notes.
+type Thread struct {
+	Notes []Note `json:"notes"`
+}
+
+// mergeRequest is the part of a merge request used to post a review.
+type mergeRequest struct {
+	WebURL   string   `json:"web_url"`
+	DiffRefs DiffRefs `json:"diff_refs"`
+}
+
+// New creates a new Client instance with the provided options.
+func New(opts ...Option) (*Client, error) {
+	// Create a new config object with the given options.
+	cfg := newConfig(opts...)
+
+	// Validate the config object, returning an error if it is invalid.
+	if err := cfg.valid(); err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		httpClient: cfg.httpClient,
+		baseURL:    cfg.baseURL,
+		token:      cfg.token,
+	}, nil
+}
+
+// do sends a request with body encoded as JSON and decodes the response into out.
+// Non-2xx responses are converted to errors using the message returned by GitLab.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.baseURL, "/")+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	req.Header.Set("User-Agent", version.App)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("gitlab error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		raw, _ := io.ReadAll(resp.Body)
+		apiErr := &APIError{
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(raw)),
+		}
+		// GitLab returns the message as a string, or as a list of errors per field
+		var e struct {
+			Message json.RawMessage `json:"message"`
+			Error   string          `json:"error"`
+		}
+		if json.Unmarshal(raw, &e) == nil {
+			var message string
+			switch {
+			case json.Unmarshal(e.Message, &message) == nil && message != "":
+				apiErr.Message = message
+			case len(e.Message) > 0:
+				apiErr.Message = string(e.Message)
+			case e.Error != "":
+				apiErr.Message = e.Error
+			}
+		}
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode gitlab response: %w", err)
+	}
+	return nil
+}
+
+// path returns the API path of the merge request followed by suffix.
+func (mr *MergeRequest) path(suffix string) string {
+	return fmt.Sprintf("/projects/%s/merge_requests/%d%s", url.PathEscape(mr.Project), mr.IID, suffix)
+}
+
+// Diffs lists the files changed by the merge request.
+func (c *Client) Diffs(ctx context.Context, mr *MergeRequest) ([]Diff, error) {
+	var diffs []Diff
+	for page := 1; ; page++ {
+		var batch []Diff
+		path := mr.path(fmt.Sprintf("/diffs?per_page=%d&page=%d", diffsPerPage, page))
+		if err := c.do(ctx, http.MethodGet, path, nil, &batch); err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, batch...)
+		if len(batch) < diffsPerPage {
+			return diffs, nil
+		}
+	}
+}
+
+// Discussions lists the discussions of the merge request.
+func (c *Client) Discussions(ctx context.Context, mr *MergeRequest) ([]Thread, error) {
+	var threads []Thread
+	for page := 1; ; page++ {
+		var batch []Thread
+		path := mr.path(fmt.Sprintf("/discussions?per_page=%d&page=%d", discussionsPerPage, page))
+		if err := c.do(ctx, http.MethodGet, path, nil, &batch); err != nil {
+			return nil, err
+		}
+		threads = append(threads, batch...)
+		if len(batch) < discussionsPerPage {
+			return threads, nil
+		}
+	}
+}
+
+// CreateDiscussion starts a discussion on the merge request, on a diff line
+// when d has a position.
+func (c *Client) CreateDiscussion(ctx context.Context, mr *MergeRequest, d *Discussion) error {
+	return c.do(ctx, http.MethodPost, mr.path("/discussions"), d, nil)
+}
+
+// PostReview posts r to the merge request. Findings on lines of the merge
+// request diff start discussions on those lines, unless an earlier run already
+// started one on the same line, the summary and the other findings are posted
+// as a single note. It returns the URL of the merge request.
+func (c *Client) PostReview(ctx context.Context, mr *MergeRequest, r *core.Review) (string, error) {
+	var info mergeRequest
+	if err := c.do(ctx, http.MethodGet, mr.path(""), nil, &info); err != nil {
+		return "", fmt.Errorf("failed to get the merge request: %w", err)
+	}
+	diffs, err := c.Diffs(ctx, mr)
+	if err != nil {
+		return "", fmt.Errorf("failed to list the merge request diffs: %w", err)
+	}
+	threads, err := c.Discussions(ctx, mr)
+	if err != nil {
+		return "", fmt.Errorf("failed to list the merge request discussions: %w", err)
+	}
+
+	discussions, outside := newDiscussions(r, diffs, threads, info.DiffRefs)
+	for i, d := range discussions {
+		err := c.CreateDiscussion(ctx, mr, d.discussion)
+		// GitLab rejects lines it cannot place, list them in the note instead
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+			outside = append(outside, discussions[i].finding)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	note := &Discussion{Body: review.Body(r.Summary, outside)}
+	if err := c.CreateDiscussion(ctx, mr, note); err != nil {
+		return "", err
+	}
+	return info.WebURL, nil
+}
+
+// lineDiscussion is a discussion started for a finding.
+type lineDiscussion struct {
+	finding    core.Finding
+	discussion *Discussion
+}
+
+// newDiscussions maps the findings of r to lines of diffs. It returns the
+// discussions of the findings found in the diffs, and the other findings.
+// Findings on a line with a discussion posted by an earlier review are left out.
+func newDiscussions(
+	r *core.Review,
+	diffs []Diff,
+	threads []Thread,
+	refs DiffRefs,
+) ([]lineDiscussion, []core.Finding) {
+	files := make(map[string]Diff, len(diffs))
+	lines := make(map[string]map[int]git.PatchLine, len(diffs))
+	for _, d := range diffs {
+		files[d.NewPath] = d
+		lines[d.NewPath] = map[int]git.PatchLine{}
+		for _, l := range git.PatchLines(d.Diff) {
+			if l.NewLine > 0 {
+				lines[d.NewPath][l.NewLine] = l
+			}
+		}
+	}
+
+	commented := map[string]bool{}
+	for _, t := range threads {
+		if len(t.Notes) == 0 {
+			continue
+		}
+		if n := t.Notes[0]; n.Position != nil && n.Position.NewLine > 0 && review.IsPosted(n.Body) {
+			commented[fmt.Sprintf("%s:%d", n.Position.NewPath, n.Position.NewLine)] = true
+		}
+	}
+
+	var (
+		discussions []lineDiscussion
+		outside     []core.Finding
+	)
+	for _, f := range r.Findings {
+		line, ok := findLine(lines[f.File], f)
+		if !ok {
+			outside = append(outside, f)
+			continue
+		}
+		if commented[fmt.Sprintf("%s:%d", f.File, line.NewLine)] {
+			continue
+		}
+		discussions = append(discussions, lineDiscussion{
+			finding: f,
+			discussion: &Discussion{
+				Body: review.Comment(f),
+				Position: &Position{
+					PositionType: "text",
+					BaseSHA:      refs.BaseSHA,
+					HeadSHA:      refs.HeadSHA,
+					StartSHA:     refs.StartSHA,
+					OldPath:      files[f.File].OldPath,
+					NewPath:      f.File,
+					OldLine:      line.OldLine,
+					NewLine:      line.NewLine,
+				},
+			},
+		})
+	}
+	return discussions, outside
+}
+
+// findLine returns the first line of f found in the diff, if any.
+func findLine(lines map[int]git.PatchLine, f core.Finding) (git.PatchLine, bool) {
+	if f.StartLine <= 0 {
+		return git.PatchLine{}, false
+	}
+	for n := f.StartLine; n <= max(f.StartLine, f.EndLine); n++ {
+		if line, ok := lines[n]; ok {
+			return line, true
+		}
+	}
+	return git.PatchLine{}, false
+}
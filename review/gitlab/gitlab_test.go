@@ -0,0 +1,224 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/review"
+)
+
+const testDiff = `@@ -1,2 +1,4 @@
+ package main
++
++func hello() {}
+ 
+@@ -10,2 +11,2 @@ func main() {
+-	world()
++	hello()`
+
+var testReview = &core.Review{
+	Summary: "Three issues found.",
+	Findings: []core.Finding{
+		{
+			File: "main.go", StartLine: 3, EndLine: 3, Severity: core.SeverityHigh,
+			Category: "bug", Message: "hello is never called", Suggestion: "Call it",
+		},
+		{
+			File: "main.go", StartLine: 4, Severity: core.SeverityLow,
+			Category: "style", Message: "Unchanged line",
+		},
+		{
+			File: "main.go", StartLine: 11, Severity: core.SeverityMedium,
+			Category: "bug", Message: "Rejected by GitLab",
+		},
+		{
+			File: "other.go", StartLine: 1, Severity: core.SeverityInfo,
+			Category: "docs", Message: "Not in the merge request",
+		},
+	},
+}
+
+func TestPostReview(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		discussions []Discussion
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v4/projects/group%2Fproject/merge_requests/5", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "test-token" {
+			t.Errorf("unexpected token header %q", r.Header.Get("PRIVATE-TOKEN"))
+		}
+		_, _ = w.Write([]byte(`{
+			"web_url": "https://gitlab.example.com/group/project/-/merge_requests/5",
+			"diff_refs": {"base_sha": "base", "head_sha": "head", "start_sha": "start"}
+		}`))
+	})
+	mux.HandleFunc("GET /api/v4/projects/group%2Fproject/merge_requests/5/diffs", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]Diff{{OldPath: "main.go", NewPath: "main.go", Diff: testDiff}})
+	})
+	mux.HandleFunc("GET /api/v4/projects/group%2Fproject/merge_requests/5/discussions", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	})
+	mux.HandleFunc("POST /api/v4/projects/group%2Fproject/merge_requests/5/discussions", func(w http.ResponseWriter, r *http.Request) {
+		var d Discussion
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+			t.Errorf("failed to decode the discussion: %v", err)
+		}
+		if d.Position != nil && d.Position.NewLine == 11 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"message": {"base": ["line_code can't be blank"]}}`))
+			return
+		}
+		mu.Lock()
+		discussions = append(discussions, d)
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": "1"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := New(WithBaseURL(server.URL+"/api/v4"), WithToken("test-token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr := &MergeRequest{Project: "group/project", IID: 5}
+	url, err := client.PostReview(context.Background(), mr, testReview)
+	if err != nil {
+		t.Fatalf("PostReview failed: %v", err)
+	}
+	if url != "https://gitlab.example.com/group/project/-/merge_requests/5" {
+		t.Errorf("unexpected merge request URL %q", url)
+	}
+
+	if len(discussions) != 3 {
+		t.Fatalf("expected 2 line discussions and a note, got %+v", discussions)
+	}
+	want := []Position{
+		{
+			PositionType: "text", BaseSHA: "base", HeadSHA: "head", StartSHA: "start",
+			OldPath: "main.go", NewPath: "main.go", NewLine: 3,
+		},
+		{
+			PositionType: "text", BaseSHA: "base", HeadSHA: "head", StartSHA: "start",
+			OldPath: "main.go", NewPath: "main.go", OldLine: 2, NewLine: 4,
+		},
+	}
+	for i := range want {
+		if discussions[i].Position == nil || *discussions[i].Position != want[i] {
+			t.Errorf("discussion %d position = %+v, want %+v", i, discussions[i].Position, want[i])
+		}
+	}
+	if !strings.Contains(discussions[0].Body, "**Suggestion:** Call it") {
+		t.Errorf("unexpected discussion body:\n%s", discussions[0].Body)
+	}
+
+	note := discussions[2]
+	if note.Position != nil {
+		t.Errorf("expected the summary without a position, got %+v", note.Position)
+	}
+	for _, s := range []string{"Three issues found.", "`main.go:11` **medium**", "`other.go:1` **info**", review.Marker} {
+		if !strings.Contains(note.Body, s) {
+			t.Errorf("expected %q in the note:\n%s", s, note.Body)
+		}
+	}
+}
+
+func TestNewDiscussionsSkipsPosted(t *testing.T) {
+	diffs := []Diff{{OldPath: "main.go", NewPath: "main.go", Diff: testDiff}}
+	threads := []Thread{
+		// Posted by an earlier run
+		{Notes: []Note{{Body: "old wording\n\n" + review.Marker, Position: &Position{NewPath: "main.go", NewLine: 3}}}},
+		// Started by a reviewer
+		{Notes: []Note{{Body: "Why unchanged?", Position: &Position{NewPath: "main.go", NewLine: 4}}}},
+		// The summary of an earlier run
+		{Notes: []Note{{Body: review.Marker}}},
+	}
+
+	discussions, outside := newDiscussions(testReview, diffs, threads, DiffRefs{})
+	if len(discussions) != 2 || discussions[0].discussion.Position.NewLine != 4 ||
+		discussions[1].discussion.Position.NewLine != 11 {
+		t.Errorf("expected the discussions on lines 4 and 11, got %+v", discussions)
+	}
+	if len(outside) != 1 || outside[0].File != "other.go" {
+		t.Errorf("expected only other.go outside the diff, got %+v", outside)
+	}
+}
+
+func TestPostReviewError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message": "401 Unauthorized"}`))
+	}))
+	defer server.Close()
+
+	client, err := New(WithBaseURL(server.URL), WithToken("test-token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.PostReview(context.Background(), &MergeRequest{Project: "1", IID: 1}, testReview)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized ||
+		apiErr.Message != "401 Unauthorized" {
+		t.Errorf("expected an unauthorized API error, got %v", err)
+	}
+}
+
+func TestNewMissingToken(t *testing.T) {
+	if _, err := New(); !errors.Is(err, errorsMissingToken) {
+		t.Errorf("expected a missing token error, got %v", err)
+	}
+}
+
+func TestReadCI(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want *MergeRequest
+	}{
+		{
+			name: "merge request pipeline",
+			env:  map[string]string{"CI_PROJECT_ID": "42", "CI_MERGE_REQUEST_IID": "7"},
+			want: &MergeRequest{Project: "42", IID: 7},
+		},
+		{
+			name: "fork",
+			env: map[string]string{
+				"CI_PROJECT_ID": "43", "CI_MERGE_REQUEST_PROJECT_ID": "42", "CI_MERGE_REQUEST_IID": "7",
+			},
+			want: &MergeRequest{Project: "42", IID: 7},
+		},
+		{
+			name: "branch pipeline",
+			env:  map[string]string{"CI_PROJECT_ID": "42"},
+		},
+		{
+			name: "invalid iid",
+			env:  map[string]string{"CI_PROJECT_ID": "42", "CI_MERGE_REQUEST_IID": "seven"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr, err := ReadCI(func(key string) string { return tt.env[key] })
+			if tt.want == nil {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", mr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *mr != *tt.want {
+				t.Errorf("ReadCI() = %+v, want %+v", mr, tt.want)
+			}
+		})
+	}
+}
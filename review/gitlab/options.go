@@ -0,0 +1,91 @@
+package gitlab
+
+import (
+	"errors"
+	"net/http"
+)
+
+var errorsMissingToken = errors.New("missing GitLab token")
+
+// DefaultBaseURL is the REST API endpoint of gitlab.com. Self-managed instances
+// use https://<host>/api/v4 instead.
+const DefaultBaseURL = "https://gitlab.com/api/v4"
+
+// Option is an interface that specifies instrumentation configuration options.
+type Option interface {
+	apply(*config)
+}
+
+// optionFunc is a type of function that can be used to implement the Option interface.
+// It takes a pointer to a config struct and modifies it.
+type optionFunc func(*config)
+
+// Ensure that optionFunc satisfies the Option interface.
+var _ Option = (*optionFunc)(nil)
+
+// The apply method of optionFunc type is implemented here to modify the config struct based on the function passed.
+func (o optionFunc) apply(c *config) {
+	o(c)
+}
+
+// WithBaseURL returns a new Option that sets the base URL of the REST API.
+// It defaults to DefaultBaseURL when empty.
+func WithBaseURL(val string) Option {
+	return optionFunc(func(c *config) {
+		if val == "" {
+			return
+		}
+		c.baseURL = val
+	})
+}
+
+// WithToken returns a new Option that sets the token used to authenticate the requests.
+func WithToken(val string) Option {
+	return optionFunc(func(c *config) {
+		c.token = val
+	})
+}
+
+// WithHTTPClient returns a new Option that sets the HTTP client used to send the requests.
+func WithHTTPClient(val *http.Client) Option {
+	return optionFunc(func(c *config) {
+		if val == nil {
+			return
+		}
+		c.httpClient = val
+	})
+}
+
+// config is a struct that stores configuration options for the instrumentation.
+type config struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// valid checks whether a config object is valid, returning an error if it is not.
+func (cfg *config) valid() error {
+	if cfg.token == "" {
+		return errorsMissingToken
+	}
+
+	// If all checks pass, return nil (no error).
+	return nil
+}
+
+// newConfig creates a new config object with default values, and applies the given options.
+func newConfig(opts ...Option) *config {
+	// Create a new config object with default values.
+	c := &config{
+		baseURL:    DefaultBaseURL,
+		httpClient: http.DefaultClient,
+	}
+
+	// Apply each of the given options to the config object.
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+
+	// Return the resulting config object.
+	return c
+}
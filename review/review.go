@@ -0,0 +1,145 @@
+// Package review renders the findings of a code review for other tools, such
+// as code scanning, CI job logs and pull request comments.
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/appleboy/CodeGPT/core"
+)
+
+// Format is the layout of a rendered review.
+type Format string
+
+// Supported review formats.
+const (
+	// Table prints the findings as one table per file, for terminals.
+	Table Format = "table"
+	// JSON prints the review as indented JSON.
+	JSON Format = "json"
+	// Markdown prints the findings as one table per file, e.g. for pull request comments.
+	Markdown Format = "markdown"
+	// SARIF prints a SARIF 2.1.0 log with a rule per category, for code scanning.
+	SARIF Format = "sarif"
+	// GitHub prints workflow commands that annotate the changed lines in GitHub Actions.
+	GitHub Format = "github"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{Table, JSON, Markdown, SARIF, GitHub}
+
+// IsValid reports whether f is a supported format.
+func (f Format) IsValid() bool {
+	return slices.Contains(Formats, f)
+}
+
+// Write renders r to w in format f. The Table format is left to the caller,
+// since it depends on the terminal.
+func Write(w io.Writer, r *core.Review, f Format) error {
+	switch f {
+	case JSON:
+		return writeJSON(w, r)
+	case Markdown:
+		_, err := io.WriteString(w, markdown(r))
+		return err
+	case SARIF:
+		return writeJSON(w, newSARIF(r))
+	case GitHub:
+		_, err := io.WriteString(w, annotations(r))
+		return err
+	default:
+		return fmt.Errorf("unsupported review format %q", f)
+	}
+}
+
+// writeJSON writes v to w as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// markdown renders the summary followed by a table of findings for every file.
+// The findings are expected to be sorted by file.
+func markdown(r *core.Review) string {
+	var sb strings.Builder
+	sb.WriteString("## Code Review\n\n")
+	if summary := strings.TrimSpace(r.Summary); summary != "" {
+		sb.WriteString(summary + "\n")
+	}
+	if len(r.Findings) == 0 {
+		sb.WriteString("\nNo findings.\n")
+		return sb.String()
+	}
+
+	for i, f := range r.Findings {
+		if i == 0 || f.File != r.Findings[i-1].File {
+			fmt.Fprintf(&sb, "\n### `%s`\n\n", f.File)
+			sb.WriteString("| Severity | Lines | Category | Message | Suggestion |\n")
+			sb.WriteString("| --- | --- | --- | --- | --- |\n")
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s |\n",
+			f.Severity, f.Lines(), f.Category, markdownCell(f.Message), markdownCell(f.Suggestion))
+	}
+	return sb.String()
+}
+
+// oneLine collapses the whitespace of s, including newlines.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// markdownCell escapes s to fit a single Markdown table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// Marker ends every comment posted by codegpt review. It is hidden when the
+// Markdown is rendered, and tells earlier comments apart from the reviewers' ones.
+const Marker = "<!-- codegpt-review -->"
+
+// IsPosted reports whether body is a comment posted by codegpt review.
+func IsPosted(body string) bool {
+	return strings.Contains(body, Marker)
+}
+
+// Comment renders f as the Markdown body of an inline review comment.
+func Comment(f core.Finding) string {
+	body := fmt.Sprintf("**%s** · %s\n\n%s", f.Severity, f.Category, strings.TrimSpace(f.Message))
+	if suggestion := strings.TrimSpace(f.Suggestion); suggestion != "" {
+		body += "\n\n**Suggestion:** " + suggestion
+	}
+	return body + "\n\n" + Marker
+}
+
+// Body renders the Markdown body of a review posted to a pull request: the
+// summary, followed by the findings that could not be attached to a line.
+func Body(summary string, findings []core.Finding) string {
+	var sb strings.Builder
+	sb.WriteString("## Code Review\n\n")
+	if summary = strings.TrimSpace(summary); summary != "" {
+		sb.WriteString(summary + "\n")
+	}
+	if len(findings) > 0 {
+		sb.WriteString("\n### Other Findings\n\n")
+	}
+	for _, f := range findings {
+		location := f.File
+		if lines := f.Lines(); lines != "" {
+			location += ":" + lines
+		}
+		line := fmt.Sprintf("- `%s` **%s** · %s: %s", location, f.Severity, f.Category, oneLine(f.Message))
+		if f.Suggestion != "" {
+			line += " Suggestion: " + oneLine(f.Suggestion)
+		}
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("\n" + Marker + "\n")
+	return sb.String()
+}
@@ -0,0 +1,146 @@
+package review
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/appleboy/CodeGPT/core"
+)
+
+var testReview = &core.Review{
+	Summary: "Two issues found.",
+	Findings: []core.Finding{
+		{
+			File: "cmd/ping.go", StartLine: 12, EndLine: 14, Severity: core.SeverityCritical,
+			Category: "security", Message: "The ip is passed to the shell", Suggestion: "Use exec.Command",
+		},
+		{
+			File: "cmd/ping.go", StartLine: 20, Severity: core.SeverityLow,
+			Category: "style", Message: "Ignored error,\nreturn it | wrap it",
+		},
+		{
+			File: "main.go", Severity: core.SeverityMedium,
+			Category: "typo", Message: "100% wrong",
+		},
+	},
+}
+
+func TestFormatIsValid(t *testing.T) {
+	for _, f := range Formats {
+		if !f.IsValid() {
+			t.Errorf("expected %s to be valid", f)
+		}
+	}
+	if Format("xml").IsValid() {
+		t.Error("expected xml to be invalid")
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testReview, JSON); err != nil {
+		t.Fatal(err)
+	}
+	got, err := core.ParseReview(buf.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Summary != testReview.Summary || len(got.Findings) != len(testReview.Findings) {
+		t.Errorf("unexpected review: %+v", got)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testReview, Markdown); err != nil {
+		t.Fatal(err)
+	}
+	want := "## Code Review\n\nTwo issues found.\n\n" +
+		"### `cmd/ping.go`\n\n" +
+		"| Severity | Lines | Category | Message | Suggestion |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| critical | 12-14 | security | The ip is passed to the shell | Use exec.Command |\n" +
+		"| low | 20 | style | Ignored error,<br>return it \\| wrap it |  |\n" +
+		"\n### `main.go`\n\n" +
+		"| Severity | Lines | Category | Message | Suggestion |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| medium |  | typo | 100% wrong |  |\n"
+	if got := buf.String(); got != want {
+		t.Errorf("markdown =\n%s\nwant\n%s", got, want)
+	}
+
+	buf.Reset()
+	if err := Write(&buf, &core.Review{Summary: "Fine."}, Markdown); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "No findings.") {
+		t.Errorf("expected no findings:\n%s", buf.String())
+	}
+}
+
+func TestWriteGitHub(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testReview, GitHub); err != nil {
+		t.Fatal(err)
+	}
+	want := "::error file=cmd/ping.go,line=12,endLine=14,title=CodeGPT security (critical)::" +
+		"The ip is passed to the shell%0A%0ASuggestion: Use exec.Command\n" +
+		"::notice file=cmd/ping.go,line=20,title=CodeGPT style (low)::Ignored error,%0Areturn it | wrap it\n" +
+		"::warning file=main.go,title=CodeGPT typo (medium)::100%25 wrong\n"
+	if got := buf.String(); got != want {
+		t.Errorf("annotations =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testReview, SARIF); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log: %+v", log)
+	}
+	run := log.Runs[0]
+	if n := len(run.Tool.Driver.Rules); n != len(core.ReviewCategories)+1 {
+		t.Errorf("expected a rule per category and one for typo, got %d", n)
+	}
+	if len(run.Results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(run.Results))
+	}
+
+	for i, want := range []struct {
+		ruleID    string
+		level     string
+		startLine int
+		endLine   int
+	}{
+		{"codegpt/security", "error", 12, 14},
+		{"codegpt/style", "note", 20, 0},
+		{"codegpt/typo", "warning", 1, 0},
+	} {
+		r := run.Results[i]
+		if r.RuleID != want.ruleID || run.Tool.Driver.Rules[r.RuleIndex].ID != want.ruleID {
+			t.Errorf("result %d: rule %s (index %d), want %s", i, r.RuleID, r.RuleIndex, want.ruleID)
+		}
+		if r.Level != want.level {
+			t.Errorf("result %d: level %s, want %s", i, r.Level, want.level)
+		}
+		region := r.Locations[0].PhysicalLocation.Region
+		if region.StartLine != want.startLine || region.EndLine != want.endLine {
+			t.Errorf("result %d: region %+v, want %d-%d", i, region, want.startLine, want.endLine)
+		}
+	}
+}
+
+func TestWriteUnsupported(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, testReview, Table); err == nil {
+		t.Error("expected an error for the table format")
+	}
+}
@@ -0,0 +1,157 @@
+package review
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/appleboy/CodeGPT/core"
+	"github.com/appleboy/CodeGPT/version"
+)
+
+// SARIF 2.1.0 identifiers, see https://docs.oasis-open.org/sarif/sarif/v2.1.0.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// rulePrefix prefixes the category of a finding to make its rule ID.
+	rulePrefix = "codegpt/"
+)
+
+// sarifLog is the subset of a SARIF log written for a review.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+// sarifLevel returns the SARIF level of a finding with severity s.
+func sarifLevel(s core.Severity) string {
+	switch s {
+	case core.SeverityCritical, core.SeverityHigh:
+		return "error"
+	case core.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// newSARIF converts r to a SARIF log with a rule per review category. The rules
+// of the known categories always come first, so their indexes are stable.
+func newSARIF(r *core.Review) *sarifLog {
+	categories := slices.Clone(core.ReviewCategories)
+	for _, f := range r.Findings {
+		if !slices.Contains(categories, f.Category) {
+			categories = append(categories, f.Category)
+		}
+	}
+
+	rules := make([]sarifRule, 0, len(categories))
+	for _, c := range categories {
+		name := c
+		if name == "" {
+			name = "general"
+		}
+		rules = append(rules, sarifRule{
+			ID:               rulePrefix + name,
+			Name:             strings.ToUpper(name[:1]) + name[1:],
+			ShortDescription: sarifMessage{Text: "CodeGPT review finding: " + name},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(r.Findings))
+	for _, f := range r.Findings {
+		i := slices.Index(categories, f.Category)
+		message := strings.TrimSpace(f.Message)
+		if suggestion := strings.TrimSpace(f.Suggestion); suggestion != "" {
+			message += "\n\nSuggestion: " + suggestion
+		}
+
+		result := sarifResult{
+			RuleID:    rules[i].ID,
+			RuleIndex: i,
+			Level:     sarifLevel(f.Severity),
+			Message:   sarifMessage{Text: message},
+		}
+		if f.File != "" {
+			// Code scanning requires a start line, fall back to the top of the file
+			region := sarifRegion{StartLine: max(f.StartLine, 1)}
+			if f.EndLine > region.StartLine {
+				region.EndLine = f.EndLine
+			}
+			result.Locations = []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: f.File, URIBaseID: "%SRCROOT%"},
+					Region:           region,
+				},
+			}}
+		}
+		results = append(results, result)
+	}
+
+	return &sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           version.App,
+				Version:        version.Version,
+				InformationURI: "https://github.com/appleboy/CodeGPT",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+}
@@ -51,6 +51,12 @@ func processTemplate(name string, data map[string]any) (*bytes.Buffer, error) {
 	return &tpl, nil
 }
 
+// HasTemplate reports whether a template with the given name has been loaded.
+func HasTemplate(name string) bool {
+	_, ok := templates[name]
+	return ok
+}
+
 // GetTemplateByString returns the parsed template as a string.
 // It returns an error if the template processing fails.
 func GetTemplateByString(name string, data map[string]any) (string, error) {
@@ -111,6 +111,23 @@ func TestLoadTemplatesFromDir(t *testing.T) {
 	}
 }
 
+func TestHasTemplate(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(tempDir+"/exists.tmpl", []byte("ok"), 0o600); err != nil {
+		t.Fatalf("Failed to create test template file: %v", err)
+	}
+	if err := LoadTemplatesFromDir(tempDir); err != nil {
+		t.Fatalf("Failed to load templates from directory: %v", err)
+	}
+
+	if !HasTemplate("exists.tmpl") {
+		t.Error("expected exists.tmpl to be loaded")
+	}
+	if HasTemplate("missing.tmpl") {
+		t.Error("expected missing.tmpl not to be loaded")
+	}
+}
+
 // Create an embedded filesystem with a sample template
 //
 //go:embed templates/*